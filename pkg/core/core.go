@@ -2,8 +2,10 @@ package core
 
 // Import all subpackages
 import (
+    "github.com/ryuux05/godex/pkg/core/decoder"
     "github.com/ryuux05/godex/pkg/core/processor"
     "github.com/ryuux05/godex/pkg/core/rpc"
+    "github.com/ryuux05/godex/pkg/core/sink"
     "github.com/ryuux05/godex/pkg/core/types"
 )
 
@@ -13,24 +15,75 @@ import (
 type Processor = processor.Processor
 type Options = processor.Options
 type ChainInfo = processor.ChainInfo
+type ChainConfig = processor.ChainConfig
 type FetchMode = processor.FetchMode
+type StartFrom = processor.StartFrom
+type ContractFilter = processor.ContractFilter
+type ConcurrencyScaling = processor.ConcurrencyScaling
+type Backpressure = processor.Backpressure
+type ProcessorStats = processor.ProcessorStats
+type ChainStats = processor.ChainStats
+type ChainHealth = processor.ChainHealth
+type ChainError = processor.ChainError
+type CompletionInfo = processor.CompletionInfo
+type EventIndexer = processor.EventIndexer
+type Enricher = processor.Enricher
 
 const (
-    FetchModeLogs     FetchMode = processor.FetchModeLogs
-    FetchModeReceipts FetchMode = processor.FetchModeReceipts
+    FetchModeLogs       FetchMode = processor.FetchModeLogs
+    FetchModeReceipts   FetchMode = processor.FetchModeReceipts
+    FetchModeTxReceipts FetchMode = processor.FetchModeTxReceipts
+)
+
+const (
+    StartFromGenesis StartFrom = processor.StartFromGenesis
+    StartFromHead    StartFrom = processor.StartFromHead
+    StartFromBlock   StartFrom = processor.StartFromBlock
+)
+
+const (
+    BackpressureBlock Backpressure = processor.BackpressureBlock
+    BackpressureDrop  Backpressure = processor.BackpressureDrop
+)
+
+const (
+    CompletionReasonCompleted = processor.CompletionReasonCompleted
+    CompletionReasonCanceled  = processor.CompletionReasonCanceled
+    CompletionReasonFailed    = processor.CompletionReasonFailed
 )
 // Decoder types
+type Decoder = decoder.Decoder
+type StandardDecoder = decoder.StandardDecoder
+type ChainDecoder = decoder.ChainDecoder
 
 // Sink types
+type Sink = sink.Sink
+type MemorySink = sink.MemorySink
+type BatchWriter = sink.BatchWriter
+type EventKey = sink.EventKey
 
 // RPC types
 type RPC = rpc.RPC
 type HTTPRPC = rpc.HTTPRPC
+type MethodOverride = rpc.MethodOverride
+type IPCRPC = rpc.IPCRPC
+type QuorumRPC = rpc.QuorumRPC
+type TxReceiptsRPC = rpc.TxReceiptsRPC
+type CallRPC = rpc.CallRPC
+type FilteredReceiptsRPC = rpc.FilteredReceiptsRPC
+type ChainIDRPC = rpc.ChainIDRPC
+type BackoffStrategy = rpc.BackoffStrategy
+type ExponentialBackoff = rpc.ExponentialBackoff
+type ConstantBackoff = rpc.ConstantBackoff
 
 // Blockchain types
 type Log = types.Log
+type Event = types.Event
 type Block = types.Block
 type Receipt = types.Receipt
+type Transaction = types.Transaction
+type BlockWithTxs = types.BlockWithTxs
+type CallMsg = types.CallMsg
 type Filter = types.Filter
 type Address = types.Address
 
@@ -38,6 +91,20 @@ type Address = types.Address
 
 // Processor
 var NewProcessor = processor.NewProcessor
+var NewEventIndexer = processor.NewEventIndexer
+
+// Decoder
+var NewStandardDecoder = decoder.NewStandardDecoder
+var NewStandardDecoderWithPrelude = decoder.NewStandardDecoderWithPrelude
+var NewChainDecoder = decoder.NewChainDecoder
+
+// Sink
+var NewMemorySink = sink.NewMemorySink
+var NewBatchWriter = sink.NewBatchWriter
+var KeyOf = sink.KeyOf
 
 // RPC
 var NewHTTPRPC = rpc.NewHTTPRPC
+var NewIPCRPC = rpc.NewIPCRPC
+var NewQuorumRPC = rpc.NewQuorumRPC
+var WaitForBlock = rpc.WaitForBlock
@@ -0,0 +1,109 @@
+package decoder
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// DecodeBatch decodes logs with the events registered under the ABI name.
+// The logs are grouped by topic0 so each event is resolved once per batch.
+// Events are returned in the order of logs, the logs that don't match an event are skipped like in Decode.
+func (d *StandardDecoder) DecodeBatch(name string, logs []types.Log) ([]*types.Event, error) {
+	abi, exists := d.events[name]
+	if !exists {
+		return nil, fmt.Errorf("ABI '%s' not found", name)
+	}
+
+	results := make([]*types.Event, len(logs))
+	if err := decodeInto(abi, logs, results); err != nil {
+		return nil, err
+	}
+	return compactEvents(results), nil
+}
+
+// DecodeBatchConcurrent is DecodeBatch split over concurrency goroutines,
+// for CPU bound decoding of large batches. Events are returned in the order of logs.
+func (d *StandardDecoder) DecodeBatchConcurrent(name string, logs []types.Log, concurrency int) ([]*types.Event, error) {
+	abi, exists := d.events[name]
+	if !exists {
+		return nil, fmt.Errorf("ABI '%s' not found", name)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Contiguous chunks, each worker writes its own part of results
+	results := make([]*types.Event, len(logs))
+	chunk := (len(logs) + concurrency - 1) / concurrency
+	errs := make([]error, concurrency)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		start := w * chunk
+		if start >= len(logs) {
+			break
+		}
+		end := min(start+chunk, len(logs))
+
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			if err := decodeInto(abi, logs[start:end], results[start:end]); err != nil {
+				errs[w] = err
+			}
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	// The error of the first failing log in input order
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return compactEvents(results), nil
+}
+
+// decodeInto decodes logs grouped by topic0 and stores each event at the index of its log in results
+func decodeInto(abi map[string][]*registeredEvent, logs []types.Log, results []*types.Event) error {
+	groups := make(map[string][]int)
+	for i, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		groups[log.Topics[0]] = append(groups[log.Topics[0]], i)
+	}
+
+	firstErr := -1
+	var err error
+	for topic, indexes := range groups {
+		candidates, ok := abi[topic]
+		if !ok {
+			continue
+		}
+		for _, i := range indexes {
+			event, decodeErr := decodeEvent(matchArity(candidates, len(logs[i].Topics)), logs[i])
+			if decodeErr != nil {
+				if firstErr < 0 || i < firstErr {
+					firstErr, err = i, fmt.Errorf("error decoding log %s/%s: %w", logs[i].TransactionHash, logs[i].LogIndex, decodeErr)
+				}
+				continue
+			}
+			results[i] = event
+		}
+	}
+	return err
+}
+
+// compactEvents drops the nil entries of the logs that didn't decode, keeping the order
+func compactEvents(results []*types.Event) []*types.Event {
+	events := make([]*types.Event, 0, len(results))
+	for _, event := range results {
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
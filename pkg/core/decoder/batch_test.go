@@ -0,0 +1,156 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMixedDecoder(t testing.TB) *StandardDecoder {
+	decoder := NewStandardDecoder()
+	for _, abi := range []string{erc20Transfer_ABI, approvalEvent_ABI, boolEvent_ABI, stringEvent_ABI} {
+		if err := decoder.RegisterABI("mixed", abi); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return decoder
+}
+
+// mixedLogs cycles through Transfer, Approval, BoolEvent, StringEvent and a log of an unknown event
+func mixedLogs(n int) []types.Log {
+	transfer := benchmarkTransferLog()
+
+	approval := benchmarkTransferLog()
+	approval.Topics = []string{utils.FunctionSignatureToTopic("Approval(address,address,uint256)"), approval.Topics[1], approval.Topics[2]}
+
+	boolEvent := types.Log{
+		Topics: []string{utils.FunctionSignatureToTopic("BoolEvent(bool)")},
+		Data:   "0x0000000000000000000000000000000000000000000000000000000000000001",
+	}
+
+	stringEvent := types.Log{
+		Topics: []string{utils.FunctionSignatureToTopic("StringEvent(string)")},
+		Data:   "0x0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000b48656c6c6f20576f726c64000000000000000000000000000000000000000000",
+	}
+
+	unknown := benchmarkTransferLog()
+	unknown.Topics = []string{utils.FunctionSignatureToTopic("Unknown()")}
+
+	templates := []types.Log{transfer, approval, boolEvent, stringEvent, unknown}
+	logs := make([]types.Log, n)
+	for i := range logs {
+		logs[i] = templates[i%len(templates)]
+		logs[i].BlockNumber = "0x1"
+		logs[i].LogIndex = fmt.Sprintf("0x%x", i)
+	}
+	return logs
+}
+
+func TestDecodeBatch_MixedEventsInOrder(t *testing.T) {
+	decoder := newMixedDecoder(t)
+	logs := mixedLogs(20)
+
+	events, err := decoder.DecodeBatch("mixed", logs)
+	assert.NoError(t, err)
+	// Every fifth log is the unknown event
+	if !assert.Len(t, events, 16) {
+		return
+	}
+
+	expectedTypes := []string{"Transfer", "Approval", "BoolEvent", "StringEvent"}
+	for i, event := range events {
+		assert.Equal(t, expectedTypes[i%4], event.EventType)
+		// Input order is kept: the skipped logs leave a gap in the log indexes
+		assert.Equal(t, uint64(i/4*5+i%4), event.LogIndex)
+	}
+	assert.Equal(t, big.NewInt(100000000), events[0].Fields["value"])
+	assert.Equal(t, true, events[2].Fields["success"])
+	assert.Equal(t, "Hello World", events[3].Fields["message"])
+
+	// Same as decoding one by one
+	for i, log := range logs {
+		event, err := decoder.Decode("mixed", log)
+		assert.NoError(t, err)
+		if i%5 == 4 {
+			assert.Nil(t, event)
+			continue
+		}
+		assert.Equal(t, events[i/5*4+i%5], event)
+	}
+}
+
+func TestDecodeBatchConcurrent_SameAsSerial(t *testing.T) {
+	decoder := newMixedDecoder(t)
+	logs := mixedLogs(1003)
+
+	serial, err := decoder.DecodeBatch("mixed", logs)
+	assert.NoError(t, err)
+
+	for _, concurrency := range []int{0, 1, 3, 8, 2000} {
+		concurrent, err := decoder.DecodeBatchConcurrent("mixed", logs, concurrency)
+		assert.NoError(t, err)
+		assert.Equal(t, serial, concurrent, "concurrency %d", concurrency)
+	}
+
+	events, err := decoder.DecodeBatchConcurrent("mixed", nil, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestDecodeBatch_Errors(t *testing.T) {
+	decoder := newMixedDecoder(t)
+
+	_, err := decoder.DecodeBatch("missing", mixedLogs(5))
+	assert.Error(t, err)
+	_, err = decoder.DecodeBatchConcurrent("missing", mixedLogs(5), 2)
+	assert.Error(t, err)
+
+	// The first malformed log in input order is reported
+	logs := mixedLogs(10)
+	logs[6].BlockNumber = "0xzz"
+	logs[6].TransactionHash = "0xfirst"
+	logs[8].BlockNumber = "0xzz"
+	logs[8].TransactionHash = "0xsecond"
+
+	_, err = decoder.DecodeBatch("mixed", logs)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "0xfirst")
+	}
+	_, err = decoder.DecodeBatchConcurrent("mixed", logs, 3)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "0xfirst")
+	}
+}
+
+func BenchmarkDecodeBatch_Mixed50k(b *testing.B) {
+	decoder := newMixedDecoder(b)
+	logs := mixedLogs(50_000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decoder.DecodeBatch("mixed", logs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBatchConcurrent_Mixed50k(b *testing.B) {
+	decoder := newMixedDecoder(b)
+	logs := mixedLogs(50_000)
+
+	for _, concurrency := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decoder.DecodeBatchConcurrent("mixed", logs, concurrency); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package decoder
+
+import (
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// ChainDecoder tries several decoders in order, e.g. a StandardDecoder for the known ABIs
+// then a custom decoder as a fallback for everything else.
+type ChainDecoder struct {
+	decoders []Decoder
+}
+
+var _ Decoder = (*ChainDecoder)(nil)
+
+// NewChainDecoder returns a decoder trying decoders in the given order, the first one has precedence.
+func NewChainDecoder(decoders ...Decoder) *ChainDecoder {
+	return &ChainDecoder{decoders: decoders}
+}
+
+// Decode returns the event of the first decoder decoding log.
+// A decoder skipping the log with nil, nil passes it to the next one, and the log is skipped
+// when all of them skip it. An error is returned right away without trying the next decoders,
+// so a log malformed for a decoder is reported rather than decoded by a fallback.
+func (c *ChainDecoder) Decode(name string, log types.Log) (*types.Event, error) {
+	for _, d := range c.decoders {
+		event, err := d.Decode(name, log)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			return event, nil
+		}
+	}
+	return nil, nil
+}
+
+// DecodeBatch decodes every log like Decode, events are returned in the order of logs without the skipped ones.
+func (c *ChainDecoder) DecodeBatch(name string, logs []types.Log) ([]*types.Event, error) {
+	events := make([]*types.Event, 0, len(logs))
+	for _, log := range logs {
+		event, err := c.Decode(name, log)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
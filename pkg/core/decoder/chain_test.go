@@ -0,0 +1,80 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// catchAllDecoder decodes any log as a raw event, or fails on the logs of failOn
+type catchAllDecoder struct {
+	failOn string
+}
+
+func (d catchAllDecoder) Decode(name string, log types.Log) (*types.Event, error) {
+	if log.Address == d.failOn {
+		return nil, fmt.Errorf("malformed log of %s", log.Address)
+	}
+	return &types.Event{EventType: "Raw", Address: log.Address, Fields: types.EventFields{"topic0": log.Topics[0], "data": log.Data}}, nil
+}
+
+func (d catchAllDecoder) DecodeBatch(name string, logs []types.Log) ([]*types.Event, error) {
+	return nil, fmt.Errorf("not used")
+}
+
+func TestChainDecoder_FallsBackOnSkip(t *testing.T) {
+	standard := NewStandardDecoder()
+	assert.NoError(t, standard.RegisterABI("token", erc20Transfer_ABI))
+	decoder := NewChainDecoder(standard, catchAllDecoder{})
+
+	// Known event, decoded by the standard decoder
+	transfer := benchmarkTransferLog()
+	event, err := decoder.Decode("token", transfer)
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer", event.EventType)
+	assert.Equal(t, big.NewInt(100000000), event.Fields["value"])
+
+	// Unknown event, skipped by the standard decoder then decoded by the fallback
+	unknown := benchmarkTransferLog()
+	unknown.Topics = []string{"0x01"}
+	event, err = decoder.Decode("token", unknown)
+	assert.NoError(t, err)
+	assert.Equal(t, "Raw", event.EventType)
+	assert.Equal(t, "0x01", event.Fields["topic0"])
+
+	events, err := decoder.DecodeBatch("token", []types.Log{unknown, transfer})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "Raw", events[0].EventType)
+	assert.Equal(t, "Transfer", events[1].EventType)
+}
+
+func TestChainDecoder_SkipAndErrors(t *testing.T) {
+	standard := NewStandardDecoder()
+	assert.NoError(t, standard.RegisterABI("token", erc20Transfer_ABI))
+
+	// Skipped by every decoder
+	unknown := benchmarkTransferLog()
+	unknown.Topics = []string{"0x01"}
+	event, err := NewChainDecoder(standard).Decode("token", unknown)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	event, err = NewChainDecoder().Decode("token", unknown)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	// An error is returned without trying the fallback
+	decoder := NewChainDecoder(catchAllDecoder{failOn: unknown.Address}, standard)
+	_, err = decoder.Decode("token", benchmarkTransferLog())
+	assert.ErrorContains(t, err, "malformed log")
+	_, err = decoder.DecodeBatch("token", []types.Log{unknown})
+	assert.Error(t, err)
+
+	// The first decoder has precedence
+	event, err = NewChainDecoder(catchAllDecoder{}, standard).Decode("token", benchmarkTransferLog())
+	assert.NoError(t, err)
+	assert.Equal(t, "Raw", event.EventType)
+}
@@ -5,8 +5,10 @@ import (
 )
 
 type Decoder interface {
-	// Decode is a function to transform log into strcutural event
-	Decode(log types.Log) (*types.Event, error)
-	// Batch decoding
-	DecodeBatch(logs []types.Log) (*[]types.Event, error)
-}
\ No newline at end of file
+	// Decode is a function to transform log into strcutural event using the ABI registered as name
+	Decode(name string, log types.Log) (*types.Event, error)
+	// Batch decoding, events are returned in the order of logs
+	DecodeBatch(name string, logs []types.Log) ([]*types.Event, error)
+}
+
+var _ Decoder = (*StandardDecoder)(nil)
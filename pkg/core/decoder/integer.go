@@ -0,0 +1,121 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// intType parses an integer ABI type such as "uint8" or "int256" into its signedness and width in bits.
+// "uint" and "int" are the aliases of uint256 and int256. ok is false for the other types.
+func intType(typ string) (signed bool, bits int, ok bool) {
+	width, isUint := strings.CutPrefix(typ, "uint")
+	if !isUint {
+		var isInt bool
+		width, isInt = strings.CutPrefix(typ, "int")
+		if !isInt {
+			return false, 0, false
+		}
+		signed = true
+	}
+	if width == "" {
+		return signed, 256, true
+	}
+
+	bits, err := strconv.Atoi(width)
+	if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+		return false, 0, false
+	}
+	return signed, bits, true
+}
+
+// integerWord decodes the ABI word of an integer of the given width and checks that the value fits in it:
+// the padding bytes must be zero, or 0xff for a negative signed value (sign extension).
+func integerWord(hexData string, signed bool, bits int) ([32]byte, bool, error) {
+	if len(hexData) != 64 {
+		return [32]byte{}, false, fmt.Errorf("invalid integer hex length: expected 64, got %d", len(hexData))
+	}
+	word, ok := decodeWord(hexData)
+	if !ok {
+		return word, false, fmt.Errorf("failed to parse hex as integer: %s", hexData)
+	}
+
+	pad := 32 - bits/8
+	negative := signed && word[pad]&0x80 != 0
+	fill := byte(0)
+	if negative {
+		fill = 0xff
+	}
+	for _, b := range word[:pad] {
+		if b != fill {
+			return word, false, fmt.Errorf("value out of range for %d bits integer", bits)
+		}
+	}
+	return word, negative, nil
+}
+
+// decodeUintN decodes an unsigned integer of at most 64 bits
+func decodeUintN(hexData string, bits int) (uint64, error) {
+	word, _, err := integerWord(hexData, false, bits)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range word[24:] {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// decodeIntN decodes a signed integer of at most 64 bits
+func decodeIntN(hexData string, bits int) (int64, error) {
+	word, _, err := integerWord(hexData, true, bits)
+	if err != nil {
+		return 0, err
+	}
+	// The padding is the sign extension, so the last 8 bytes are the two's complement of the value
+	var v uint64
+	for _, b := range word[24:] {
+		v = v<<8 | uint64(b)
+	}
+	return int64(v), nil
+}
+
+// decodeBigIntN decodes an integer wider than 64 bits, negative values of signed integers included
+func decodeBigIntN(hexData string, signed bool, bits int) (*big.Int, error) {
+	word, negative, err := integerWord(hexData, signed, bits)
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetBytes(word[32-bits/8:])
+	if negative {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	}
+	return v, nil
+}
+
+// decodeInteger decodes an integer ABI word by type, with the same Go type in topics and data:
+// uint8 to uint64 as uint64, int8 to int64 as int64, and the wider ones as *big.Int
+func decodeInteger(hexData string, signed bool, bits int) (any, error) {
+	switch {
+	case bits > 64:
+		return decodeBigIntN(hexData, signed, bits)
+	case signed:
+		return decodeIntN(hexData, bits)
+	default:
+		return decodeUintN(hexData, bits)
+	}
+}
+
+// decodeIntegerWords decodes the words of an integer array into []uint64, []int64 or []*big.Int
+func decodeIntegerWords(words []string, signed bool, bits int) (any, error) {
+	switch {
+	case bits > 64:
+		return decodeWords(words, func(w string) (*big.Int, error) { return decodeBigIntN(w, signed, bits) })
+	case signed:
+		return decodeWords(words, func(w string) (int64, error) { return decodeIntN(w, bits) })
+	default:
+		return decodeWords(words, func(w string) (uint64, error) { return decodeUintN(w, bits) })
+	}
+}
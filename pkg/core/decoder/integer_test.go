@@ -0,0 +1,118 @@
+package decoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// intWord encodes v as a 32 bytes two's complement ABI word
+func intWord(v *big.Int) string {
+	if v.Sign() < 0 {
+		v = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	s := v.Text(16)
+	return strings.Repeat("0", 64-len(s)) + s
+}
+
+func bigFromString(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+func TestDecode_IntegerTypes(t *testing.T) {
+	tests := []struct {
+		typ      string
+		value    *big.Int
+		expected any
+	}{
+		{"uint8", big.NewInt(255), uint64(255)},
+		{"uint16", big.NewInt(65535), uint64(65535)},
+		{"uint24", big.NewInt(1 << 20), uint64(1 << 20)},
+		{"uint32", big.NewInt(1 << 31), uint64(1 << 31)},
+		{"uint64", new(big.Int).SetUint64(1<<64 - 1), uint64(1<<64 - 1)},
+		{"uint128", bigFromString("340282366920938463463374607431768211455"), bigFromString("340282366920938463463374607431768211455")},
+		{"uint256", new(big.Int).Lsh(big.NewInt(1), 255), new(big.Int).Lsh(big.NewInt(1), 255)},
+		{"uint", big.NewInt(42), big.NewInt(42)},
+		{"int8", big.NewInt(-128), int64(-128)},
+		{"int16", big.NewInt(-1), int64(-1)},
+		{"int32", big.NewInt(123456), int64(123456)},
+		{"int64", big.NewInt(-1 << 63), int64(-1 << 63)},
+		{"int128", bigFromString("-170141183460469231731687303715884105728"), bigFromString("-170141183460469231731687303715884105728")},
+		{"int256", big.NewInt(-1), big.NewInt(-1)},
+		{"int", big.NewInt(7), big.NewInt(7)},
+	}
+
+	for _, tt := range tests {
+		for _, indexed := range []bool{true, false} {
+			signature := "Value(" + tt.typ + ")"
+			decoder := NewStandardDecoder()
+			assert.NoError(t, decoder.RegisterEvent("test", signature, []bool{indexed}))
+
+			log := types.Log{
+				// uint and int are hashed as uint256 and int256
+				Topics:      []string{utils.FunctionSignatureToTopic("Value(" + utils.CanonicalType(tt.typ) + ")")},
+				Data:        "0x",
+				BlockNumber: "0x1",
+				LogIndex:    "0x0",
+			}
+			if indexed {
+				log.Topics = append(log.Topics, "0x"+intWord(tt.value))
+			} else {
+				log.Data = "0x" + intWord(tt.value)
+			}
+
+			event, err := decoder.Decode("test", log)
+			assert.NoError(t, err)
+			if assert.NotNil(t, event, "%s indexed=%v", tt.typ, indexed) {
+				// Same Go type whether the value is in the topics or the data
+				assert.IsType(t, tt.expected, event.Fields["arg0"], "%s indexed=%v", tt.typ, indexed)
+				assert.Equal(t, tt.expected, event.Fields["arg0"], "%s indexed=%v", tt.typ, indexed)
+			}
+		}
+	}
+}
+
+func TestDecode_IntegerOutOfRange(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEvent("test", "Value(uint8)", []bool{false}))
+
+	// 256 doesn't fit in a uint8, the log doesn't match the layout
+	event, err := decoder.Decode("test", types.Log{
+		Topics:      []string{utils.FunctionSignatureToTopic("Value(uint8)")},
+		Data:        "0x" + intWord(big.NewInt(256)),
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestDecode_IntegerArrays(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEvent("test", "Values(int8[],uint16[],int256[])", []bool{false, false, false}))
+
+	word := func(v int64) string { return intWord(big.NewInt(v)) }
+	data := "0x" +
+		word(96) + word(192) + word(288) + // offsets
+		word(2) + word(-1) + word(5) + // int8[]
+		word(2) + word(1) + word(65535) + // uint16[]
+		word(1) + word(-2) // int256[]
+
+	event, err := decoder.Decode("test", types.Log{
+		Topics:      []string{utils.FunctionSignatureToTopic("Values(int8[],uint16[],int256[])")},
+		Data:        data,
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	})
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, []int64{-1, 5}, event.Fields["arg0"])
+		assert.Equal(t, []uint64{1, 65535}, event.Fields["arg1"])
+		assert.Equal(t, []*big.Int{big.NewInt(-2)}, event.Fields["arg2"])
+	}
+}
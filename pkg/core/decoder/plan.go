@@ -0,0 +1,241 @@
+package decoder
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// fieldSource tells where the value of a field is located in the log
+type fieldSource int
+
+const (
+	// The value is an indexed parameter stored in log.Topics
+	sourceTopic fieldSource = iota
+	// The value is a static type stored inline in log.Data, one 32 bytes word unless it is a fixed array or a tuple
+	sourceDataWord
+	// The value is a dynamic type, the word in log.Data is an offset to the tail
+	sourceDataDynamic
+)
+
+// fieldStep describes how to decode a single event field
+type fieldStep struct {
+	// Key of the field in Event.Fields
+	name string
+	// Solidity type of the field
+	typ string
+	source fieldSource
+	// Topic index for sourceTopic, byte offset of the head word in data otherwise
+	index int
+	// Number of head words of a sourceDataWord field, more than one for a static fixed array or tuple
+	words int
+}
+
+// decodePlan is the precomputed layout of an event.
+// It is built once at RegisterABI time so Decode only has to execute the steps in order.
+type decodePlan struct {
+	steps []fieldStep
+	// Number of topics required, including the topic0 signature hash
+	topicCount int
+	// headSize is the size in bytes of the head of log.Data, one word per non-indexed parameter
+	// except the static fixed arrays and tuples taking the words of their elements.
+	// 0 when every parameter is indexed and log.Data is "0x".
+	headSize int
+	// unsized is true when a data field follows a static type of unknown size, e.g. a tuple of an ABI JSON
+	// without its components: its offset is unknown so the logs of the event are skipped
+	unsized bool
+	// keepRawOnUnknownType keeps the raw word of an unsupported type, see StandardDecoder.KeepRawOnUnknownType
+	keepRawOnUnknownType bool
+}
+
+// registeredEvent bundles the event definition with its decode plan
+type registeredEvent struct {
+	*types.EventDefinition
+	plan decodePlan
+	// signatureHash is the topic hash of Signature, computed at registration
+	signatureHash string
+	// scales are the decimals of the fields registered with RegisterScale, nil when none
+	scales map[string]uint8
+}
+
+func buildDecodePlan(inputs []types.EventInput) decodePlan {
+	plan := decodePlan{
+		steps:      make([]fieldStep, 0, len(inputs)),
+		topicCount: 1,
+	}
+
+	dataOffset := 0
+	// A static type of unknown size was met, the offsets of the next data fields are unknown
+	unknownSize := false
+	for _, input := range inputs {
+		step := fieldStep{name: input.Name, typ: input.Type}
+
+		switch {
+		case input.Indexed:
+			step.source = sourceTopic
+			step.index = plan.topicCount
+			plan.topicCount++
+		case isDynamicType(input.Type):
+			// Only its offset is in the head, relative to the start of data, so a dynamic param
+			// takes one head word wherever it is and the static params after it keep their slot
+			plan.unsized = plan.unsized || unknownSize
+			step.source = sourceDataDynamic
+			step.index = dataOffset
+			dataOffset += 32
+		default:
+			// A static fixed array or tuple is inline, the params after it start after its last word
+			plan.unsized = plan.unsized || unknownSize
+			words, ok := staticWords(input.Type)
+			if !ok {
+				unknownSize = true
+				words = 1
+			}
+			step.source = sourceDataWord
+			step.index = dataOffset
+			step.words = words
+			dataOffset += 32 * words
+		}
+
+		plan.steps = append(plan.steps, step)
+	}
+	plan.headSize = dataOffset
+
+	return plan
+}
+
+// missingData is true when the event has non-indexed parameters but log has no data at all,
+// unlike data too short for them
+func (p *decodePlan) missingData(log types.Log) bool {
+	return p.headSize > 0 && len(log.Topics) >= p.topicCount && (log.Data == "" || log.Data == "0x")
+}
+
+// execute decodes the log fields following the plan.
+// ok is false when the log doesn't match the layout of the event.
+func (p *decodePlan) execute(log types.Log) (types.EventFields, bool) {
+	if len(log.Topics) < p.topicCount || p.unsized {
+		return nil, false
+	}
+
+	fields := make(types.EventFields, len(p.steps))
+	for _, step := range p.steps {
+		var value any
+		var err error
+		// The 32 bytes word of the field, without 0x
+		var word string
+
+		switch step.source {
+		case sourceTopic:
+			topic := log.Topics[step.index]
+			if len(topic) < 2 {
+				return nil, false
+			}
+			word = topic[2:]
+			value, err = decodeByType(word, step.typ)
+
+		case sourceDataWord:
+			// Here we times 2 because each byte is represented by 2 character
+			// Pass clean data without the 0x format
+			hexStart := 2 + (step.index * 2)
+			hexEnd := hexStart + 64*step.words
+			if hexEnd > len(log.Data) {
+				return nil, false
+			}
+			word = log.Data[hexStart:hexEnd]
+			value, err = decodeByType(word, step.typ)
+
+		case sourceDataDynamic:
+			// Offset is in byte
+			hexStart := 2 + (step.index * 2)
+			if hexStart+64 > len(log.Data) {
+				return nil, false
+			}
+			word = log.Data[hexStart : hexStart+64]
+			value, err = decodeByTypeWithOffset(log.Data[2:], step.index, step.typ)
+		}
+
+		if errors.Is(err, errUnknownType) && p.keepRawOnUnknownType {
+			value, err = "0x"+word, nil
+		}
+		if err != nil {
+			return nil, false
+		}
+		fields[step.name] = value
+	}
+
+	return fields, true
+}
+
+// isDynamicType reports whether typ is encoded in the tail of log.Data, the head only has its offset:
+// string, bytes, dynamic arrays, and the fixed arrays and tuples with a dynamic element
+func isDynamicType(typ string) bool {
+	if typ == "string" || typ == "bytes" || strings.HasSuffix(typ, "[]") {
+		return true
+	}
+	if elem, _, ok := fixedArray(typ); ok {
+		return isDynamicType(elem)
+	}
+	if components, ok := tupleComponents(typ); ok {
+		for _, c := range components {
+			if isDynamicType(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// staticWords returns the number of 32 bytes words of the static type typ.
+// ok is false when its size is unknown, e.g. "tuple" without its components.
+func staticWords(typ string) (int, bool) {
+	if elem, length, ok := fixedArray(typ); ok {
+		words, ok := staticWords(elem)
+		return words * length, ok
+	}
+	if components, ok := tupleComponents(typ); ok {
+		total := 0
+		for _, c := range components {
+			words, ok := staticWords(c)
+			if !ok {
+				return 0, false
+			}
+			total += words
+		}
+		return total, true
+	}
+	if typ == "tuple" || strings.HasPrefix(typ, "tuple[") {
+		return 0, false
+	}
+	return 1, true
+}
+
+// fixedArray splits a fixed array type such as "uint256[2]" into its element type and length
+func fixedArray(typ string) (string, int, bool) {
+	if !strings.HasSuffix(typ, "]") {
+		return "", 0, false
+	}
+	open := strings.LastIndex(typ, "[")
+	if open < 0 {
+		return "", 0, false
+	}
+	length, err := strconv.Atoi(typ[open+1 : len(typ)-1])
+	if err != nil || length <= 0 {
+		return "", 0, false
+	}
+	return typ[:open], length, true
+}
+
+// tupleComponents returns the component types of a tuple written as "(uint256,address)"
+func tupleComponents(typ string) ([]string, bool) {
+	if !strings.HasPrefix(typ, "(") || !strings.HasSuffix(typ, ")") {
+		return nil, false
+	}
+	// Parsed as the parameter list of a signature
+	_, components, err := utils.ParseSignature("tuple" + typ)
+	if err != nil {
+		return nil, false
+	}
+	return components, true
+}
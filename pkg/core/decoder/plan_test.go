@@ -0,0 +1,265 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDecodePlan_ExistingABIs(t *testing.T) {
+	tests := []struct {
+		name       string
+		abi        string
+		topicCount int
+		steps      []fieldStep
+	}{
+		{
+			name:       "erc20 transfer",
+			abi:        erc20Transfer_ABI,
+			topicCount: 3,
+			steps: []fieldStep{
+				{name: "from", typ: "address", source: sourceTopic, index: 1},
+				{name: "to", typ: "address", source: sourceTopic, index: 2},
+				{name: "value", typ: "uint256", source: sourceDataWord, index: 0, words: 1},
+			},
+		},
+		{
+			name:       "erc721 transfer",
+			abi:        erc721Transfer_ABI,
+			topicCount: 4,
+			steps: []fieldStep{
+				{name: "from", typ: "address", source: sourceTopic, index: 1},
+				{name: "to", typ: "address", source: sourceTopic, index: 2},
+				{name: "tokenId", typ: "uint256", source: sourceTopic, index: 3},
+			},
+		},
+		{
+			name:       "approval",
+			abi:        approvalEvent_ABI,
+			topicCount: 3,
+			steps: []fieldStep{
+				{name: "owner", typ: "address", source: sourceTopic, index: 1},
+				{name: "spender", typ: "address", source: sourceTopic, index: 2},
+				{name: "value", typ: "uint256", source: sourceDataWord, index: 0, words: 1},
+			},
+		},
+		{
+			name:       "bool event",
+			abi:        boolEvent_ABI,
+			topicCount: 1,
+			steps: []fieldStep{
+				{name: "success", typ: "bool", source: sourceDataWord, index: 0, words: 1},
+			},
+		},
+		{
+			name:       "string event",
+			abi:        stringEvent_ABI,
+			topicCount: 1,
+			steps: []fieldStep{
+				{name: "message", typ: "string", source: sourceDataDynamic, index: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewStandardDecoder()
+			err := decoder.RegisterABI("test", tt.abi)
+			assert.NoError(t, err)
+			assert.Len(t, decoder.events["test"], 1)
+
+			for _, candidates := range decoder.events["test"] {
+				assert.Len(t, candidates, 1)
+				e := candidates[0]
+				assert.Equal(t, tt.topicCount, e.plan.topicCount)
+				assert.Equal(t, tt.steps, e.plan.steps)
+			}
+		})
+	}
+}
+
+func TestDecodePlan_MatchesExpectedOutput(t *testing.T) {
+	boolTopic := utils.FunctionSignatureToTopic("BoolEvent(bool)")
+	stringTopic := utils.FunctionSignatureToTopic("StringEvent(string)")
+
+	tests := []struct {
+		name   string
+		abi    string
+		log    types.Log
+		fields types.EventFields
+	}{
+		{
+			name: "erc20 transfer",
+			abi:  erc20Transfer_ABI,
+			log: types.Log{
+				Topics: []string{
+					"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+					"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+					"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+				},
+				Data: "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+			},
+			fields: types.EventFields{
+				"from":  "0xa1b2c3d4e5f6789012345678901234567890abcd",
+				"to":    "0xf1e2d3c4b5a6978012345678901234567890dcba",
+				"value": big.NewInt(100000000),
+			},
+		},
+		{
+			name: "erc721 transfer",
+			abi:  erc721Transfer_ABI,
+			log: types.Log{
+				Topics: []string{
+					"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+					"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+					"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+					"0x0000000000000000000000000000000000000000000000000000000000000123",
+				},
+				Data: "0x",
+			},
+			fields: types.EventFields{
+				"from":    "0xa1b2c3d4e5f6789012345678901234567890abcd",
+				"to":      "0xf1e2d3c4b5a6978012345678901234567890dcba",
+				"tokenId": big.NewInt(291),
+			},
+		},
+		{
+			name: "bool event",
+			abi:  boolEvent_ABI,
+			log: types.Log{
+				Topics: []string{boolTopic},
+				Data:   "0x0000000000000000000000000000000000000000000000000000000000000001",
+			},
+			fields: types.EventFields{
+				"success": true,
+			},
+		},
+		{
+			name: "string event",
+			abi:  stringEvent_ABI,
+			log: types.Log{
+				Topics: []string{stringTopic},
+				Data:   "0x0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000b48656c6c6f20576f726c64000000000000000000000000000000000000000000",
+			},
+			fields: types.EventFields{
+				"message": "Hello World",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewStandardDecoder()
+			err := decoder.RegisterABI("test", tt.abi)
+			assert.NoError(t, err)
+
+			tt.log.BlockNumber = "0x1"
+			tt.log.LogIndex = "0x0"
+
+			event, err := decoder.Decode("test", tt.log)
+			assert.NoError(t, err)
+			assert.NotNil(t, event)
+			assert.Equal(t, tt.fields, event.Fields)
+		})
+	}
+}
+
+func TestDecodePlan_DynamicDataTooShort(t *testing.T) {
+	decoder := NewStandardDecoder()
+	decoder.RegisterABI("string", stringEvent_ABI)
+
+	log := types.Log{
+		Topics: []string{utils.FunctionSignatureToTopic("StringEvent(string)")},
+		// The offset of the string without its length and bytes
+		Data:        "0x0000000000000000000000000000000000000000000000000000000000000020",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	event, err := decoder.Decode("string", log)
+
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestDecodePlan_InterleavedStaticAndDynamic(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		indexed   []bool
+		topics    []string
+		data      string
+		steps     []fieldStep
+		fields    types.EventFields
+	}{
+		{
+			// The string takes one head word between the static params, its bytes are in the tail
+			name:      "indexed, static, dynamic, static",
+			signature: "Mixed(address,uint256,string,bool)",
+			indexed:   []bool{true, false, false, false},
+			topics:    []string{"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd"},
+			data: "0x" +
+				"000000000000000000000000000000000000000000000000000000000000002a" + // 42
+				"0000000000000000000000000000000000000000000000000000000000000060" + // offset of "foo"
+				"0000000000000000000000000000000000000000000000000000000000000001" + // true
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+				"666f6f0000000000000000000000000000000000000000000000000000000000",
+			steps: []fieldStep{
+				{name: "arg0", typ: "address", source: sourceTopic, index: 1},
+				{name: "arg1", typ: "uint256", source: sourceDataWord, index: 0, words: 1},
+				{name: "arg2", typ: "string", source: sourceDataDynamic, index: 32},
+				{name: "arg3", typ: "bool", source: sourceDataWord, index: 64, words: 1},
+			},
+			fields: types.EventFields{
+				"arg0": "0xa1b2c3d4e5f6789012345678901234567890abcd",
+				"arg1": big.NewInt(42),
+				"arg2": "foo",
+				"arg3": true,
+			},
+		},
+		{
+			name:      "dynamic before static",
+			signature: "Named(string,uint256)",
+			indexed:   []bool{false, false},
+			data: "0x" +
+				"0000000000000000000000000000000000000000000000000000000000000040" + // offset of "foo"
+				"000000000000000000000000000000000000000000000000000000000000002a" + // 42
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+				"666f6f0000000000000000000000000000000000000000000000000000000000",
+			steps: []fieldStep{
+				{name: "arg0", typ: "string", source: sourceDataDynamic, index: 0},
+				{name: "arg1", typ: "uint256", source: sourceDataWord, index: 32, words: 1},
+			},
+			fields: types.EventFields{
+				"arg0": "foo",
+				"arg1": big.NewInt(42),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewStandardDecoder()
+			err := decoder.RegisterEvent("test", tt.signature, tt.indexed)
+			assert.NoError(t, err)
+
+			for _, candidates := range decoder.events["test"] {
+				assert.Equal(t, tt.steps, candidates[0].plan.steps)
+			}
+
+			event, err := decoder.Decode("test", types.Log{
+				Topics:      append(decoder.Topics("test"), tt.topics...),
+				Data:        tt.data,
+				BlockNumber: "0x1",
+				LogIndex:    "0x0",
+			})
+			assert.NoError(t, err)
+			if assert.NotNil(t, event) {
+				assert.Equal(t, tt.fields, event.Fields)
+			}
+		})
+	}
+}
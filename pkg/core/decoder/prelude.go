@@ -0,0 +1,79 @@
+package decoder
+
+// ABI names registered by NewStandardDecoderWithPrelude
+const (
+	PreludeERC20   = "ERC20"
+	PreludeERC721  = "ERC721"
+	PreludeERC1155 = "ERC1155"
+	// PreludeTokens holds the events of all the token standards above.
+	// Use it when the standard of the emitting contract is unknown,
+	// the ERC20 and ERC721 Transfer are told apart by their number of indexed parameters.
+	PreludeTokens = "tokens"
+)
+
+const erc20PreludeABI = `[
+	{"anonymous": false, "type": "event", "name": "Transfer", "inputs": [
+		{"indexed": true, "name": "from", "type": "address"},
+		{"indexed": true, "name": "to", "type": "address"},
+		{"indexed": false, "name": "value", "type": "uint256"}
+	]},
+	{"anonymous": false, "type": "event", "name": "Approval", "inputs": [
+		{"indexed": true, "name": "owner", "type": "address"},
+		{"indexed": true, "name": "spender", "type": "address"},
+		{"indexed": false, "name": "value", "type": "uint256"}
+	]}
+]`
+
+const erc721PreludeABI = `[
+	{"anonymous": false, "type": "event", "name": "Transfer", "inputs": [
+		{"indexed": true, "name": "from", "type": "address"},
+		{"indexed": true, "name": "to", "type": "address"},
+		{"indexed": true, "name": "tokenId", "type": "uint256"}
+	]},
+	{"anonymous": false, "type": "event", "name": "ApprovalForAll", "inputs": [
+		{"indexed": true, "name": "owner", "type": "address"},
+		{"indexed": true, "name": "operator", "type": "address"},
+		{"indexed": false, "name": "approved", "type": "bool"}
+	]}
+]`
+
+const erc1155PreludeABI = `[
+	{"anonymous": false, "type": "event", "name": "TransferSingle", "inputs": [
+		{"indexed": true, "name": "operator", "type": "address"},
+		{"indexed": true, "name": "from", "type": "address"},
+		{"indexed": true, "name": "to", "type": "address"},
+		{"indexed": false, "name": "id", "type": "uint256"},
+		{"indexed": false, "name": "value", "type": "uint256"}
+	]},
+	{"anonymous": false, "type": "event", "name": "TransferBatch", "inputs": [
+		{"indexed": true, "name": "operator", "type": "address"},
+		{"indexed": true, "name": "from", "type": "address"},
+		{"indexed": true, "name": "to", "type": "address"},
+		{"indexed": false, "name": "ids", "type": "uint256[]"},
+		{"indexed": false, "name": "values", "type": "uint256[]"}
+	]}
+]`
+
+// NewStandardDecoderWithPrelude returns a decoder with the common token events already registered:
+// ERC20 Transfer/Approval, ERC721 Transfer/ApprovalForAll and ERC1155 TransferSingle/TransferBatch.
+// Each standard is registered under its Prelude name, and all of them under PreludeTokens.
+func NewStandardDecoderWithPrelude() *StandardDecoder {
+	d := NewStandardDecoder()
+
+	prelude := map[string]string{
+		PreludeERC20:   erc20PreludeABI,
+		PreludeERC721:  erc721PreludeABI,
+		PreludeERC1155: erc1155PreludeABI,
+	}
+	for name, abi := range prelude {
+		// The prelude ABIs are constants, they can't fail to parse
+		if err := d.RegisterABI(name, abi); err != nil {
+			panic(err)
+		}
+		if err := d.RegisterABI(PreludeTokens, abi); err != nil {
+			panic(err)
+		}
+	}
+
+	return d
+}
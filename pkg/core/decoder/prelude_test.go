@@ -0,0 +1,140 @@
+package decoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	preludeFrom     = "0xa1b2c3d4e5f6789012345678901234567890abcd"
+	preludeTo       = "0xf1e2d3c4b5a6978012345678901234567890dcba"
+	preludeOperator = "0x1111111111111111111111111111111111111111"
+)
+
+// word left pads a hex value without 0x to a 32 bytes ABI word
+func word(hexValue string) string {
+	return strings.Repeat("0", 64-len(hexValue)) + hexValue
+}
+
+func preludeLog(signature string, indexed []string, data string) types.Log {
+	topics := []string{utils.FunctionSignatureToTopic(signature)}
+	for _, v := range indexed {
+		topics = append(topics, "0x"+word(strings.TrimPrefix(v, "0x")))
+	}
+	return types.Log{
+		Address:     "0x1234567890123456789012345678901234567890",
+		Topics:      topics,
+		Data:        "0x" + data,
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+}
+
+func TestPrelude_ERC20(t *testing.T) {
+	decoder := NewStandardDecoderWithPrelude()
+
+	event, err := decoder.Decode(PreludeERC20, preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo}, word("5f5e100")))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "Transfer", event.EventType)
+	assert.Equal(t, preludeFrom, event.Fields["from"])
+	assert.Equal(t, preludeTo, event.Fields["to"])
+	assert.Equal(t, big.NewInt(100000000), event.Fields["value"])
+
+	event, err = decoder.Decode(PreludeERC20, preludeLog("Approval(address,address,uint256)", []string{preludeFrom, preludeTo}, word("64")))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "Approval", event.EventType)
+	assert.Equal(t, preludeFrom, event.Fields["owner"])
+	assert.Equal(t, preludeTo, event.Fields["spender"])
+	assert.Equal(t, big.NewInt(100), event.Fields["value"])
+}
+
+func TestPrelude_ERC721(t *testing.T) {
+	decoder := NewStandardDecoderWithPrelude()
+
+	event, err := decoder.Decode(PreludeERC721, preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo, "123"}, ""))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "Transfer", event.EventType)
+	assert.Equal(t, preludeFrom, event.Fields["from"])
+	assert.Equal(t, preludeTo, event.Fields["to"])
+	assert.Equal(t, big.NewInt(291), event.Fields["tokenId"])
+
+	event, err = decoder.Decode(PreludeERC721, preludeLog("ApprovalForAll(address,address,bool)", []string{preludeFrom, preludeOperator}, word("1")))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "ApprovalForAll", event.EventType)
+	assert.Equal(t, preludeFrom, event.Fields["owner"])
+	assert.Equal(t, preludeOperator, event.Fields["operator"])
+	assert.Equal(t, true, event.Fields["approved"])
+}
+
+func TestPrelude_ERC1155(t *testing.T) {
+	decoder := NewStandardDecoderWithPrelude()
+
+	event, err := decoder.Decode(PreludeERC1155, preludeLog(
+		"TransferSingle(address,address,address,uint256,uint256)",
+		[]string{preludeOperator, preludeFrom, preludeTo},
+		word("7")+word("a"),
+	))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "TransferSingle", event.EventType)
+	assert.Equal(t, preludeOperator, event.Fields["operator"])
+	assert.Equal(t, big.NewInt(7), event.Fields["id"])
+	assert.Equal(t, big.NewInt(10), event.Fields["value"])
+
+	// Heads are the offsets of both arrays, then each array is its length followed by the elements
+	data := word("40") + word("a0") +
+		word("2") + word("1") + word("2") +
+		word("2") + word("64") + word("c8")
+	event, err = decoder.Decode(PreludeERC1155, preludeLog(
+		"TransferBatch(address,address,address,uint256[],uint256[])",
+		[]string{preludeOperator, preludeFrom, preludeTo},
+		data,
+	))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "TransferBatch", event.EventType)
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2)}, event.Fields["ids"])
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(200)}, event.Fields["values"])
+}
+
+func TestPrelude_TokensDisambiguatesTransfer(t *testing.T) {
+	decoder := NewStandardDecoderWithPrelude()
+
+	// Same topic hash, told apart by the number of topics
+	event, err := decoder.Decode(PreludeTokens, preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo}, word("5f5e100")))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, big.NewInt(100000000), event.Fields["value"])
+	assert.NotContains(t, event.Fields, "tokenId")
+
+	event, err = decoder.Decode(PreludeTokens, preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo, "123"}, ""))
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, big.NewInt(291), event.Fields["tokenId"])
+	assert.NotContains(t, event.Fields, "value")
+
+	// An ERC721 Transfer is not an ERC20 one
+	event, err = decoder.Decode(PreludeERC20, preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo, "123"}, ""))
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestNewStandardDecoder_Empty(t *testing.T) {
+	log := preludeLog("Transfer(address,address,uint256)", []string{preludeFrom, preludeTo}, word("5f5e100"))
+
+	_, err := NewStandardDecoder().Decode(PreludeERC20, log)
+	assert.Error(t, err)
+
+	// The deprecated spelling behaves the same
+	_, err = NewStandsardDecoder().Decode(PreludeERC20, log)
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ScaledSuffix is appended to the key of a scaled field for its normalized value, e.g. "valueScaled" for "value"
+const ScaledSuffix = "Scaled"
+
+// RegisterScale exposes the integer field of the event registered under the ABI name normalized by decimals,
+// e.g. a token amount of 1500000000000000000 with 18 decimals as "1.5" under field+ScaledSuffix.
+// The raw value stays under field. The normalized value is an exact decimal string without trailing zeros.
+// Register the event first, the scale is kept when the event is registered again.
+func (d *StandardDecoder) RegisterScale(name, event, field string, decimals uint8) error {
+	abi, exists := d.events[name]
+	if !exists {
+		return fmt.Errorf("ABI '%s' not found", name)
+	}
+
+	var matched []*registeredEvent
+	for _, candidates := range abi {
+		for _, c := range candidates {
+			if c.Name != event {
+				continue
+			}
+			for _, input := range c.Inputs {
+				if input.Name != field {
+					continue
+				}
+				if !strings.HasPrefix(input.Type, "uint") && !strings.HasPrefix(input.Type, "int") || strings.HasSuffix(input.Type, "]") {
+					return fmt.Errorf("field %s of event %s is a %s, only integers can be scaled", field, event, input.Type)
+				}
+				matched = append(matched, c)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("event %s with a field %s not found in ABI '%s'", event, field, name)
+	}
+
+	if d.scales == nil {
+		d.scales = make(map[string]map[string]map[string]uint8)
+	}
+	if d.scales[name] == nil {
+		d.scales[name] = make(map[string]map[string]uint8)
+	}
+	if d.scales[name][event] == nil {
+		d.scales[name][event] = make(map[string]uint8)
+	}
+	d.scales[name][event][field] = decimals
+	for _, c := range matched {
+		c.scales = d.scales[name][event]
+	}
+	return nil
+}
+
+// applyScales adds the normalized value of the scaled fields of e to fields
+func (e *registeredEvent) applyScales(fields map[string]any) {
+	for field, decimals := range e.scales {
+		if scaled, ok := scaleInteger(fields[field], decimals); ok {
+			fields[field+ScaledSuffix] = scaled
+		}
+	}
+}
+
+// scaleInteger formats the decoded integer v divided by 10^decimals as an exact decimal string
+func scaleInteger(v any, decimals uint8) (string, bool) {
+	var n *big.Int
+	switch x := v.(type) {
+	case *big.Int:
+		n = x
+	case uint64:
+		n = new(big.Int).SetUint64(x)
+	case int64:
+		n = big.NewInt(x)
+	default:
+		return "", false
+	}
+
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	s := new(big.Rat).SetFrac(n, denominator).FloatString(int(decimals))
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s, true
+}
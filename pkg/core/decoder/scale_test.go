@@ -0,0 +1,71 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterScale(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterABI("erc20", erc20Transfer_ABI))
+	assert.NoError(t, decoder.RegisterScale("erc20", "Transfer", "value", 18))
+
+	log := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		// 1.5 tokens of 18 decimals
+		Data:        "0x00000000000000000000000000000000000000000000000014d1120d7b160000",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	event, err := decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		raw, _ := new(big.Int).SetString("1500000000000000000", 10)
+		assert.Equal(t, raw, event.Fields["value"])
+		assert.Equal(t, "1.5", event.Fields["value"+ScaledSuffix])
+	}
+
+	// Kept when the event is registered again
+	assert.NoError(t, decoder.RegisterABI("erc20", erc20Transfer_ABI))
+	event, err = decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, "1.5", event.Fields["valueScaled"])
+	}
+
+	assert.Error(t, decoder.RegisterScale("unknown", "Transfer", "value", 18))
+	assert.Error(t, decoder.RegisterScale("erc20", "Approval", "value", 18))
+	// Addresses can't be scaled
+	assert.Error(t, decoder.RegisterScale("erc20", "Transfer", "from", 18))
+}
+
+func TestScaleInteger(t *testing.T) {
+	tests := []struct {
+		value    any
+		decimals uint8
+		expected string
+	}{
+		{uint64(1_000_000), 6, "1"},
+		{uint64(1_234_567), 6, "1.234567"},
+		{uint64(5), 18, "0.000000000000000005"},
+		{int64(-2_500), 3, "-2.5"},
+		{uint64(42), 0, "42"},
+		{big.NewInt(0), 18, "0"},
+	}
+	for _, tt := range tests {
+		scaled, ok := scaleInteger(tt.value, tt.decimals)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, scaled, tt.value)
+	}
+
+	_, ok := scaleInteger("0xabc", 18)
+	assert.False(t, ok)
+}
@@ -1,12 +1,14 @@
 package decoder
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math/big"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/ryuux05/godex/pkg/core/types"
@@ -14,16 +16,42 @@ import (
 )
 
 type StandardDecoder struct {
-	events map[string]map[string]*types.EventDefinition
+	// Registered events by ABI name then topic hash.
+	// Events sharing a signature but not the indexed layout (e.g. ERC20 and ERC721 Transfer) share the topic hash.
+	events map[string]map[string][]*registeredEvent
+	// TopicHasher computes the topic hash of an event signature, defaults to utils.FunctionSignatureToTopic (Keccak256).
+	// Set it before registering ABIs, e.g. for a chain hashing signatures differently or to normalize them first.
+	TopicHasher func(signature string) string
+	// KeepRawOnUnknownType decodes a field of a type the decoder doesn't support, e.g. a fixed array or a tuple,
+	// as its raw hex words instead of skipping the log. For a dynamic type the word is its offset in data.
+	// A static fixed array or tuple takes as many head words as its elements, the fields after it are read after them.
+	// The logs of an event with data after a tuple of unknown components, e.g. from an ABI JSON, are still skipped.
+	// Defaults to false, set it before registering ABIs.
+	KeepRawOnUnknownType bool
+	// Decimals of the scaled fields by ABI name, event name then field, see RegisterScale
+	scales map[string]map[string]map[string]uint8
 }
 
 
-func NewStandsardDecoder() *StandardDecoder {
+// NewStandardDecoder returns an empty decoder, register ABIs before decoding.
+func NewStandardDecoder() *StandardDecoder {
 	return &StandardDecoder{
-		events: make(map[string]map[string]*types.EventDefinition),
+		events: make(map[string]map[string][]*registeredEvent),
 	}
 }
 
+// NewStandsardDecoder is the misspelled name of NewStandardDecoder, kept for compatibility.
+//
+// Deprecated: Use NewStandardDecoder instead.
+func NewStandsardDecoder() *StandardDecoder {
+	return NewStandardDecoder()
+}
+
+// Decode decodes log with the events registered under the ABI name.
+// A log that doesn't match any event of the ABI, either by topic hash or by layout
+// (fewer or more topics than indexed parameters, data too short, malformed topic),
+// is not an error: it returns nil, nil so the caller can skip it and keep decoding the batch.
+// Errors are reserved for an unknown ABI name, malformed log metadata and ErrDataMissing.
 func (d *StandardDecoder) Decode(name string, log types.Log) (*types.Event, error) {
 	// If topic is empty skip it
 	if len(log.Topics) == 0 {
@@ -37,62 +65,28 @@ func (d *StandardDecoder) Decode(name string, log types.Log) (*types.Event, erro
 	}
 
 
-	e, exist := abi[log.Topics[0]]
-	if !exist {
+	return decodeEvent(matchArity(abi[log.Topics[0]], len(log.Topics)), log)
+}
+
+// decodeEvent decodes log with the event e, nil when e is nil or the log doesn't match its layout
+func decodeEvent(e *registeredEvent, log types.Log) (*types.Event, error) {
+	if e == nil {
 		return nil, nil
 	}
+	// Equal by construction unless the definition was registered with a wrong TopicHash
+	if log.Topics[0] != e.signatureHash {
+		return nil, fmt.Errorf("event %s: topic0 %s doesn't match the hash %s of its signature %s, check its TopicHash",
+			e.Name, log.Topics[0], e.signatureHash, e.Signature)
+	}
 
-	var topicNum = 1
-	var dataOffset = 0
-	field := make(map[string]interface{})
-
-	for _, input := range e.Inputs {
-		if input.Indexed == true {
-			if topicNum >= len(log.Topics) {
-                return nil, nil
-            }
-
-			value, err := decodeByType(log.Topics[topicNum][2:], input.Type)
-			if err != nil {
-				return nil, nil
-			}
-			field[input.Name] = value
-			topicNum ++
-		} else {
-			if input.Type != "string" && input.Type != "bytes" {
-
-				start := dataOffset
-				end := dataOffset + 32
-				
-				// Here we times 2 because each byte is represented by 2 character
-				// Pass clean data without the 0x format
-				hexStart := 2 + (start * 2)
-				hexEnd := 2 + (end * 2)
-				
-				if hexEnd > len(log.Data) {
-					return nil, nil
-				}
-				
-				value, err := decodeByType(log.Data[hexStart:hexEnd], input.Type)
-				if err != nil {
-					return nil, nil
-				}
-				
-				field[input.Name] = value
-				dataOffset += 32
-			} else {
-				// Pass clean data without the 0x format
-				// Offset is in byte
-				value, err := decodeByTypeWithOffset(log.Data[2:], dataOffset, input.Type)
-				if err != nil {
-					return nil, nil
-				}
-				
-				field[input.Name] = value
-				dataOffset += 32
-			}
-		}
+	if e.plan.missingData(log) {
+		return nil, fmt.Errorf("event %s: %w", e.Name, ErrDataMissing)
 	}
+	field, ok := e.plan.execute(log)
+	if !ok {
+		return nil, nil
+	}
+	e.applyScales(field)
 
 	blockNumber, err := utils.HexQtyToUint64(log.BlockNumber)
 	if err != nil {
@@ -111,13 +105,11 @@ func (d *StandardDecoder) Decode(name string, log types.Log) (*types.Event, erro
 		LogIndex: logIndex,
 		EventType: e.Name,
 		Fields: field,
+		RawLog: &log,
+		ChainId: log.ChainId,
 	}, nil
 }
 
-func (d *StandardDecoder) DecodeBatch(logs []types.Log) (*[]types.Event, error) {
-	return nil, nil
-}
-
 func (d *StandardDecoder) RegisterABI(name, abiJson string) error {
 	var abi ABI 
 	err := json.Unmarshal([]byte(abiJson), &abi)
@@ -126,7 +118,7 @@ func (d *StandardDecoder) RegisterABI(name, abiJson string) error {
 	}
 
 	if d.events[name] == nil {
-		d.events[name] = make(map[string]*types.EventDefinition)
+		d.events[name] = make(map[string][]*registeredEvent)
 	}
 
 	for _, item := range abi {
@@ -137,7 +129,7 @@ func (d *StandardDecoder) RegisterABI(name, abiJson string) error {
 		// Build the event signature from the abi
 		signature := buildSignature(item)
 
-		topicHash := utils.FunctionSignatureToTopic(signature)
+		topicHash := d.topicHash(signature)
 
 		eventDefinition := &types.EventDefinition{
 			Name: item.Name,
@@ -146,12 +138,125 @@ func (d *StandardDecoder) RegisterABI(name, abiJson string) error {
 			Inputs: convertInputs(item.Inputs),
 		}
 
-		d.events[name][topicHash] = eventDefinition
+		d.register(name, eventDefinition, topicHash)
 	}
 
 	return nil
 }
 
+// RegisterEvent registers a single event from its signature without a JSON ABI.
+// signature is the event signature (e.g. "Transfer(address,address,uint256)"), its types are canonicalized
+// before hashing so "Transfer(address,address,uint)" has the same topic. indexed flags each parameter in order.
+// Since a signature has no parameter names, the fields are named by position: "arg0", "arg1", ...
+func (d *StandardDecoder) RegisterEvent(name, signature string, indexed []bool) error {
+	eventName, paramTypes, err := utils.ParseSignature(signature)
+	if err != nil {
+		return fmt.Errorf("invalid event signature: %w", err)
+	}
+
+	if len(indexed) != len(paramTypes) {
+		return fmt.Errorf("indexed flags count mismatch: signature has %d params, got %d flags", len(paramTypes), len(indexed))
+	}
+
+	inputs := make([]types.EventInput, len(paramTypes))
+	for i, paramType := range paramTypes {
+		// The topic is the hash of the canonical types, e.g. uint256 for uint
+		paramType = utils.CanonicalType(paramType)
+		paramTypes[i] = paramType
+		inputs[i] = types.EventInput{
+			Name:    fmt.Sprintf("arg%d", i),
+			Type:    paramType,
+			Indexed: indexed[i],
+		}
+	}
+
+	canonical := fmt.Sprintf("%s(%s)", eventName, strings.Join(paramTypes, ","))
+	topicHash := d.topicHash(canonical)
+	d.register(name, &types.EventDefinition{
+		Name:      eventName,
+		Signature: canonical,
+		TopicHash: topicHash,
+		Inputs:    inputs,
+	}, topicHash)
+
+	return nil
+}
+
+// RegisterEventDefinition registers a prebuilt event definition under the ABI name, e.g. one generated ahead of time.
+// The definition is indexed by its TopicHash as given, Decode reports an error if a log matches
+// a TopicHash that isn't the hash of the Signature.
+func (d *StandardDecoder) RegisterEventDefinition(name string, def types.EventDefinition) error {
+	if _, _, err := utils.ParseSignature(def.Signature); err != nil {
+		return fmt.Errorf("invalid event signature: %w", err)
+	}
+	signatureHash := d.topicHash(def.Signature)
+	if def.TopicHash == "" {
+		def.TopicHash = signatureHash
+	}
+	d.register(name, &def, signatureHash)
+	return nil
+}
+
+// Topics returns the sorted topic hashes of the events registered under the ABI name, nil for an unknown name.
+// Use them as the topics of the logs filter to fetch only the logs the ABI can decode.
+func (d *StandardDecoder) Topics(name string) []string {
+	abi, exists := d.events[name]
+	if !exists {
+		return nil
+	}
+	topics := make([]string, 0, len(abi))
+	for topic := range abi {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// topicHash hashes signature with TopicHasher if set
+func (d *StandardDecoder) topicHash(signature string) string {
+	if d.TopicHasher != nil {
+		return d.TopicHasher(signature)
+	}
+	return utils.FunctionSignatureToTopic(signature)
+}
+
+// register stores the event definition under the ABI name along with its decode plan,
+// signatureHash is the topic hash of its signature.
+// An event with the same topic hash and number of topics replaces the registered one,
+// otherwise both are kept and Decode picks by the number of topics of the log.
+func (d *StandardDecoder) register(name string, eventDefinition *types.EventDefinition, signatureHash string) {
+	if d.events[name] == nil {
+		d.events[name] = make(map[string][]*registeredEvent)
+	}
+
+	e := &registeredEvent{
+		EventDefinition: eventDefinition,
+		plan: buildDecodePlan(eventDefinition.Inputs),
+		signatureHash: signatureHash,
+		scales: d.scales[name][eventDefinition.Name],
+	}
+	e.plan.keepRawOnUnknownType = d.KeepRawOnUnknownType
+
+	candidates := d.events[name][eventDefinition.TopicHash]
+	for i, c := range candidates {
+		if c.plan.topicCount == e.plan.topicCount {
+			candidates[i] = e
+			return
+		}
+	}
+	d.events[name][eventDefinition.TopicHash] = append(candidates, e)
+}
+
+// matchArity returns the event whose indexed parameters match the number of topics of the log
+func matchArity(candidates []*registeredEvent, topicCount int) *registeredEvent {
+	for _, c := range candidates {
+		if c.plan.topicCount == topicCount {
+			return c
+		}
+	}
+	return nil
+}
+
 func (d *StandardDecoder) RegisterABIFromFile(name string, filepath string) error{
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -187,21 +292,27 @@ func convertInputs(inputs []ABIInput) []types.EventInput {
     return result
 }
 
+// ErrDataMissing is returned when a log of an event with non-indexed parameters has no data at all,
+// e.g. a provider dropping it, rather than skipping it like data too short for the event
+var ErrDataMissing = errors.New("event expects data but the log has none")
+
+// errUnknownType is returned when decoding a type the decoder doesn't support
+var errUnknownType = errors.New("unidentified data type")
+
 func decodeByType(hex string, types string) (any, error){
+	if signed, bits, ok := intType(types); ok {
+		return decodeInteger(hex, signed, bits)
+	}
 	switch types {
 	case "address":
 		return decodeAddress(hex)
-	case "uint256", "uint", "int256", "int":
-		return decodeBigInt(hex)
-	case "uint8", "uint16", "uint32", "uint64":
-		return decodeUint(hex)
 	case "bool":
 		return decodeBool(hex)
 	case "bytes32":
 		return decodeBytes32(hex)
 	default:
 		// Handle arrays, tuples, or return error
-		return nil, fmt.Errorf("unidentified data type")
+		return nil, errUnknownType
 	}
 }
 
@@ -212,8 +323,11 @@ func decodeByTypeWithOffset(data string, offset int, types string) (any, error)
 	case "string":
 		return decodeString(data, offset)
 	default:
+		if elemType, ok := strings.CutSuffix(types, "[]"); ok {
+			return decodeDynamicArray(data, offset, elemType)
+		}
 		// Handle arrays, tuples, or return error
-		return nil, fmt.Errorf("unidentified data type")
+		return nil, errUnknownType
 	}	
 }
 
@@ -230,34 +344,52 @@ func decodeAddress(hexData string) (string, error) {
 	return "0x" + addressHex, nil
 }
 
-func decodeBigInt(hexData string) (*big.Int, error) {
-	if len(hexData) != 64 {
-		return nil, fmt.Errorf("invalid big int hex length: expected 64, got %d", len(hexData))
+func decodeUint(hex string) (uint64, error) {
+	if len(hex) != 64 {
+		return 0, fmt.Errorf("invalid big int hex length: expected 64, got %d", len(hex))
 	}
 
-	value := new(big.Int)
-
-	_, ok := value.SetString(hexData, 16) 
+	word, ok := decodeWord(hex)
 	if !ok {
-        return nil, fmt.Errorf("failed to parse hex as big integer: %s", hexData)
-    }
-    
-    return value, nil
+		return 0, fmt.Errorf("failed to parse hex as big integer: %s", hex)
+	}
+
+	// Convert to uint64 (check overflow)
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, fmt.Errorf("value too large for uint64")
+		}
+	}
+
+	return binary.BigEndian.Uint64(word[24:]), nil
 }
 
-func decodeUint(hex string) (uint64, error) {
-	v, err := decodeBigInt(hex)
-	if err != nil {
-		return 0, err
+// decodeWord decodes a 64 character hex string into a 32 byte ABI word without allocating
+func decodeWord(hexData string) ([32]byte, bool) {
+	var word [32]byte
+	for i := 0; i < 32; i++ {
+		hi, ok1 := fromHexChar(hexData[i*2])
+		lo, ok2 := fromHexChar(hexData[i*2+1])
+		if !ok1 || !ok2 {
+			return word, false
+		}
+		word[i] = hi<<4 | lo
 	}
+	return word, true
+}
 
-	// Convert to uint64 (check overflow)
-    if !v.IsUint64() {
-        return 0, fmt.Errorf("value too large for uint64")
-    }
-    
-    return v.Uint64(), nil
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
 }
+
 func decodeBool(hexData string) (bool, error) {
 	if len(hexData) != 64 {
 		return false, fmt.Errorf("invalid bool hex length: expected 64, got %d", len(hexData))
@@ -292,66 +424,111 @@ func decodeBytes32(hexData string) ([]byte, error) {
 }
 
 func decodeString(data string, offset int) (string, error) {
+	bytes, err := decodeDynamicBytes(data, offset)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// decodeDynamicBytes decodes the bytes or string whose head word is at offset.
+// Every dynamic param has its own head word, an offset relative to the start of data
+// pointing into the tail they share, where its length is followed by its content.
+func decodeDynamicBytes(data string, offset int) ([]byte, error) {
 	// Get the data offset pointer
 	hexStart := (offset * 2)
-    hexEnd := hexStart + 64
-	offsetHex := data[hexStart:hexEnd]
-
-	p, err := decodeUint(offsetHex)
+	hexEnd := hexStart + 64
+	if hexEnd > len(data) {
+		return nil, fmt.Errorf("head offset out of range")
+	}
+	p, err := decodeUint(data[hexStart:hexEnd])
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Get the data lenght
-	dataStart := (p * 2)  // Convert byte offset to hex position
-    lengthHex := data[dataStart:dataStart+64]
-    
-    l, err := decodeUint(lengthHex)
+	// Get the data length
+	dataStart := p * 2 // Convert byte offset to hex position
+	if p > uint64(len(data)) || dataStart+64 > uint64(len(data)) {
+		return nil, fmt.Errorf("tail offset out of range")
+	}
+	l, err := decodeUint(data[dataStart : dataStart+64])
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Get the actual data
-	bytesStart := dataStart + 64  // After length
-    bytesEnd := bytesStart + (l * 2)  // l bytes = l*2 hex chars
-    dataHex := data[bytesStart:bytesEnd]
-    
-    bytes, err := hex.DecodeString(dataHex)
+	bytesStart := dataStart + 64 // After length
+	if l > (uint64(len(data))-bytesStart)/2 {
+		return nil, fmt.Errorf("length %d out of range", l)
+	}
+	bytesEnd := bytesStart + (l * 2) // l bytes = l*2 hex chars
+
+	bytes, err := hex.DecodeString(data[bytesStart:bytesEnd])
 	if err != nil {
-        return "", fmt.Errorf("failed to decode hex: %w", err)
-    }
-	return string(bytes), nil
+		return nil, fmt.Errorf("failed to decode hex: %w", err)
+	}
+	return bytes, nil
 }
 
-func decodeDynamicBytes(data string, offset int) ([]byte, error) {
+// decodeDynamicArray decodes an array of static types such as uint256[] into a typed slice,
+// e.g. []*big.Int for uint256[] or []string for address[]
+func decodeDynamicArray(data string, offset int, elemType string) (any, error) {
 	// Get the data offset pointer
 	hexStart := (offset * 2)
-    hexEnd := hexStart + 64
-	offsetHex := data[hexStart:hexEnd]
-
-	p, err := decodeUint(offsetHex)
+	hexEnd := hexStart + 64
+	if hexEnd > len(data) {
+		return nil, fmt.Errorf("head offset out of range")
+	}
+	p, err := decodeUint(data[hexStart:hexEnd])
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the data lenght
-	dataStart := (p * 2)  // Convert byte offset to hex position
-    lengthHex := data[dataStart:dataStart+64]
-    
-    l, err := decodeUint(lengthHex)
+	// Get the array length
+	dataStart := p * 2
+	if p > uint64(len(data)) || dataStart+64 > uint64(len(data)) {
+		return nil, fmt.Errorf("array offset out of range")
+	}
+	l, err := decodeUint(data[dataStart : dataStart+64])
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the actual data
-	bytesStart := dataStart + 64  // After length
-    bytesEnd := bytesStart + (l * 2)  // l bytes = l*2 hex chars
-    dataHex := data[bytesStart:bytesEnd]
-    
-    bytes, err := hex.DecodeString(dataHex)
-	if err != nil {
-        return nil, fmt.Errorf("failed to decode hex: %w", err)
-    }
-	return bytes, nil
+	// Elements follow the length, one word each
+	elemStart := dataStart + 64
+	if l > (uint64(len(data))-elemStart)/64 {
+		return nil, fmt.Errorf("array length %d out of range", l)
+	}
+	words := make([]string, l)
+	for i := range words {
+		wordStart := elemStart + uint64(i)*64
+		words[i] = data[wordStart : wordStart+64]
+	}
+
+	if signed, bits, ok := intType(elemType); ok {
+		return decodeIntegerWords(words, signed, bits)
+	}
+	switch elemType {
+	case "address":
+		return decodeWords(words, decodeAddress)
+	case "bool":
+		return decodeWords(words, decodeBool)
+	case "bytes32":
+		return decodeWords(words, decodeBytes32)
+	default:
+		return nil, errUnknownType
+	}
 }
 
+// decodeWords decodes each word of an array with decode
+func decodeWords[T any](words []string, decode func(string) (T, error)) ([]T, error) {
+	values := make([]T, len(words))
+	for i, w := range words {
+		v, err := decode(w)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
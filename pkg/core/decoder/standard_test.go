@@ -4,9 +4,12 @@ import (
 	//"encoding/json"
 	//"fmt"
 	"math/big"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -126,8 +129,48 @@ const stringEvent_ABI = `[
 	}
   ]`
 
+const erc1155TransferBatch_ABI = `[
+	{
+	  "anonymous": false,
+	  "inputs": [
+		{
+		  "indexed": true,
+		  "internalType": "address",
+		  "name": "operator",
+		  "type": "address"
+		},
+		{
+		  "indexed": true,
+		  "internalType": "address",
+		  "name": "from",
+		  "type": "address"
+		},
+		{
+		  "indexed": true,
+		  "internalType": "address",
+		  "name": "to",
+		  "type": "address"
+		},
+		{
+		  "indexed": false,
+		  "internalType": "uint256[]",
+		  "name": "ids",
+		  "type": "uint256[]"
+		},
+		{
+		  "indexed": false,
+		  "internalType": "uint256[]",
+		  "name": "values",
+		  "type": "uint256[]"
+		}
+	  ],
+	  "name": "TransferBatch",
+	  "type": "event"
+	}
+  ]`
+
 func TestDecodeTransfer_Successful(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	err := decoder.RegisterABI("erc20", erc20Transfer_ABI)
 	assert.NoError(t, err)
 
@@ -162,7 +205,7 @@ func TestDecodeTransfer_Successful(t *testing.T) {
 }
 
 func TestDecodeERC721Transfer_Successful(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	err := decoder.RegisterABI("erc721", erc721Transfer_ABI)
 	assert.NoError(t, err)
 
@@ -191,8 +234,153 @@ func TestDecodeERC721Transfer_Successful(t *testing.T) {
 	assert.Equal(t, big.NewInt(291), event.Fields["tokenId"]) // 0x123 = 291
 }
 
+func TestDecodeApproval_Successful(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterABI("erc20", erc20Transfer_ABI))
+	assert.NoError(t, decoder.RegisterABI("approval", approvalEvent_ABI))
+
+	// USDC approval of 1,000,000 tokens (6 decimals)
+	log := types.Log{
+		Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Topics: []string{
+			"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:            "0x000000000000000000000000000000000000000000000000000000e8d4a51000",
+		BlockNumber:     "0x112a880",
+		BlockHash:       "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		TransactionHash: "0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
+		LogIndex:        "0x7",
+	}
+
+	event, err := decoder.Decode("approval", log)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, uint64(18000000), event.BlockNumber)
+	assert.Equal(t, uint64(7), event.LogIndex)
+	assert.Equal(t, "Approval", event.EventType)
+	assert.Equal(t, types.EventFields{
+		"owner":   "0xa1b2c3d4e5f6789012345678901234567890abcd",
+		"spender": "0xf1e2d3c4b5a6978012345678901234567890dcba",
+		"value":   big.NewInt(1000000000000),
+	}, event.Fields)
+	assert.Equal(t, []string{"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"}, decoder.Topics("approval"))
+
+	// Approval and Transfer have different topic0, the Transfer ABI doesn't match the log
+	event, err = decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+const fixedPointEvent_ABI = `[
+	{
+	  "anonymous": false,
+	  "inputs": [
+		{"indexed": true, "name": "market", "type": "address"},
+		{"indexed": false, "name": "rate", "type": "fixed128x18"},
+		{"indexed": false, "name": "amount", "type": "uint256"}
+	  ],
+	  "name": "RateUpdated",
+	  "type": "event"
+	}
+  ]`
+
+func TestDecode_KeepRawOnUnknownType(t *testing.T) {
+	rate := "00000000000000000000000000000000000000000000000014d1120d7b160000"
+	log := types.Log{
+		Topics: []string{
+			utils.FunctionSignatureToTopic("RateUpdated(address,fixed128x18,uint256)"),
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+		},
+		Data:        "0x" + rate + "0000000000000000000000000000000000000000000000000000000000000064",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	// Strict by default, the log doesn't decode
+	strict := NewStandardDecoder()
+	assert.NoError(t, strict.RegisterABI("rates", fixedPointEvent_ABI))
+	event, err := strict.Decode("rates", log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	// The unsupported field keeps its raw word, the other fields are decoded
+	lenient := NewStandardDecoder()
+	lenient.KeepRawOnUnknownType = true
+	assert.NoError(t, lenient.RegisterABI("rates", fixedPointEvent_ABI))
+	event, err = lenient.Decode("rates", log)
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, types.EventFields{
+		"market": "0xa1b2c3d4e5f6789012345678901234567890abcd",
+		"rate":   "0x" + rate,
+		"amount": big.NewInt(100),
+	}, event.Fields)
+}
+
+func TestDecode_FieldAfterStaticArrayAndTuple(t *testing.T) {
+	word := func(v string) string { return strings.Repeat("0", 64-len(v)) + v }
+
+	tests := []struct {
+		name      string
+		signature string
+		raw       string
+	}{
+		{name: "fixed array", signature: "Fixed(uint256[2],uint256)", raw: "0x" + word("1") + word("2")},
+		{name: "static tuple", signature: "Pair((uint256,uint256),uint256)", raw: "0x" + word("1") + word("2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewStandardDecoder()
+			decoder.KeepRawOnUnknownType = true
+			assert.NoError(t, decoder.RegisterEvent("test", tt.signature, []bool{false, false}))
+
+			// The field after the two inline words is the third word of data
+			event, err := decoder.Decode("test", types.Log{
+				Topics:      decoder.Topics("test"),
+				Data:        "0x" + word("1") + word("2") + word("3"),
+				BlockNumber: "0x1",
+				LogIndex:    "0x0",
+			})
+			assert.NoError(t, err)
+			if assert.NotNil(t, event) {
+				assert.Equal(t, tt.raw, event.Fields["arg0"])
+				assert.Equal(t, big.NewInt(3), event.Fields["arg1"])
+			}
+		})
+	}
+}
+
+func TestDecode_FieldAfterUnsizedTuple(t *testing.T) {
+	tupleEvent_ABI := `[{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": false, "name": "order", "type": "tuple"},
+			{"indexed": false, "name": "amount", "type": "uint256"}
+		],
+		"name": "Filled",
+		"type": "event"
+	}]`
+	decoder := NewStandardDecoder()
+	decoder.KeepRawOnUnknownType = true
+	assert.NoError(t, decoder.RegisterABI("orders", tupleEvent_ABI))
+
+	// The size of the tuple is unknown, so is the offset of amount: the log is skipped
+	event, err := decoder.Decode("orders", types.Log{
+		Topics:      []string{utils.FunctionSignatureToTopic("Filled(tuple,uint256)")},
+		Data:        "0x" + strings.Repeat("0", 64*3),
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
 func TestDecode_ABINotFound(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 
 	log := types.Log{
 		Topics: []string{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
@@ -206,7 +394,7 @@ func TestDecode_ABINotFound(t *testing.T) {
 }
 
 func TestDecode_LogWithNoTopics(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("erc20", erc20Transfer_ABI)
 
 	log := types.Log{
@@ -221,7 +409,7 @@ func TestDecode_LogWithNoTopics(t *testing.T) {
 }
 
 func TestDecode_EventNotInABI(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("erc20", erc20Transfer_ABI)
 
 	// Log with different topic hash (not Transfer)
@@ -238,7 +426,7 @@ func TestDecode_EventNotInABI(t *testing.T) {
 }
 
 func TestDecode_StructureMismatch(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("erc20", erc20Transfer_ABI)
 
 	// ERC721 log (4 topics) but using ERC20 ABI (expects 3 topics)
@@ -259,7 +447,7 @@ func TestDecode_StructureMismatch(t *testing.T) {
 }
 
 func TestDecode_BoolEvent(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("bool", boolEvent_ABI)
 
 	log := types.Log{
@@ -282,7 +470,7 @@ func TestDecode_BoolEvent(t *testing.T) {
 }
 
 func TestDecode_StringEvent(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("string", stringEvent_ABI)
 
 	// String "Hello World" encoded in ABI format
@@ -308,7 +496,7 @@ func TestDecode_StringEvent(t *testing.T) {
 }
 
 func TestRegisterABI_InvalidJSON(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 
 	err := decoder.RegisterABI("test", "invalid json")
 
@@ -317,7 +505,7 @@ func TestRegisterABI_InvalidJSON(t *testing.T) {
 }
 
 func TestRegisterABI_EmptyABI(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 
 	err := decoder.RegisterABI("empty", "[]")
 
@@ -325,7 +513,7 @@ func TestRegisterABI_EmptyABI(t *testing.T) {
 }
 
 func TestRegisterABI_MultipleABIs(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 
 	err1 := decoder.RegisterABI("erc20", erc20Transfer_ABI)
 	err2 := decoder.RegisterABI("erc721", erc721Transfer_ABI)
@@ -372,7 +560,7 @@ func TestRegisterABI_MultipleABIs(t *testing.T) {
 }
 
 func TestDecode_DataTooShort(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("erc20", erc20Transfer_ABI)
 
 	log := types.Log{
@@ -392,8 +580,63 @@ func TestDecode_DataTooShort(t *testing.T) {
 	assert.Nil(t, event)
 }
 
+func TestDecode_DataMissing(t *testing.T) {
+	decoder := NewStandardDecoder()
+	decoder.RegisterABI("erc20", erc20Transfer_ABI)
+
+	// ERC20 Transfer expects its value in data
+	for _, data := range []string{"0x", ""} {
+		log := types.Log{
+			Topics: []string{
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+				"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+			},
+			Data:        data,
+			BlockNumber: "0x1",
+			LogIndex:    "0x0",
+		}
+
+		event, err := decoder.Decode("erc20", log)
+		assert.ErrorIs(t, err, ErrDataMissing)
+		assert.Nil(t, event)
+	}
+
+	// Data too short is skipped, not reported as missing
+	short := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x05f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err := decoder.Decode("erc20", short)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	// No data expected, every parameter is indexed
+	decoder.RegisterABI("erc721", erc721Transfer_ABI)
+	log := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+			"0x0000000000000000000000000000000000000000000000000000000000000123",
+		},
+		Data:        "0x",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err = decoder.Decode("erc721", log)
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+}
+
 func TestDecode_MissingIndexedParameter(t *testing.T) {
-	decoder := NewStandsardDecoder()
+	decoder := NewStandardDecoder()
 	decoder.RegisterABI("erc20", erc20Transfer_ABI)
 
 	// Missing second topic (to address)
@@ -414,3 +657,472 @@ func TestDecode_MissingIndexedParameter(t *testing.T) {
 	assert.Nil(t, event)
 }
 
+
+func benchmarkTransferLog() types.Log {
+	return types.Log{
+		Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:            "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber:     "0x112a880",
+		BlockHash:       "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+		TransactionHash: "0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
+		LogIndex:        "0x5",
+	}
+}
+
+func BenchmarkDecodeTransfer(b *testing.B) {
+	decoder := NewStandardDecoder()
+	if err := decoder.RegisterABI("erc20", erc20Transfer_ABI); err != nil {
+		b.Fatal(err)
+	}
+	log := benchmarkTransferLog()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decoder.Decode("erc20", log); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBatch(b *testing.B) {
+	decoder := NewStandardDecoder()
+	if err := decoder.RegisterABI("erc20", erc20Transfer_ABI); err != nil {
+		b.Fatal(err)
+	}
+	logs := make([]types.Log, 1000)
+	for i := range logs {
+		logs[i] = benchmarkTransferLog()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, log := range logs {
+			if _, err := decoder.Decode("erc20", log); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestRegisterEvent_DecodeTransfer(t *testing.T) {
+	decoder := NewStandardDecoder()
+	err := decoder.RegisterEvent("erc20", "Transfer(address,address,uint256)", []bool{true, true, false})
+	assert.NoError(t, err)
+
+	log := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	event, err := decoder.Decode("erc20", log)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "Transfer", event.EventType)
+	assert.Equal(t, "0xa1b2c3d4e5f6789012345678901234567890abcd", event.Fields["arg0"])
+	assert.Equal(t, "0xf1e2d3c4b5a6978012345678901234567890dcba", event.Fields["arg1"])
+	assert.Equal(t, big.NewInt(100000000), event.Fields["arg2"])
+}
+
+func TestRegisterEvent_CanonicalTypes(t *testing.T) {
+	decoder := NewStandardDecoder()
+	err := decoder.RegisterEvent("erc20", "Transfer(address,address,uint)", []bool{true, true, false})
+	assert.NoError(t, err)
+
+	// Hashed as Transfer(address,address,uint256)
+	topic := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	assert.Equal(t, []string{topic}, decoder.Topics("erc20"))
+	assert.Equal(t, "Transfer(address,address,uint256)", decoder.events["erc20"][topic][0].Signature)
+
+	event, err := decoder.Decode("erc20", types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	})
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, big.NewInt(100000000), event.Fields["arg2"])
+	}
+}
+
+func TestRegisterEvent_InvalidSignature(t *testing.T) {
+	decoder := NewStandardDecoder()
+
+	err := decoder.RegisterEvent("bad", "Transfer(address,address", []bool{true, true})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid event signature")
+
+	err = decoder.RegisterEvent("bad", "(address)", []bool{true})
+	assert.Error(t, err)
+}
+
+func TestRegisterEvent_IndexedCountMismatch(t *testing.T) {
+	decoder := NewStandardDecoder()
+
+	err := decoder.RegisterEvent("erc20", "Transfer(address,address,uint256)", []bool{true, true})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mismatch")
+}
+
+func TestRegisterEventDefinition(t *testing.T) {
+	inputs := []types.EventInput{
+		{Name: "from", Type: "address", Indexed: true},
+		{Name: "to", Type: "address", Indexed: true},
+		{Name: "value", Type: "uint256"},
+	}
+	log := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	// The TopicHash is computed when left empty
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEventDefinition("erc20", types.EventDefinition{
+		Name:      "Transfer",
+		Signature: "Transfer(address,address,uint256)",
+		Inputs:    inputs,
+	}))
+	event, err := decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100000000), event.Fields["value"])
+
+	assert.Error(t, decoder.RegisterEventDefinition("erc20", types.EventDefinition{Name: "Transfer", Signature: "Transfer"}))
+}
+
+func TestDecode_WrongTopicHashIsCaught(t *testing.T) {
+	// Transfer inputs registered under the Approval topic hash
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEventDefinition("erc20", types.EventDefinition{
+		Name:      "Transfer",
+		Signature: "Transfer(address,address,uint256)",
+		TopicHash: "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925",
+		Inputs: []types.EventInput{
+			{Name: "from", Type: "address", Indexed: true},
+			{Name: "to", Type: "address", Indexed: true},
+			{Name: "value", Type: "uint256"},
+		},
+	}))
+
+	// An Approval log would be decoded as a Transfer without the check
+	log := types.Log{
+		Topics: []string{
+			"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err := decoder.Decode("erc20", log)
+	assert.ErrorContains(t, err, "doesn't match the hash 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	assert.Nil(t, event)
+
+	_, err = decoder.DecodeBatch("erc20", []types.Log{log})
+	assert.ErrorContains(t, err, "doesn't match the hash")
+}
+
+func TestDecode_PreservesRawLog(t *testing.T) {
+	decoder := NewStandardDecoder()
+	decoder.RegisterABI("erc20", erc20Transfer_ABI)
+
+	log := types.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:             "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber:      "0x1",
+		TransactionIndex: "0x7",
+		LogIndex:         "0x0",
+		Removed:          true,
+		ChainId:          "137",
+	}
+
+	event, err := decoder.Decode("erc20", log)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.NotNil(t, event.RawLog)
+	assert.Equal(t, "0x7", event.RawLog.TransactionIndex)
+	assert.Equal(t, true, event.RawLog.Removed)
+	assert.Equal(t, log.Data, event.RawLog.Data)
+	assert.Equal(t, "137", event.ChainId)
+}
+
+func TestDecodeERC1155TransferBatch_Successful(t *testing.T) {
+	decoder := NewStandardDecoder()
+	err := decoder.RegisterABI("erc1155", erc1155TransferBatch_ABI)
+	assert.NoError(t, err)
+
+	log := types.Log{
+		Address: "0x76be3b62873462d2142405439777e971754e8e77",
+		Topics: []string{
+			"0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb",
+			"0x0000000000000000000000001111111111111111111111111111111111111111",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		// Head: offset of ids (0x40), offset of values (0xa0)
+		// Tail: ids length 2 then [1, 2], values length 2 then [10, 20]
+		Data: "0x" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"00000000000000000000000000000000000000000000000000000000000000a0" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"000000000000000000000000000000000000000000000000000000000000000a" +
+			"0000000000000000000000000000000000000000000000000000000000000014",
+		BlockNumber:     "0x1",
+		BlockHash:       "0xabcdef",
+		TransactionHash: "0x123456",
+		LogIndex:        "0x0",
+	}
+
+	event, err := decoder.Decode("erc1155", log)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, "TransferBatch", event.EventType)
+	assert.Equal(t, "0x1111111111111111111111111111111111111111", event.Fields["operator"])
+	assert.Equal(t, "0xa1b2c3d4e5f6789012345678901234567890abcd", event.Fields["from"])
+	assert.Equal(t, "0xf1e2d3c4b5a6978012345678901234567890dcba", event.Fields["to"])
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2)}, event.Fields["ids"])
+	assert.Equal(t, []*big.Int{big.NewInt(10), big.NewInt(20)}, event.Fields["values"])
+}
+
+func TestDecodeERC1155TransferBatch_LengthOutOfRange(t *testing.T) {
+	decoder := NewStandardDecoder()
+	err := decoder.RegisterABI("erc1155", erc1155TransferBatch_ABI)
+	assert.NoError(t, err)
+
+	log := types.Log{
+		Topics: []string{
+			"0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb",
+			"0x0000000000000000000000001111111111111111111111111111111111111111",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		// ids claims 255 elements but the data ends after the length
+		Data: "0x" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000060" +
+			"00000000000000000000000000000000000000000000000000000000000000ff",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+
+	event, err := decoder.Decode("erc1155", log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestTopicHasher_Injected(t *testing.T) {
+	var hashed []string
+	decoder := NewStandardDecoder()
+	decoder.TopicHasher = func(signature string) string {
+		hashed = append(hashed, signature)
+		return "0xstub"
+	}
+
+	assert.NoError(t, decoder.RegisterABI("erc20", erc20Transfer_ABI))
+	assert.NoError(t, decoder.RegisterEvent("approval", "Approval(address,address,uint256)", []bool{true, true, false}))
+	assert.Equal(t, []string{"Transfer(address,address,uint256)", "Approval(address,address,uint256)"}, hashed)
+
+	log := types.Log{
+		Topics: []string{
+			"0xstub",
+			"0x000000000000000000000000a1b2c3d4e5f6789012345678901234567890abcd",
+			"0x000000000000000000000000f1e2d3c4b5a6978012345678901234567890dcba",
+		},
+		Data:        "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err := decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, "Transfer", event.EventType)
+		assert.Equal(t, big.NewInt(100000000), event.Fields["value"])
+	}
+
+	// The Keccak256 topic is not registered anymore
+	log.Topics[0] = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	event, err = decoder.Decode("erc20", log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestDecode_MismatchedLogsAreSkipped(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterABI("erc20", erc20Transfer_ABI))
+
+	valid := benchmarkTransferLog()
+
+	missingTopic := benchmarkTransferLog()
+	missingTopic.Topics = missingTopic.Topics[:2]
+
+	emptyTopic := benchmarkTransferLog()
+	emptyTopic.Topics = []string{emptyTopic.Topics[0], "", emptyTopic.Topics[2]}
+
+	shortData := benchmarkTransferLog()
+	shortData.Data = "0x05f5e100"
+
+	// The mismatched logs don't stop the valid ones around them from decoding
+	var decoded []*types.Event
+	for _, log := range []types.Log{valid, missingTopic, emptyTopic, shortData, valid} {
+		event, err := decoder.Decode("erc20", log)
+		assert.NoError(t, err)
+		if event != nil {
+			decoded = append(decoded, event)
+		}
+	}
+	if assert.Len(t, decoded, 2) {
+		assert.Equal(t, big.NewInt(100000000), decoded[0].Fields["value"])
+		assert.Equal(t, big.NewInt(100000000), decoded[1].Fields["value"])
+	}
+
+	// Metadata errors are still reported
+	badBlock := benchmarkTransferLog()
+	badBlock.BlockNumber = "0xzz"
+	_, err := decoder.Decode("erc20", badBlock)
+	assert.Error(t, err)
+}
+
+func TestTopics(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterABI("ERC20", erc20Transfer_ABI))
+	assert.NoError(t, decoder.RegisterABI("ERC20", approvalEvent_ABI))
+
+	expected := []string{
+		utils.FunctionSignatureToTopic("Transfer(address,address,uint256)"),
+		utils.FunctionSignatureToTopic("Approval(address,address,uint256)"),
+	}
+	sort.Strings(expected)
+	assert.Equal(t, expected, decoder.Topics("ERC20"))
+	assert.Nil(t, decoder.Topics("unknown"))
+}
+
+func TestDecode_MultipleDynamicParams(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEvent("pair", "Pair(string,string)", []bool{false, false}))
+	assert.NoError(t, decoder.RegisterEvent("mixed", "Mixed(uint256,string,bytes)", []bool{false, false, false}))
+
+	// Each dynamic param has its own head word pointing into the shared tail
+	pair := types.Log{
+		Topics: decoder.Topics("pair"),
+		Data: "0x" +
+			"0000000000000000000000000000000000000000000000000000000000000040" + // offset of "foo"
+			"0000000000000000000000000000000000000000000000000000000000000080" + // offset of "bar"
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"666f6f0000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"6261720000000000000000000000000000000000000000000000000000000000",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err := decoder.Decode("pair", pair)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, "foo", event.Fields["arg0"])
+		assert.Equal(t, "bar", event.Fields["arg1"])
+	}
+
+	mixed := types.Log{
+		Topics: decoder.Topics("mixed"),
+		Data: "0x" +
+			"000000000000000000000000000000000000000000000000000000000000002a" + // 42
+			"0000000000000000000000000000000000000000000000000000000000000060" + // offset of "foo"
+			"00000000000000000000000000000000000000000000000000000000000000a0" + // offset of 0xbeef
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"666f6f0000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"beef000000000000000000000000000000000000000000000000000000000000",
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	}
+	event, err = decoder.Decode("mixed", mixed)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, big.NewInt(42), event.Fields["arg0"])
+		assert.Equal(t, "foo", event.Fields["arg1"])
+		assert.Equal(t, []byte{0xbe, 0xef}, event.Fields["arg2"])
+	}
+}
+
+func TestDecode_DynamicParamOutOfRange(t *testing.T) {
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEvent("pair", "Pair(string,string)", []bool{false, false}))
+
+	for _, data := range []string{
+		// The second offset points past the data
+		"0x" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000400" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"666f6f0000000000000000000000000000000000000000000000000000000000",
+		// The length of the second string runs past the data
+		"0x" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"00000000000000000000000000000000000000000000000000000000000000ff" +
+			"666f6f0000000000000000000000000000000000000000000000000000000000",
+	} {
+		event, err := decoder.Decode("pair", types.Log{
+			Topics:      decoder.Topics("pair"),
+			Data:        data,
+			BlockNumber: "0x1",
+			LogIndex:    "0x0",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	}
+}
+
+func TestDecode_DynamicArrayOversizedOffset(t *testing.T) {
+	// The offset overflows once converted to a hex position
+	data := "0000000000000000000000000000000000000000000000007fffffffffffffff"
+	_, err := decodeDynamicArray(data, 0, "uint256")
+	assert.ErrorContains(t, err, "array offset out of range")
+	// The head word itself is past the data
+	_, err = decodeDynamicArray(data, 32, "uint256")
+	assert.ErrorContains(t, err, "head offset out of range")
+
+	decoder := NewStandardDecoder()
+	assert.NoError(t, decoder.RegisterEvent("amounts", "Amounts(uint256[])", []bool{false}))
+	event, err := decoder.Decode("amounts", types.Log{
+		Topics:      decoder.Topics("amounts"),
+		Data:        "0x" + data,
+		BlockNumber: "0x1",
+		LogIndex:    "0x0",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+}
@@ -3,8 +3,15 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"net"
+
+	"github.com/ryuux05/godex/pkg/core/types"
 )
 
+// ErrBlockNotFound is matched by the error of a block the node doesn't have yet,
+// e.g. a block just above the head of a load balanced node lagging behind the others.
+var ErrBlockNotFound = errors.New("block not found")
+
 type HTTPError struct {
 	StatusCode int `json:"statusCode"`
 	Message string `json:"message"`
@@ -16,8 +23,13 @@ type RPCError struct  {
 	Data    any    `json:"data,omitempty"`
 }
 
+// ReorgError is returned when the common ancestor of a reorg isn't found within Options.MaxReorgDepth blocks.
+// The chain halts at its cursor instead of rewinding blindly, so an operator can investigate.
 type ReorgError struct {
-
+	// Cursor is the last committed block when the reorg was detected
+	Cursor uint64 `json:"cursor"`
+	// MaxDepth is the configured Options.MaxReorgDepth
+	MaxDepth uint64 `json:"maxDepth"`
 }
 
 // We need to implement the Error function to follow the error interface
@@ -29,6 +41,10 @@ func (e *RPCError) Error() string {
     return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
 }
 
+func (e *ReorgError) Error() string {
+	return fmt.Sprintf("reorg deeper than %d blocks below block %d, common ancestor not found", e.MaxDepth, e.Cursor)
+}
+
 // Helper function to check if the error is retriable
 func IsRetryableError(err error) bool {
 	// Try to extract HTTPError
@@ -54,5 +70,116 @@ func IsRetryableError(err error) bool {
 		}
 	}
 
+	// Inconsistent responses usually come from a bad node behind a load balancer
+	var consistencyErr *ConsistencyError
+	if errors.As(err, &consistencyErr) {
+		return true
+	}
+
+	// A null result usually means the node serving the call isn't synced to the block yet
+	var shapeErr *ResultShapeError
+	if errors.As(err, &shapeErr) && shapeErr.Got == "null" {
+		return true
+	}
+
 	return false
-}
\ No newline at end of file
+}
+
+// IsBlockNotFound reports whether err means the requested block doesn't exist on the node yet
+func IsBlockNotFound(err error) bool {
+	return errors.Is(err, ErrBlockNotFound)
+}
+
+// IsOutageError reports whether err means the provider is unavailable rather than rejecting the request:
+// a retryable error, or a network failure such as a refused connection or a timeout.
+func IsOutageError(err error) bool {
+	if IsRetryableError(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// QuorumError is returned when not enough providers agree on a response
+type QuorumError struct {
+	Method string `json:"method"`
+	// Number of providers that needed to agree
+	Quorum int `json:"quorum"`
+	// Size of the largest group of providers that agreed
+	Agreed int `json:"agreed"`
+	// Number of providers that were queried
+	Providers int `json:"providers"`
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("quorum not reached for %s: %d/%d providers agreed, need %d", e.Method, e.Agreed, e.Providers, e.Quorum)
+}
+
+// ConsistencyError is returned when a provider response contradicts itself or the requested block,
+// e.g. a receipt from another block. Load balanced providers often answer correctly on retry.
+type ConsistencyError struct {
+	Message string `json:"message"`
+}
+
+func (e *ConsistencyError) Error() string {
+	return fmt.Sprintf("inconsistent rpc response: %s", e.Message)
+}
+
+// ReceiptMismatchError is returned with Options.ValidateReceipts when the logs of a window keep carrying
+// another block hash than the header of their block, after the window was fetched WindowRetries more times.
+type ReceiptMismatchError struct {
+	// Block is the first block whose logs disagree with its header
+	Block uint64 `json:"block"`
+	// Attempts is the number of times the window was fetched
+	Attempts int `json:"attempts"`
+}
+
+func (e *ReceiptMismatchError) Error() string {
+	return fmt.Sprintf("logs of block %d disagree with its header after %d attempts", e.Block, e.Attempts)
+}
+
+// ResultShapeError is returned when the result of a call doesn't have the expected JSON shape,
+// e.g. null for a block the node doesn't know or a string where an array is expected.
+type ResultShapeError struct {
+	Method   string `json:"method"`
+	Expected string `json:"expected"`
+	Got      string `json:"got"`
+}
+
+func (e *ResultShapeError) Error() string {
+	return fmt.Sprintf("unexpected %s result: expected %s, got %s", e.Method, e.Expected, e.Got)
+}
+
+// Is reports a null eth_getBlockByNumber result as ErrBlockNotFound
+func (e *ResultShapeError) Is(target error) bool {
+	return target == ErrBlockNotFound && e.Method == "eth_getBlockByNumber" && e.Got == "null"
+}
+
+// FilterError is returned when fetching the logs of a window fails.
+// It carries the failing filter so the error identifies the exact range, topics and addresses.
+type FilterError struct {
+	Filter types.Filter `json:"filter"`
+	Err    error        `json:"-"`
+}
+
+func (e *FilterError) Error() string {
+	var topics any = e.Filter.Topics
+	if len(e.Filter.Topic0) > 0 {
+		topics = append([]any{e.Filter.Topic0}, toAny(e.Filter.Topics)...)
+	}
+	return fmt.Sprintf("error fetching logs of blocks %s-%s (topics: %v, addresses: %v): %v",
+		e.Filter.FromBlock, e.Filter.ToBlock, topics, e.Filter.Address, e.Err)
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// Unwrap exposes the RPC error, so IsRetryableError still sees it
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// AuditDiscrepancy reports a committed block whose logs count differs between the two fetch modes
+type AuditDiscrepancy struct {
+	ChainId string
+	Block   uint64
+	// Mode the block was committed with and its number of logs
+	Mode     FetchMode
+	ModeLogs int
+	// Number of logs returned by the other mode
+	AlternateLogs int
+}
+
+// maxAuditsInFlight bounds the windows audited at the same time in a batch
+const maxAuditsInFlight = 4
+
+// auditQueue runs the audits of a batch off the commit path, at most maxAuditsInFlight at a time
+type auditQueue struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newAuditQueue() *auditQueue {
+	return &auditQueue{slots: make(chan struct{}, maxAuditsInFlight)}
+}
+
+// run starts audit in the background. The audit is skipped when the queue is full,
+// a sample is dropped rather than slowing the commits down.
+func (q *auditQueue) run(audit func()) {
+	select {
+	case q.slots <- struct{}{}:
+	default:
+		log.Println("Audits still in flight, skipping the audit of a window")
+		return
+	}
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		defer func() { <-q.slots }()
+		audit()
+	}()
+}
+
+// wait blocks until the audits in flight are done
+func (q *auditQueue) wait() {
+	q.wg.Wait()
+}
+
+// auditWindow re-fetches a random sample of the blocks of a committed window with the other fetch mode
+// and reports the blocks whose logs count differs. Audit failures are logged and don't affect the chain.
+func (p *Processor) auditWindow(ctx context.Context, chain *chainState, from uint64, to uint64, logs []types.Log) {
+	rate := chain.opts.AuditSampleRate
+	if rate <= 0 {
+		return
+	}
+
+	// Receipts are audited against the logs, the logs against the block receipts
+	alternate := FetchModeReceipts
+	if chain.opts.FetchMode != FetchModeLogs {
+		alternate = FetchModeLogs
+	}
+
+	for block := from; block <= to; block++ {
+		if rate < 1 && rand.Float64() >= rate {
+			continue
+		}
+
+		alternateLogs, err := p.fetchRangeWith(ctx, block, block, alternate, chain)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Audit of block %d failed: %v\n", block, err)
+			}
+			continue
+		}
+
+		modeLogs, alternateCount := countBlockLogs(logs, block), countBlockLogs(alternateLogs, block)
+		if modeLogs == alternateCount {
+			continue
+		}
+
+		log.Printf("Audit of block %d: %d logs with %s but %d with %s\n", block, modeLogs, chain.opts.FetchMode, alternateCount, alternate)
+		chain.stats.auditDiscrepancies.Add(1)
+		if chain.opts.OnAuditDiscrepancy != nil {
+			chain.opts.OnAuditDiscrepancy(AuditDiscrepancy{
+				ChainId:       chain.chainInfo.ChainId,
+				Block:         block,
+				Mode:          chain.opts.FetchMode,
+				ModeLogs:      modeLogs,
+				AlternateLogs: alternateCount,
+			})
+		}
+	}
+}
+
+// countBlockLogs counts the logs of block, removals excluded
+func countBlockLogs(logs []types.Log, block uint64) int {
+	count := 0
+	for _, l := range logs {
+		blockNumber, err := utils.HexQtyToUint64(l.BlockNumber)
+		if err == nil && blockNumber == block && !l.Removed {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,193 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSampleRate_ReportsDiscrepancy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x3"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			// One log per block
+			result = []map[string]any{
+				{"address": "0xabc", "blockNumber": "0x1", "logIndex": "0x0"},
+				{"address": "0xabc", "blockNumber": "0x2", "logIndex": "0x0"},
+				{"address": "0xabc", "blockNumber": "0x3", "logIndex": "0x0"},
+			}
+		case "eth_getBlockReceipts":
+			block := req.Params[0].(string)
+			logs := []map[string]any{{"address": "0xabc", "blockNumber": block, "logIndex": "0x0"}}
+			// eth_getLogs dropped a log of block 2
+			if block == "0x2" {
+				logs = append(logs, map[string]any{"address": "0xabc", "blockNumber": block, "logIndex": "0x1"})
+			}
+			// A removal isn't a log of the block, block 3 has no discrepancy
+			if block == "0x3" {
+				logs = append(logs, map[string]any{"address": "0xabc", "blockNumber": block, "logIndex": "0x1", "removed": true})
+			}
+			result = []map[string]any{{"blockNumber": block, "transactionHash": "0xth" + block, "logs": logs}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var discrepancies []AuditDiscrepancy
+	opts := Options{
+		RangeSize:       10,
+		LogsBufferSize:  10,
+		EndBlock:        3,
+		AuditSampleRate: 1,
+		OnAuditDiscrepancy: func(d AuditDiscrepancy) {
+			mu.Lock()
+			defer mu.Unlock()
+			discrepancies = append(discrepancies, d)
+		},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	// The audit doesn't change what is emitted
+	assert.Len(t, processor.DrainLogs(chain.ChainId), 3)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []AuditDiscrepancy{{
+		ChainId:       "1",
+		Block:         2,
+		Mode:          FetchModeLogs,
+		ModeLogs:      1,
+		AlternateLogs: 2,
+	}}, discrepancies)
+	assert.Equal(t, uint64(1), processor.Stats().Chains[chain.ChainId].AuditDiscrepancies)
+}
+
+func TestAuditSampleRate_DoesNotBlockCommits(t *testing.T) {
+	// The audit fetches hang until the committed logs are received
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x3"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		case "eth_getBlockReceipts":
+			<-release
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+	var releaseOnce sync.Once
+	releaseAudits := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseAudits()
+
+	opts := Options{
+		RangeSize:       1,
+		LogsBufferSize:  10,
+		EndBlock:        3,
+		AuditSampleRate: 1,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+	logsCh, err := processor.Logs(chain.ChainId)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- processor.Run(ctx)
+	}()
+
+	// Every window commits while its audit is still in flight
+	for i := 0; i < 3; i++ {
+		select {
+		case <-logsCh:
+		case <-time.After(time.Second):
+			t.Fatalf("log %d not committed while the audits are in flight", i)
+		}
+	}
+	releaseAudits()
+	assert.NoError(t, <-runErr)
+	assert.Equal(t, uint64(3), processor.Stats().Chains[chain.ChainId].AuditDiscrepancies)
+}
+
+func TestCountBlockLogs(t *testing.T) {
+	logs := []types.Log{
+		{BlockNumber: "0x1", LogIndex: "0x0"},
+		{BlockNumber: "0x1", LogIndex: "0x1"},
+		// Removals don't count
+		{BlockNumber: "0x1", LogIndex: "0x2", Removed: true},
+		{BlockNumber: "0x3", LogIndex: "0x0"},
+	}
+	assert.Equal(t, 2, countBlockLogs(logs, 1))
+	assert.Equal(t, 0, countBlockLogs(logs, 2))
+	assert.Equal(t, 0, countBlockLogs(nil, 1))
+}
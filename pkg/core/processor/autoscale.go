@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// defaultScalingSampleSize is the ConcurrencyScaling.SampleSize used when it is not set
+const defaultScalingSampleSize = 10
+
+// defaultScalingMaxErrorRate is the ConcurrencyScaling.MaxErrorRate used when it is not set
+const defaultScalingMaxErrorRate = 0.1
+
+// ConcurrencyScaling adjusts the number of fetchers of a chain at runtime from the observed RPC latency and error rate.
+// Every SampleSize fetch calls, a fetcher is added while the calls are fast and rarely fail,
+// removed when they slow down, and the fetchers are halved when they fail too often, e.g. on rate limiting.
+// FetcherConcurrency is the initial number of fetchers, clamped to the bounds.
+type ConcurrencyScaling struct {
+	// MinConcurrency is the lowest number of fetchers, defaults to 1
+	MinConcurrency int
+	// MaxConcurrency is the highest number of fetchers, required
+	MaxConcurrency int
+	// TargetLatency is the average fetch call latency under which a fetcher is added.
+	// Above twice the target a fetcher is removed. Required.
+	TargetLatency time.Duration
+	// MaxErrorRate is the fraction of failed fetch calls, between 0 and 1, above which the fetchers are halved, defaults to 0.1
+	MaxErrorRate float64
+	// SampleSize is the number of fetch calls between two adjustments, defaults to 10
+	SampleSize int
+}
+
+// concurrencyScaler bounds the windows fetched at the same time of a chain with ConcurrencyScaling.
+// The pool spawns MaxConcurrency fetchers and each one holds a slot while it fetches,
+// so the limit can change while windows are in flight without restarting the batch.
+type concurrencyScaler struct {
+	cfg ConcurrencyScaling
+	chainId string
+
+	mu sync.Mutex
+	limit int
+	inUse int
+	// wake is closed when a slot may be free, then replaced
+	wake chan struct{}
+	// Fetch calls observed since the last adjustment
+	calls int
+	failures int
+	latency time.Duration
+}
+
+// newConcurrencyScaler validates cfg and fills in its defaults, the limit starts at initial clamped to the bounds
+func newConcurrencyScaler(chainId string, cfg ConcurrencyScaling, initial int) (*concurrencyScaler, error) {
+	if cfg.MinConcurrency <= 0 {
+		cfg.MinConcurrency = 1
+	}
+	if cfg.MaxConcurrency < cfg.MinConcurrency {
+		return nil, fmt.Errorf("MaxConcurrency must be at least MinConcurrency %d, got %d", cfg.MinConcurrency, cfg.MaxConcurrency)
+	}
+	if cfg.TargetLatency <= 0 {
+		return nil, fmt.Errorf("TargetLatency must be positive, got %s", cfg.TargetLatency)
+	}
+	if cfg.MaxErrorRate < 0 || cfg.MaxErrorRate > 1 {
+		return nil, fmt.Errorf("MaxErrorRate must be between 0 and 1, got %v", cfg.MaxErrorRate)
+	}
+	if cfg.MaxErrorRate == 0 {
+		cfg.MaxErrorRate = defaultScalingMaxErrorRate
+	}
+	if cfg.SampleSize <= 0 {
+		cfg.SampleSize = defaultScalingSampleSize
+	}
+
+	return &concurrencyScaler{
+		cfg: cfg,
+		chainId: chainId,
+		limit: min(max(initial, cfg.MinConcurrency), cfg.MaxConcurrency),
+		wake: make(chan struct{}),
+	}, nil
+}
+
+// fetcherConcurrency returns the number of fetchers of the chain allowed to fetch at the same time
+func (c *chainState) fetcherConcurrency() int {
+	if c.scaler != nil {
+		return c.scaler.current()
+	}
+	return max(c.opts.FetcherConcurrency, 1)
+}
+
+// current returns the number of fetchers allowed to fetch at the same time
+func (s *concurrencyScaler) current() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+// acquire waits for a fetch slot, false when ctx is done first. A nil scaler never waits.
+func (s *concurrencyScaler) acquire(ctx context.Context) bool {
+	if s == nil {
+		return true
+	}
+	for {
+		s.mu.Lock()
+		if s.inUse < s.limit {
+			s.inUse++
+			s.mu.Unlock()
+			return true
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-wake:
+		}
+	}
+}
+
+// release gives back a slot taken by acquire
+func (s *concurrencyScaler) release() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inUse--
+	s.broadcast()
+}
+
+// observe records a fetch call and adjusts the limit once SampleSize calls were observed.
+// Calls aborted by a canceled batch should not be observed.
+func (s *concurrencyScaler) observe(latency time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	s.latency += latency
+	if err != nil {
+		s.failures++
+	}
+	if s.calls < s.cfg.SampleSize {
+		return
+	}
+
+	errorRate := float64(s.failures) / float64(s.calls)
+	average := s.latency / time.Duration(s.calls)
+	s.calls, s.failures, s.latency = 0, 0, 0
+
+	limit := s.limit
+	switch {
+	case errorRate > s.cfg.MaxErrorRate:
+		limit = max(limit/2, s.cfg.MinConcurrency)
+	case average > 2*s.cfg.TargetLatency:
+		limit = max(limit-1, s.cfg.MinConcurrency)
+	case average < s.cfg.TargetLatency:
+		limit = min(limit+1, s.cfg.MaxConcurrency)
+	}
+	if limit == s.limit {
+		return
+	}
+
+	log.Printf("Chain %s fetcher concurrency %d -> %d (average latency %s, error rate %.2f)\n", s.chainId, s.limit, limit, average, errorRate)
+	s.limit = limit
+	s.broadcast()
+}
+
+// broadcast wakes up the fetchers waiting for a slot, the caller holds the lock
+func (s *concurrencyScaler) broadcast() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
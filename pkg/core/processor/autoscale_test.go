@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyScaler_AdjustsWithinBounds(t *testing.T) {
+	scaler, err := newConcurrencyScaler("1", ConcurrencyScaling{MinConcurrency: 2, MaxConcurrency: 6, TargetLatency: 100 * time.Millisecond, SampleSize: 5}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, scaler.current())
+
+	sample := func(latency time.Duration, failures int) {
+		for i := 0; i < 5; i++ {
+			var err error
+			if i < failures {
+				err = fmt.Errorf("429 too many requests")
+			}
+			scaler.observe(latency, err)
+		}
+	}
+
+	// Fast calls add a fetcher per sample, up to the max
+	for i := 0; i < 10; i++ {
+		sample(10*time.Millisecond, 0)
+	}
+	assert.Equal(t, 6, scaler.current())
+
+	// Between the target and twice it nothing moves
+	sample(150*time.Millisecond, 0)
+	assert.Equal(t, 6, scaler.current())
+
+	// Slow calls remove a fetcher per sample, down to the min
+	sample(300*time.Millisecond, 0)
+	assert.Equal(t, 5, scaler.current())
+	for i := 0; i < 10; i++ {
+		sample(300*time.Millisecond, 0)
+	}
+	assert.Equal(t, 2, scaler.current())
+
+	// Rate limiting halves the fetchers even when the calls are fast
+	for i := 0; i < 4; i++ {
+		sample(10*time.Millisecond, 0)
+	}
+	assert.Equal(t, 6, scaler.current())
+	sample(10*time.Millisecond, 1)
+	assert.Equal(t, 3, scaler.current())
+	sample(10*time.Millisecond, 2)
+	assert.Equal(t, 2, scaler.current())
+}
+
+func TestConcurrencyScaler_InvalidConfig(t *testing.T) {
+	_, err := newConcurrencyScaler("1", ConcurrencyScaling{MinConcurrency: 4, MaxConcurrency: 2, TargetLatency: time.Second}, 1)
+	assert.Error(t, err)
+	_, err = newConcurrencyScaler("1", ConcurrencyScaling{MaxConcurrency: 2}, 1)
+	assert.Error(t, err)
+	_, err = newConcurrencyScaler("1", ConcurrencyScaling{MaxConcurrency: 2, TargetLatency: time.Second, MaxErrorRate: 2}, 1)
+	assert.Error(t, err)
+
+	// The initial concurrency is clamped to the bounds
+	scaler, err := newConcurrencyScaler("1", ConcurrencyScaling{MaxConcurrency: 2, TargetLatency: time.Second}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, scaler.current())
+}
+
+func TestConcurrencyScaling_FollowsLatency(t *testing.T) {
+	var latency atomic.Int64
+	latency.Store(int64(time.Millisecond))
+	var inFlight, maxInFlight atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = utils.Uint64ToHexQty(1_000_000)
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			n := inFlight.Add(1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(latency.Load()))
+			inFlight.Add(-1)
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          10,
+		FetcherConcurrency: 1,
+		ConcurrencyScaling: &ConcurrencyScaling{MaxConcurrency: 4, TargetLatency: 20 * time.Millisecond, SampleSize: 4},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = processor.Run(ctx) }()
+
+	concurrency := func() int {
+		health, err := processor.Health("1")
+		assert.NoError(t, err)
+		return health.FetcherConcurrency
+	}
+
+	// A fast provider gets more fetchers
+	assert.Eventually(t, func() bool { return concurrency() == 4 }, 5*time.Second, time.Millisecond)
+
+	// A slow one gets fewer
+	latency.Store(int64(60 * time.Millisecond))
+	assert.Eventually(t, func() bool { return concurrency() == 1 }, 5*time.Second, time.Millisecond)
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int64(4))
+	assert.Greater(t, maxInFlight.Load(), int64(1))
+}
@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// blockCache is a bounded LRU of recently fetched blocks, indexed by height.
+// It lets the reorg check, the reorg walk back and the window hash storage share one fetch per block.
+// A nil cache is valid and caches nothing.
+type blockCache struct {
+	size     int
+	order    *list.List // front is the most recently used
+	byNumber map[uint64]*list.Element
+	mu       sync.Mutex
+}
+
+type cachedBlock struct {
+	number uint64
+	block  types.Block
+}
+
+func newBlockCache(size int) *blockCache {
+	if size <= 0 {
+		return nil
+	}
+	return &blockCache{
+		size:     size,
+		order:    list.New(),
+		byNumber: make(map[uint64]*list.Element, size),
+	}
+}
+
+func (c *blockCache) get(number uint64) (types.Block, bool) {
+	if c == nil {
+		return types.Block{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.byNumber[number]
+	if !ok {
+		return types.Block{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedBlock).block, true
+}
+
+func (c *blockCache) put(number uint64, block types.Block) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.byNumber[number]; ok {
+		c.remove(e)
+	}
+	e := c.order.PushFront(&cachedBlock{number: number, block: block})
+	c.byNumber[number] = e
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidateFrom drops every block at or above number
+func (c *blockCache) invalidateFrom(number uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n, e := range c.byNumber {
+		if n >= number {
+			c.remove(e)
+		}
+	}
+}
+
+// invalidateRange drops the blocks of [from..to]
+func (c *blockCache) invalidateRange(from uint64, to uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n, e := range c.byNumber {
+		if n >= from && n <= to {
+			c.remove(e)
+		}
+	}
+}
+
+// remove unlinks an entry, the caller must hold the lock
+func (c *blockCache) remove(e *list.Element) {
+	cb := e.Value.(*cachedBlock)
+	c.order.Remove(e)
+	delete(c.byNumber, cb.number)
+}
+
+// getBlock returns the block at number, from the cache when possible
+func (p *Processor) getBlock(ctx context.Context, chain *chainState, number uint64) (types.Block, error) {
+	if block, ok := chain.blockCache.get(number); ok {
+		return block, nil
+	}
+
+	block, err := chain.chainInfo.RPC.GetBlock(ctx, utils.Uint64ToHexQty(number))
+	chain.recordError("eth_getBlockByNumber", number, number, chain.stats.recordRPC(err))
+	if err != nil {
+		return types.Block{}, err
+	}
+	chain.blockCache.put(number, block)
+	return block, nil
+}
+
+// getBlocks returns the blocks of [from..to] in order, from the cache when possible
+func (p *Processor) getBlocks(ctx context.Context, from uint64, to uint64, chain *chainState) ([]types.Block, error) {
+	blocks := make([]types.Block, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		block, err := p.getBlock(ctx, chain, number)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newBlockCache(2)
+	c.put(1, types.Block{Hash: "0x1"})
+	c.put(2, types.Block{Hash: "0x2"})
+
+	// Touch 1 so 2 is the least recently used
+	_, ok := c.get(1)
+	assert.True(t, ok)
+	c.put(3, types.Block{Hash: "0x3"})
+
+	_, ok = c.get(2)
+	assert.False(t, ok)
+
+	block, ok := c.get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "0x1", block.Hash)
+	_, ok = c.get(3)
+	assert.True(t, ok)
+}
+
+func TestBlockCache_InvalidateFrom(t *testing.T) {
+	c := newBlockCache(8)
+	for i := uint64(1); i <= 5; i++ {
+		c.put(i, types.Block{Hash: utils.Uint64ToHexQty(i)})
+	}
+
+	c.invalidateFrom(3)
+
+	for i := uint64(1); i <= 5; i++ {
+		_, ok := c.get(i)
+		assert.Equal(t, i < 3, ok)
+	}
+}
+
+func TestBlockCache_NilIsDisabled(t *testing.T) {
+	c := newBlockCache(0)
+	assert.Nil(t, c)
+
+	c.put(1, types.Block{Hash: "0x1"})
+	_, ok := c.get(1)
+	assert.False(t, ok)
+	c.invalidateFrom(0)
+}
+
+// countGetBlockCalls runs a chain with one reorg at block 41 and returns the number of eth_getBlockByNumber calls
+func countGetBlockCalls(t *testing.T, cacheSize int) uint64 {
+	var getBlockCalls atomic.Uint64
+	var mu sync.Mutex
+	flip := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x64"})
+		case "eth_getBlockByNumber":
+			getBlockCalls.Add(1)
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+
+			parentHash := utils.Uint64ToHexQty(blockNum - 1)
+			mu.Lock()
+			if !flip && blockNum == 41 {
+				flip = true
+				parentHash = "0xforked"
+			}
+			mu.Unlock()
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result": map[string]any{
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": parentHash,
+				},
+			})
+		case "eth_getLogs":
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": []any{}})
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          10,
+		FetcherConcurrency: 1,
+		LogsBufferSize:     1024,
+		BlockCacheSize:     cacheSize,
+	})
+	assert.NoError(t, err)
+
+	_ = processor.Run(ctx)
+
+	assert.Equal(t, uint64(1), processor.Stats().Total.Reorgs)
+	return getBlockCalls.Load()
+}
+
+func TestBlockCache_SharesFetchesAcrossReorgCheck(t *testing.T) {
+	withoutCache := countGetBlockCalls(t, 0)
+	withCache := countGetBlockCalls(t, 64)
+
+	// The block at the found ancestor + 1 is fetched by the walk back and reused by the re-planned window
+	assert.Less(t, withCache, withoutCache)
+}
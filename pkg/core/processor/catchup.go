@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"log"
+)
+
+// checkCaughtUp fires the caught up signal of the chain the first time its cursor is within conf blocks of the last fetched head
+func (c *chainState) checkCaughtUp(conf uint64) {
+	head := c.head.Load()
+	if c.watermark.Load()+conf < head {
+		return
+	}
+	c.caughtUpOnce.Do(func() {
+		log.Printf("Chain %s caught up with head %d at block %d, following the tip\n", c.chainInfo.ChainId, head, c.watermark.Load())
+		close(c.caughtUp)
+	})
+}
+
+// CaughtUp returns a channel closed the first time the chain catches up with the head,
+// i.e. its cursor is within the confirmations of the last fetched head. It marks the end of the backfill:
+// the chain follows the tip from then on, even if it falls behind again later.
+// A bounded chain completing before reaching the head never fires it.
+// It returns nil for an unknown chain.
+func (p *Processor) CaughtUp(chainId string) <-chan struct{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return nil
+	}
+	return chain.caughtUp
+}
+
+// isCaughtUp tells whether the caught up signal fired
+func (c *chainState) isCaughtUp() bool {
+	select {
+	case <-c.caughtUp:
+		return true
+	default:
+		return false
+	}
+}
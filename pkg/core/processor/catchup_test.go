@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaughtUp_FiresOnceAfterBackfill(t *testing.T) {
+	var head atomic.Uint64
+	head.Store(500)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = utils.Uint64ToHexQty(head.Load())
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:    50,
+		Confimation:  2,
+		PollInterval: 5 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, processor.CaughtUp("2"))
+
+	caughtUp := processor.CaughtUp("1")
+	health, err := processor.Health("1")
+	assert.NoError(t, err)
+	assert.False(t, health.CaughtUp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- processor.Run(ctx) }()
+
+	// Fires once the backfill reaches head minus the confirmations
+	select {
+	case <-caughtUp:
+	case <-time.After(5 * time.Second):
+		t.Fatal("chain never caught up")
+	}
+	watermark, err := processor.Watermark("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(498), watermark)
+
+	// Falling behind again doesn't reset it, the signal is about the end of the backfill
+	head.Store(1000)
+	assert.Eventually(t, func() bool {
+		health, err := processor.Health("1")
+		return err == nil && health.Head == 1000 && health.Watermark == 998
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-runErr)
+
+	health, err = processor.Health("1")
+	assert.NoError(t, err)
+	assert.True(t, health.CaughtUp)
+	// Still the same closed channel
+	assert.Equal(t, caughtUp, processor.CaughtUp("1"))
+}
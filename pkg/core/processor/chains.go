@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// addChainCallTimeout bounds the eth_chainId call of VerifyChainID made by AddChain
+const addChainCallTimeout = 10 * time.Second
+
+// ChainConfig is a chain to add with AddChains
+type ChainConfig struct {
+	Info ChainInfo
+	Opts *Options
+}
+
+// AddChains adds several chains at once, all or nothing: every entry is validated first,
+// and if any is invalid none is added and the errors of all the invalid entries are returned joined.
+// Like AddChain, it can't be called while the processor is running.
+func (p *Processor) AddChains(chains []ChainConfig) error {
+	return p.AddChainsContext(context.Background(), chains)
+}
+
+// AddChainsContext is AddChains with a context bounding the chain id checks of VerifyChainID
+func (p *Processor) AddChainsContext(ctx context.Context, chains []ChainConfig) error {
+	// Ask the nodes before locking, a slow endpoint doesn't block the processor
+	verifyErrs := make([]error, len(chains))
+	for i, chain := range chains {
+		if chain.Opts != nil && chain.Opts.VerifyChainID {
+			verifyErrs[i] = verifyChainID(ctx, chain.Info)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isRunning {
+		return fmt.Errorf("cannot add chains while processor is running")
+	}
+
+	states := make([]*chainState, 0, len(chains))
+	seen := make(map[string]struct{}, len(chains))
+	var errs []error
+	for i, chain := range chains {
+		if _, exists := seen[chain.Info.ChainId]; exists {
+			errs = append(errs, fmt.Errorf("chain %d: chain %s is added twice", i, chain.Info.ChainId))
+			continue
+		}
+		seen[chain.Info.ChainId] = struct{}{}
+
+		if chain.Opts == nil {
+			errs = append(errs, fmt.Errorf("chain %d (%s): options are required", i, chain.Info.ChainId))
+			continue
+		}
+		state, err := newChainState(chain.Info, chain.Opts)
+		if err == nil {
+			err = verifyErrs[i]
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chain %d (%s): %w", i, chain.Info.ChainId, err))
+			continue
+		}
+		states = append(states, state)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, state := range states {
+		p.register(state)
+	}
+	return nil
+}
+
+// RemoveChain removes a chain and releases its resources: its logs, logs batch and blocks channels
+// and the channels of its subscribers are closed, as well as its Completion channel.
+// It can't be called while the processor is running.
+func (p *Processor) RemoveChain(chainId string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isRunning {
+		return fmt.Errorf("cannot remove chain while processor is running")
+	}
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainId)
+	}
+
+	// Already closed if the chain completed
+	p.completeChain(p.logsCh[chainId], p.logsBatchCh[chainId], p.blocksCh[chainId], chain)
+	close(chain.completion)
+
+	delete(p.chains, chainId)
+	delete(p.logsCh, chainId)
+	delete(p.logsBatchCh, chainId)
+	delete(p.blocksCh, chainId)
+	return nil
+}
+
+// verifyChainID checks that the node of chain reports the chain id of chain.ChainId,
+// given in decimal (e.g. "137") or hex (e.g. "0x89")
+func verifyChainID(ctx context.Context, chain ChainInfo) error {
+	client, ok := chain.RPC.(rpc.ChainIDRPC)
+	if !ok {
+		return fmt.Errorf("VerifyChainID requires an RPC implementing rpc.ChainIDRPC, got %T", chain.RPC)
+	}
+
+	// Decimal or 0x/0X prefixed hex, like the id the RPC reports
+	expected, err := utils.HexQtyToUint64(chain.ChainId)
+	if err != nil {
+		return fmt.Errorf("chain id %q is not a number: %w", chain.ChainId, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, addChainCallTimeout)
+	defer cancel()
+	reported, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting chain id of chain %s: %w", chain.ChainId, err)
+	}
+	got, err := utils.HexQtyToUint64(reported)
+	if err != nil {
+		return fmt.Errorf("malformed chain id %q reported for chain %s: %w", reported, chain.ChainId, err)
+	}
+	if got != expected {
+		return fmt.Errorf("chain %s: the RPC endpoint reports chain id %d, it serves another chain", chain.ChainId, got)
+	}
+	return nil
+}
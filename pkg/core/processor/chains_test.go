@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddChains(t *testing.T) {
+	client := rpc.NewHTTPRPC("http://localhost", 0)
+
+	processor := NewProcessor()
+	err := processor.AddChains([]ChainConfig{
+		{Info: ChainInfo{ChainId: "1", RPC: client}, Opts: &Options{RangeSize: 10}},
+		{Info: ChainInfo{ChainId: "10", RPC: client}, Opts: &Options{RangeSize: 10}},
+	})
+	assert.NoError(t, err)
+	_, err = processor.GetChain("1")
+	assert.NoError(t, err)
+	_, err = processor.GetChain("10")
+	assert.NoError(t, err)
+}
+
+func TestAddChains_InvalidEntryAddsNothing(t *testing.T) {
+	client := rpc.NewHTTPRPC("http://localhost", 0)
+
+	processor := NewProcessor()
+	err := processor.AddChains([]ChainConfig{
+		{Info: ChainInfo{ChainId: "1", RPC: client}, Opts: &Options{RangeSize: 10}},
+		{Info: ChainInfo{ChainId: "10", RPC: client}, Opts: &Options{RangeSize: 10, StartFrom: "tomorrow"}},
+		{Info: ChainInfo{ChainId: "137", RPC: client}, Opts: &Options{RangeSize: 10}},
+		{Info: ChainInfo{ChainId: "137", RPC: client}, Opts: &Options{RangeSize: 10}},
+	})
+	// Every invalid entry is reported
+	assert.ErrorContains(t, err, "unknown start mode")
+	assert.ErrorContains(t, err, "added twice")
+
+	for _, chainId := range []string{"1", "10", "137"} {
+		_, err := processor.GetChain(chainId)
+		assert.Error(t, err)
+		_, err = processor.Logs(chainId)
+		assert.Error(t, err)
+	}
+}
+
+func TestRemoveChain_ClosesChannels(t *testing.T) {
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)},
+		&Options{RangeSize: 10, EmitBatches: true, EmitBlocks: true}))
+
+	logs, err := processor.Logs("1")
+	assert.NoError(t, err)
+	batches, err := processor.LogsBatched("1")
+	assert.NoError(t, err)
+	blocks, err := processor.Blocks("1")
+	assert.NoError(t, err)
+	sub, unsubscribe, err := processor.Subscribe("1")
+	assert.NoError(t, err)
+	defer unsubscribe()
+	completion := processor.Completion("1")
+
+	assert.NoError(t, processor.RemoveChain("1"))
+
+	_, open := <-logs
+	assert.False(t, open)
+	_, open = <-batches
+	assert.False(t, open)
+	_, open = <-blocks
+	assert.False(t, open)
+	_, open = <-sub
+	assert.False(t, open)
+	_, open = <-completion
+	assert.False(t, open)
+
+	_, err = processor.GetChain("1")
+	assert.Error(t, err)
+	assert.Error(t, processor.RemoveChain("1"))
+}
+
+func TestRemoveChain_NoLeak(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x5"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{
+				{"address": "0xabc", "topics": []string{"0x01"}, "data": "0x", "blockNumber": "0x1", "logIndex": "0x0"},
+			}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+	client := rpc.NewHTTPRPC(srv.URL, 0)
+	processor := NewProcessor()
+
+	// cycle adds a chain with a subscriber, runs it for a while then removes it
+	cycle := func(bounded bool) {
+		opts := &Options{RangeSize: 10, PollInterval: time.Millisecond, SubscribersOnly: true}
+		if bounded {
+			opts.EndBlock = 5
+		}
+		assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: client}, opts))
+		sub, unsubscribe, err := processor.Subscribe("1")
+		assert.NoError(t, err)
+		defer unsubscribe()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runErr := make(chan error, 1)
+		go func() { runErr <- processor.Run(ctx) }()
+		assert.Equal(t, "0x1", (<-sub).BlockNumber)
+		if !bounded {
+			cancel()
+		}
+		assert.NoError(t, <-runErr)
+
+		assert.NoError(t, processor.RemoveChain("1"))
+		for range sub {
+		}
+	}
+
+	// Warm up the HTTP connections before counting
+	cycle(true)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		cycle(i%2 == 0)
+	}
+	assert.Empty(t, processor.chains)
+	assert.Empty(t, processor.logsCh)
+	assert.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= before+2
+	}, 5*time.Second, 10*time.Millisecond)
+}
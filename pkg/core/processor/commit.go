@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// runOnCommit calls Options.OnCommit for the window from-end, retrying it up to WindowRetries times
+// with the backoff of the retry config. Every failure is recorded in RecentErrors,
+// the last one is returned to stop the chain.
+func (p *Processor) runOnCommit(ctx context.Context, chain *chainState, from uint64, end uint64, logs []types.Log) error {
+	for attempt := 0; ; attempt++ {
+		err := chain.opts.OnCommit(chain.chainInfo.ChainId, end, logs)
+		if err == nil {
+			return nil
+		}
+		chain.recordError("OnCommit", from, end, err)
+		if attempt >= chain.opts.WindowRetries {
+			return fmt.Errorf("commit callback of blocks %d-%d failed after %d attempts: %w", from, end, attempt+1, err)
+		}
+		log.Printf("Commit callback of blocks %d-%d failed, retrying it (%d/%d): %v\n", from, end, attempt+1, chain.opts.WindowRetries, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-chain.opts.Clock.After(chain.retry().Backoff(attempt+1, err)):
+		}
+	}
+}
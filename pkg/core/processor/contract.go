@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// ContractFilter watches a contract for a set of events
+type ContractFilter struct {
+	// Address of the contract, matching is case-insensitive
+	Address string
+	// Signatures are the events to watch, as function signatures or topic hashes like Options.Topics.
+	// Leave empty to watch every event of the contract.
+	Signatures []string
+}
+
+// contractFilters is the compiled form of Options.Contracts:
+// the topic0 set watched by lowercased contract address, an empty set watches every event
+type contractFilters map[string]map[string]struct{}
+
+// compileContracts compiles contracts into their filters and the addresses and topic0 of the logs filter.
+// The logs filter matches any of the addresses with any of the topics, so it may return logs of an event
+// watched on another contract only, drop them with matches. topics is empty when a contract watches every event.
+func compileContracts(contracts []ContractFilter) (contractFilters, []string, []string, error) {
+	filters := make(contractFilters, len(contracts))
+	watchAll := false
+	for _, contract := range contracts {
+		if contract.Address == "" {
+			return nil, nil, nil, fmt.Errorf("contract filter without address")
+		}
+		address := strings.ToLower(contract.Address)
+
+		set, exists := filters[address]
+		// A contract listed twice watches the events of both entries
+		if exists && len(set) == 0 {
+			continue
+		}
+		if !exists || len(contract.Signatures) == 0 {
+			set = make(map[string]struct{}, len(contract.Signatures))
+			filters[address] = set
+		}
+		if len(contract.Signatures) == 0 {
+			watchAll = true
+			continue
+		}
+		for _, topic := range utils.ConvertToTopics(contract.Signatures) {
+			set[strings.ToLower(topic)] = struct{}{}
+		}
+	}
+
+	addresses := make([]string, 0, len(filters))
+	topicSet := make(map[string]struct{})
+	for address, set := range filters {
+		addresses = append(addresses, address)
+		for topic := range set {
+			topicSet[topic] = struct{}{}
+		}
+	}
+	sort.Strings(addresses)
+
+	var topics []string
+	if !watchAll {
+		topics = make([]string, 0, len(topicSet))
+		for topic := range topicSet {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+	}
+
+	return filters, addresses, topics, nil
+}
+
+// matches reports whether log was emitted by a watched contract for one of its events
+func (f contractFilters) matches(log types.Log) bool {
+	set, exists := f[strings.ToLower(log.Address)]
+	if !exists {
+		return false
+	}
+	if len(set) == 0 {
+		return true
+	}
+	if len(log.Topics) == 0 {
+		return false
+	}
+	_, ok := set[strings.ToLower(log.Topics[0])]
+	return ok
+}
+
+// filter drops in place the logs not matching any contract
+func (f contractFilters) filter(logs []types.Log) []types.Log {
+	kept := logs[:0]
+	for _, l := range logs {
+		if f.matches(l) {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContracts_WatchDifferentEvents(t *testing.T) {
+	transfer := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+	swap := utils.FunctionSignatureToTopic("Swap(address,uint256,uint256,uint256,uint256,address)")
+	token := "0x00000000000000000000000000000000000000aa"
+	pool := "0x00000000000000000000000000000000000000bb"
+
+	// The logs the union filter returns, the pool Transfer is not watched
+	providerLogs := []map[string]any{
+		{"address": token, "topics": []string{transfer}, "blockNumber": "0x1", "blockHash": "0x1", "logIndex": "0x0"},
+		{"address": pool, "topics": []string{transfer}, "blockNumber": "0x1", "blockHash": "0x1", "logIndex": "0x1"},
+		{"address": "0x00000000000000000000000000000000000000BB", "topics": []string{swap}, "blockNumber": "0x1", "blockHash": "0x1", "logIndex": "0x2"},
+	}
+
+	for _, mode := range []FetchMode{FetchModeLogs, FetchModeReceipts} {
+		t.Run(string(mode), func(t *testing.T) {
+			var filter map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				var req struct {
+					Method string        `json:"method"`
+					Params []interface{} `json:"params"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				var result any
+				switch req.Method {
+				case "eth_blockNumber":
+					result = "0x1"
+				case "eth_getBlockByNumber":
+					blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+					assert.NoError(t, err)
+					result = map[string]any{
+						"number":     req.Params[0],
+						"hash":       req.Params[0],
+						"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+					}
+				case "eth_getLogs":
+					filter = req.Params[0].(map[string]any)
+					result = providerLogs
+				case "eth_getBlockReceipts":
+					// The receipts hold every log of the block, Approval on the token included
+					logs := append([]map[string]any{
+						{"address": token, "topics": []string{utils.FunctionSignatureToTopic("Approval(address,address,uint256)")}, "blockNumber": "0x1", "blockHash": "0x1", "logIndex": "0x3"},
+					}, providerLogs...)
+					result = []map[string]any{{"blockHash": "0x1", "blockNumber": "0x1", "logs": logs}}
+				default:
+					http.Error(w, "method no supported", http.StatusBadRequest)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+			}))
+			defer srv.Close()
+
+			processor := NewProcessor()
+			err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+				RangeSize:      10,
+				EndBlock:       1,
+				LogsBufferSize: 10,
+				FetchMode:      mode,
+				Contracts: []ContractFilter{
+					{Address: token, Signatures: []string{"Transfer(address,address,uint256)"}},
+					{Address: pool, Signatures: []string{swap}},
+				},
+			})
+			assert.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			assert.NoError(t, processor.Run(ctx))
+
+			logs := processor.DrainLogs("1")
+			if assert.Len(t, logs, 2) {
+				assert.Equal(t, token, logs[0].Address)
+				assert.Equal(t, transfer, logs[0].Topics[0])
+				assert.Equal(t, swap, logs[1].Topics[0])
+			}
+
+			if mode == FetchModeLogs {
+				// One filter with both addresses and topic0 ORed
+				topics := []any{transfer, swap}
+				sort.Slice(topics, func(i, j int) bool { return topics[i].(string) < topics[j].(string) })
+				assert.Equal(t, []any{token, pool}, filter["address"])
+				assert.Equal(t, []any{topics}, filter["topics"])
+			}
+		})
+	}
+}
+
+func TestContracts_Compile(t *testing.T) {
+	transfer := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+
+	// A contract watching every event drops the topic filter
+	filters, addresses, topics, err := compileContracts([]ContractFilter{
+		{Address: "0xAA", Signatures: []string{"Transfer(address,address,uint256)"}},
+		{Address: "0xbb"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xaa", "0xbb"}, addresses)
+	assert.Empty(t, topics)
+	assert.True(t, filters.matches(contractLog("0xbb", "0x01")))
+	assert.False(t, filters.matches(contractLog("0xaa", "0x01")))
+	assert.True(t, filters.matches(contractLog("0xaa", transfer)))
+
+	// Listed twice, the contract watches the events of both entries
+	filters, _, topics, err = compileContracts([]ContractFilter{
+		{Address: "0xaa", Signatures: []string{"Transfer(address,address,uint256)"}},
+		{Address: "0xAA", Signatures: []string{"0x01"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, topics, 2)
+	assert.True(t, filters.matches(contractLog("0xaa", transfer)))
+
+	_, _, _, err = compileContracts([]ContractFilter{{Signatures: []string{"Transfer(address,address,uint256)"}}})
+	assert.Error(t, err)
+
+	err = NewProcessor().AddChain(ChainInfo{ChainId: "1"}, &Options{
+		RangeSize: 10,
+		Topics:    []string{transfer},
+		Contracts: []ContractFilter{{Address: "0xaa"}},
+	})
+	assert.Error(t, err)
+}
+
+func contractLog(address string, topic0 string) types.Log {
+	return types.Log{Address: address, Topics: []string{topic0}}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// emitLogs commits logs to the subscribers if any, and to the consumer, either one by one on logsCh
+// or as a single batch on batchCh when EmitBatches is enabled, unless SubscribersOnly diverts them to the subscribers.
+// Logs with Removed set are passed through as removals of a previously emitted log.
+// It returns false if ctx was cancelled before all logs were sent.
+func (p *Processor) emitLogs(ctx context.Context, logsCh chan types.Log, batchCh chan []types.Log, chain *chainState, logs []types.Log, target uint64) bool {
+	if len(logs) == 0 {
+		return true
+	}
+
+	if chain.fanout.active() {
+		for _, l := range logs {
+			dropped, ok := chain.fanout.send(ctx, l, chain.opts.SubscriberBackpressure)
+			if !ok {
+				return false
+			}
+			chain.stats.logsDropped.Add(dropped)
+		}
+		// The subscribers replace the channels only when asked to
+		if chain.opts.SubscribersOnly {
+			for _, l := range logs {
+				chain.recordEmitted(l, target)
+			}
+			return true
+		}
+	}
+
+	if chain.opts.EmitBatches {
+		select {
+		case <-ctx.Done():
+			return false
+		case batchCh <- logs:
+			for _, l := range logs {
+				chain.recordEmitted(l, target)
+			}
+			return true
+		}
+	}
+
+	for _, l := range logs {
+		select {
+		case <-ctx.Done():
+			return false
+		case logsCh <- l:
+			chain.recordEmitted(l, target)
+		}
+	}
+	return true
+}
+
+// recordEmitted updates the counters and the tip dedup after a log was sent
+func (c *chainState) recordEmitted(l types.Log, target uint64) {
+	c.stats.logsEmitted.Add(1)
+	if l.Removed {
+		c.stats.logsRemoved.Add(1)
+	}
+	c.markSeen(l, target)
+}
+
+// emitBlocks sends the headers of a committed window on blocksCh in order.
+// It returns false if ctx was cancelled before all blocks were sent.
+func (p *Processor) emitBlocks(ctx context.Context, blocksCh chan types.Block, blocks []types.Block) bool {
+	for _, b := range blocks {
+		select {
+		case <-ctx.Done():
+			return false
+		case blocksCh <- b:
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEmitTestChain(t testing.TB, emitBatches bool) (*Processor, *chainState) {
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)}, &Options{
+		RangeSize:      10,
+		LogsBufferSize: 16,
+		EmitBatches:    emitBatches,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return processor, processor.chains["1"]
+}
+
+func windowOfLogs(n int) []types.Log {
+	logs := make([]types.Log, n)
+	for i := range logs {
+		logs[i] = types.Log{Address: "0xabc", BlockNumber: "0x1"}
+	}
+	return logs
+}
+
+func TestEmitLogs_Batched(t *testing.T) {
+	processor, chain := newEmitTestChain(t, true)
+
+	batchCh, err := processor.LogsBatched("1")
+	assert.NoError(t, err)
+
+	ok := processor.emitLogs(context.Background(), processor.logsCh["1"], processor.logsBatchCh["1"], chain, windowOfLogs(3), 0)
+	assert.True(t, ok)
+
+	assert.Len(t, batchCh, 1)
+	assert.Len(t, <-batchCh, 3)
+	assert.Len(t, processor.logsCh["1"], 0)
+	assert.Equal(t, uint64(3), processor.Stats().Total.LogsEmitted)
+}
+
+func TestEmitLogs_Subscribers(t *testing.T) {
+	processor, chain := newEmitTestChain(t, false)
+	sub, unsubscribe, err := processor.Subscribe("1")
+	assert.NoError(t, err)
+	defer unsubscribe()
+
+	// The subscribers receive the logs alongside the channel
+	ok := processor.emitLogs(context.Background(), processor.logsCh["1"], processor.logsBatchCh["1"], chain, windowOfLogs(3), 0)
+	assert.True(t, ok)
+	assert.Len(t, sub, 3)
+	assert.Len(t, processor.logsCh["1"], 3)
+
+	// SubscribersOnly diverts them to the subscribers
+	chain.opts.SubscribersOnly = true
+	ok = processor.emitLogs(context.Background(), processor.logsCh["1"], processor.logsBatchCh["1"], chain, windowOfLogs(2), 0)
+	assert.True(t, ok)
+	assert.Len(t, sub, 5)
+	assert.Len(t, processor.logsCh["1"], 3)
+	assert.Equal(t, uint64(5), processor.Stats().Total.LogsEmitted)
+}
+
+func TestLogsBatched_NotEnabled(t *testing.T) {
+	processor, _ := newEmitTestChain(t, false)
+
+	_, err := processor.LogsBatched("1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not enabled")
+
+	_, err = processor.LogsBatched("unknown")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func benchmarkEmitLogs(b *testing.B, emitBatches bool) {
+	processor, chain := newEmitTestChain(b, emitBatches)
+	logsCh := processor.logsCh["1"]
+	batchCh := processor.logsBatchCh["1"]
+	window := windowOfLogs(100)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if emitBatches {
+			for range batchCh {
+			}
+			return
+		}
+		for range logsCh {
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processor.emitLogs(context.Background(), logsCh, batchCh, chain, window, 0)
+	}
+	b.StopTimer()
+
+	close(logsCh)
+	if batchCh != nil {
+		close(batchCh)
+	}
+	<-done
+}
+
+// Each iteration emits a window of 100 logs
+func BenchmarkEmitLogs_PerLog(b *testing.B) {
+	benchmarkEmitLogs(b, false)
+}
+
+func BenchmarkEmitLogs_Batched(b *testing.B) {
+	benchmarkEmitLogs(b, true)
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultRecentErrorsSize is the RecentErrorsSize used when it is not set
+const defaultRecentErrorsSize = 32
+
+// ChainError is a failed RPC call of a chain, see RecentErrors
+type ChainError struct {
+	// Time of the failure, from the chain Clock
+	Time time.Time
+	// Method is the RPC method that failed, e.g. eth_getLogs
+	Method string
+	// FromBlock and ToBlock are the blocks requested, both 0 for eth_blockNumber
+	FromBlock uint64
+	ToBlock   uint64
+	// Err is the error returned by the provider
+	Err error
+}
+
+// errorLog is a ring buffer of the last errors of a chain, written by the fetchers and the arbiter
+type errorLog struct {
+	mu      sync.Mutex
+	entries []ChainError
+	// next is the slot of the next entry, the oldest one once the buffer is full
+	next int
+	full bool
+}
+
+func newErrorLog(size int) *errorLog {
+	return &errorLog{entries: make([]ChainError, size)}
+}
+
+// add records e, overwriting the oldest entry once the buffer is full
+func (l *errorLog) add(e ChainError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// list returns a copy of the entries, oldest first
+func (l *errorLog) list() []ChainError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]ChainError(nil), l.entries[:l.next]...)
+	}
+	list := make([]ChainError, 0, len(l.entries))
+	list = append(list, l.entries[l.next:]...)
+	return append(list, l.entries[:l.next]...)
+}
+
+// recordError adds a failed call of method on [from..to] to the recent errors, returning err untouched.
+// Like recordRPC, calls aborted by context cancellation are not recorded.
+func (c *chainState) recordError(method string, from uint64, to uint64, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	c.errorLog.add(ChainError{
+		Time:      c.opts.Clock.Now(),
+		Method:    method,
+		FromBlock: from,
+		ToBlock:   to,
+		Err:       err,
+	})
+	return err
+}
+
+// RecentErrors returns the last RecentErrorsSize failed RPC calls of the chain, oldest first, retries included.
+// Use it to spot patterns such as intermittent rate limiting. It is safe to call while the processor is running.
+// It returns nil for an unknown chain.
+func (p *Processor) RecentErrors(chainId string) []ChainError {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return nil
+	}
+	return chain.errorLog.list()
+}
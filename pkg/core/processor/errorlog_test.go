@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentErrors(t *testing.T) {
+	// The first 2 eth_getLogs fail with 503, the next 2 are rate limited
+	var getLogsCalls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x5"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			switch call := getLogsCalls.Add(1); {
+			case call <= 2:
+				http.Error(w, "provider down", http.StatusServiceUnavailable)
+				return
+			case call <= 4:
+				http.Error(w, "rate limited", http.StatusTooManyRequests)
+				return
+			}
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          5,
+		EndBlock:           5,
+		FetcherConcurrency: 1,
+		RecentErrorsSize:   3,
+		Clock:              rpc.NewFakeClock(time.Unix(0, 0)),
+		RetryConfig:        &rpc.RetryConfig{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 1},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, processor.RecentErrors("1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	// Only the last 3 of the 4 errors are kept, oldest first
+	recent := processor.RecentErrors("1")
+	if !assert.Len(t, recent, 3) {
+		return
+	}
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	for i, e := range recent {
+		assert.Equal(t, "eth_getLogs", e.Method)
+		assert.Equal(t, uint64(1), e.FromBlock)
+		assert.Equal(t, uint64(5), e.ToBlock)
+
+		var httpErr *errors.HTTPError
+		if assert.ErrorAs(t, e.Err, &httpErr) {
+			assert.Equal(t, statuses[i], httpErr.StatusCode)
+		}
+		if i > 0 {
+			assert.True(t, e.Time.After(recent[i-1].Time))
+		}
+	}
+
+	assert.Nil(t, processor.RecentErrors("unknown"))
+}
@@ -0,0 +1,281 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ryuux05/godex/pkg/core/decoder"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// Enricher adds data to a decoded event before it is emitted, e.g. token decimals read with eth_call.
+// client is the RPC of the chain, which implements rpc.CallRPC for the HTTP and IPC clients,
+// and event.BlockNumber gives the block to read the state at. It may update event.Fields in place.
+// ctx is canceled when the indexer stops.
+// When it fails, the event is still emitted with its decoded fields only and the failure is counted in ChainStats.EnrichErrors.
+type Enricher func(ctx context.Context, client rpc.RPC, event *types.Event) error
+
+// EventIndexer indexes several events of a single chain, each decoded with its own ABI.
+// The logs matching any of the event signatures are fetched in one filter,
+// then every log is decoded with the ABI of its topic0 and emitted in chain order on Events.
+type EventIndexer struct {
+	processor *Processor
+	decoder   decoder.Decoder
+	chainId   string
+	// ABI name by topic0, each ABI is registered under its event signature
+	routes map[string]string
+	events chan *types.Event
+	// Events per Store call of RunWithSink
+	batchSize int
+	// client is the RPC passed to enricher
+	client   rpc.RPC
+	enricher Enricher
+	// Number of logs decoded and enriched at the same time
+	concurrency int
+}
+
+// NewEventIndexer returns an indexer of the events of abis on chain.
+// abis maps an event signature (e.g. "Transfer(address,address,uint256)") or its topic hash
+// to the JSON ABI decoding it. opts.Topics is derived from the signatures and must be empty.
+// With opts.Decoder, the logs are decoded by it under their key of abis and the ABIs are ignored.
+func NewEventIndexer(chain ChainInfo, opts *Options, abis map[string]string) (*EventIndexer, error) {
+	if len(abis) == 0 {
+		return nil, fmt.Errorf("no event to index")
+	}
+	if len(opts.Topics) > 0 {
+		return nil, fmt.Errorf("topics are derived from the event signatures, Options.Topics must be empty")
+	}
+	if opts.EmitBatches {
+		return nil, fmt.Errorf("EmitBatches is not supported, events are emitted one by one")
+	}
+
+	var d decoder.Decoder = opts.Decoder
+	standard := decoder.NewStandardDecoder()
+	if d == nil {
+		d = standard
+	}
+	routes := make(map[string]string, len(abis))
+	for signature, abi := range abis {
+		topic := strings.ToLower(utils.ConvertToTopics([]string{signature})[0])
+		if other, exists := routes[topic]; exists {
+			return nil, fmt.Errorf("events %s and %s have the same topic %s", other, signature, topic)
+		}
+		if opts.Decoder != nil {
+			routes[topic] = signature
+			continue
+		}
+		if err := standard.RegisterABI(signature, abi); err != nil {
+			return nil, fmt.Errorf("event %s: %w", signature, err)
+		}
+		if !slices.Contains(standard.Topics(signature), topic) {
+			return nil, fmt.Errorf("event %s: not defined in its ABI", signature)
+		}
+		routes[topic] = signature
+	}
+
+	// Sorted so the logs filter is the same on every run
+	topics := make([]string, 0, len(routes))
+	for topic := range routes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	chainOpts := *opts
+	chainOpts.Topics = topics
+	p := NewProcessor()
+	if err := p.AddChain(chain, &chainOpts); err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.DecoderConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	x := &EventIndexer{
+		processor:   p,
+		decoder:     d,
+		chainId:     chain.ChainId,
+		routes:      routes,
+		events:      make(chan *types.Event, opts.LogsBufferSize),
+		batchSize:   opts.BatchSize,
+		client:      chain.RPC,
+		enricher:    opts.Enricher,
+		concurrency: concurrency,
+	}
+	// The sink gets the events instead of Events
+	if opts.Sink != nil {
+		p.chains[chain.ChainId].sinkEvents = x.sinkEvents
+	}
+	return x, nil
+}
+
+// sinkEvents decodes and enriches the logs of a committed window for Options.Sink, in log order
+func (x *EventIndexer) sinkEvents(ctx context.Context, logs []types.Log) ([]types.Event, error) {
+	events := make([]types.Event, 0, len(logs))
+	for _, l := range logs {
+		event, err := x.decodeAndEnrich(ctx, l)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	return events, nil
+}
+
+// Processor returns the processor running the chain, e.g. to read its Stats or StopReason.
+func (x *EventIndexer) Processor() *Processor {
+	return x.processor
+}
+
+// Events returns the decoded events in chain order. It is closed when Run returns.
+func (x *EventIndexer) Events() <-chan *types.Event {
+	return x.events
+}
+
+// decodeResult is the outcome of decoding and enriching a log
+type decodeResult struct {
+	event *types.Event
+	err   error
+}
+
+// Run indexes the chain until ctx is done, EndBlock is reached or the chain fails.
+// Logs that don't match the layout of their event are skipped like in Decode.
+// Up to DecoderConcurrency logs are decoded and enriched at the same time, the events are still emitted in chain order.
+// With Options.Sink, the events are stored in the sink as their window commits and Events stays empty.
+// Run is meant to be called once since it closes Events.
+func (x *EventIndexer) Run(ctx context.Context) error {
+	logs, err := x.processor.Logs(x.chainId)
+	if err != nil {
+		return err
+	}
+
+	// Results in log order, a log holds a slot from the moment its worker starts until it is emitted.
+	// The emitter waits on one result, so concurrency-1 more can be pending.
+	pending := make(chan chan decodeResult, x.concurrency-1)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return x.processor.Run(gctx)
+	})
+	g.Go(func() error {
+		defer close(pending)
+		for {
+			select {
+			case <-gctx.Done():
+				return nil
+			case l, ok := <-logs:
+				// Closed once EndBlock is committed
+				if !ok {
+					return nil
+				}
+				result := make(chan decodeResult, 1)
+				select {
+				case <-gctx.Done():
+					return nil
+				case pending <- result:
+				}
+				go func() {
+					event, err := x.decodeAndEnrich(gctx, l)
+					result <- decodeResult{event: event, err: err}
+				}()
+			}
+		}
+	})
+	g.Go(func() error {
+		defer close(x.events)
+		for result := range pending {
+			var r decodeResult
+			select {
+			case <-gctx.Done():
+				return nil
+			case r = <-result:
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if r.event == nil {
+				continue
+			}
+			select {
+			case <-gctx.Done():
+				return nil
+			case x.events <- r.event:
+			}
+		}
+		return nil
+	})
+	return g.Wait()
+}
+
+// RunWithSink runs the indexer and stores its events in s by batches of Options.BatchSize.
+// When ctx is done, the events decoded so far are still stored: the partial batch is flushed before returning,
+// so a restart with RestoreFromSink resumes right after it. Like Run, it is meant to be called once.
+func (x *EventIndexer) RunWithSink(ctx context.Context, s sink.Sink) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- x.Run(runCtx)
+	}()
+
+	// Not canceled by ctx, the writer reads until Run closes Events then flushes
+	err := sink.NewBatchWriter(s, x.chainId, x.batchSize).Run(context.WithoutCancel(ctx), x.events)
+	// Stop the indexer if the sink failed
+	cancel()
+	if err := <-runErr; err != nil {
+		return err
+	}
+	return err
+}
+
+// decodeAndEnrich decodes l then runs the enricher on its event, an enricher error doesn't drop the event
+func (x *EventIndexer) decodeAndEnrich(ctx context.Context, l types.Log) (*types.Event, error) {
+	event, err := x.decode(l)
+	if err != nil || event == nil || x.enricher == nil {
+		return event, err
+	}
+
+	// The enricher may have updated some fields before failing
+	fields := maps.Clone(event.Fields)
+	if err := x.enricher(ctx, x.client, event); err != nil {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		log.Printf("Enricher failed on log %d of block %d, emitting the event without enrichment: %v\n", event.LogIndex, event.BlockNumber, err)
+		x.processor.recordEnrichError(x.chainId)
+		event.Fields = fields
+	}
+	return event, nil
+}
+
+// decode decodes l with the ABI of its topic0, nil when it has none or l doesn't match it
+func (x *EventIndexer) decode(l types.Log) (*types.Event, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil
+	}
+	name, exists := x.routes[l.Topics[0]]
+	if !exists {
+		return nil, nil
+	}
+	event, err := x.decoder.Decode(name, l)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding log %s of block %s: %w", l.LogIndex, l.BlockNumber, err)
+	}
+	// A custom decoder may not copy it
+	if event != nil && event.ChainId == "" {
+		event.ChainId = l.ChainId
+	}
+	return event, nil
+}
@@ -0,0 +1,319 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const transferEventABI = `[{"anonymous":false,"type":"event","name":"Transfer","inputs":[
+	{"indexed":true,"name":"from","type":"address"},
+	{"indexed":true,"name":"to","type":"address"},
+	{"indexed":false,"name":"value","type":"uint256"}]}]`
+
+const approvalEventABI = `[{"anonymous":false,"type":"event","name":"Approval","inputs":[
+	{"indexed":true,"name":"owner","type":"address"},
+	{"indexed":true,"name":"spender","type":"address"},
+	{"indexed":false,"name":"value","type":"uint256"}]}]`
+
+// newTokenServer serves a token contract emitting an Approval and a Transfer at block 1 up to head 2.
+// The topics of the last eth_getLogs filter are stored in filterTopics.
+func newTokenServer(t *testing.T, filterTopics *[]any) *httptest.Server {
+	transferTopic := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+	approvalTopic := utils.FunctionSignatureToTopic("Approval(address,address,uint256)")
+	owner := "0x000000000000000000000000" + "1111111111111111111111111111111111111111"
+	spender := "0x000000000000000000000000" + "2222222222222222222222222222222222222222"
+	amount := "0x" + "0000000000000000000000000000000000000000000000000000000000000064"
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x2"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			*filterTopics = req.Params[0].(map[string]any)["topics"].([]any)
+			// One contract emitting both events
+			result = []map[string]any{
+				{"address": "0xabc", "topics": []string{approvalTopic, owner, spender}, "data": amount, "blockNumber": "0x1", "logIndex": "0x0"},
+				{"address": "0xabc", "topics": []string{transferTopic, owner, spender}, "data": amount, "blockNumber": "0x1", "logIndex": "0x1"},
+				// Anonymous event logged by the contract, skipped
+				{"address": "0xabc", "topics": []string{"0x01"}, "data": "0x", "blockNumber": "0x2", "logIndex": "0x0"},
+			}
+		case "eth_call":
+			// decimals() of the token, 6
+			assert.Equal(t, "0x313ce567", req.Params[0].(map[string]any)["data"])
+			result = "0x" + "0000000000000000000000000000000000000000000000000000000000000006"
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestEventIndexer_TransferAndApproval(t *testing.T) {
+	transferTopic := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+	approvalTopic := utils.FunctionSignatureToTopic("Approval(address,address,uint256)")
+
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, LogsBufferSize: 10},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.Run(ctx))
+
+	var eventTypes []string
+	for event := range indexer.Events() {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(t, "0x1111111111111111111111111111111111111111", event.Fields[map[string]string{"Transfer": "from", "Approval": "owner"}[event.EventType]])
+		assert.Equal(t, big.NewInt(100), event.Fields["value"])
+	}
+	assert.Equal(t, []string{"Approval", "Transfer"}, eventTypes)
+
+	// Both events are fetched in one OR-filter on topic0
+	expected := []any{approvalTopic, transferTopic}
+	sort.Slice(expected, func(i, j int) bool { return expected[i].(string) < expected[j].(string) })
+	assert.Equal(t, []any{expected}, filterTopics)
+}
+
+func TestNewEventIndexer_Errors(t *testing.T) {
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)}
+
+	_, err := NewEventIndexer(chain, &Options{}, nil)
+	assert.Error(t, err)
+
+	_, err = NewEventIndexer(chain, &Options{Topics: []string{"Transfer(address,address,uint256)"}},
+		map[string]string{"Transfer(address,address,uint256)": transferEventABI})
+	assert.Error(t, err)
+
+	// The ABI doesn't define the event of its signature
+	_, err = NewEventIndexer(chain, &Options{}, map[string]string{"Transfer(address,address,uint256)": approvalEventABI})
+	assert.ErrorContains(t, err, "not defined in its ABI")
+
+	// Signature and topic hash of the same event
+	_, err = NewEventIndexer(chain, &Options{}, map[string]string{
+		"Transfer(address,address,uint256)":                                 transferEventABI,
+		utils.FunctionSignatureToTopic("Transfer(address,address,uint256)"): transferEventABI,
+	})
+	assert.ErrorContains(t, err, "same topic")
+}
+
+func TestEventIndexer_RunWithSink(t *testing.T) {
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		// The batch is never full, the events are stored by the final flush
+		&Options{RangeSize: 10, EndBlock: 2, BatchSize: 10},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	s := sink.NewMemorySink()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.RunWithSink(ctx, s))
+
+	events := s.Events("1")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "Approval", events[0].EventType)
+		assert.Equal(t, "Transfer", events[1].EventType)
+	}
+}
+
+func TestEventIndexer_Enricher(t *testing.T) {
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	var blocks []string
+	// Reads the decimals of the token at the block of the event and scales the value with them
+	enricher := func(ctx context.Context, client rpc.RPC, event *types.Event) error {
+		block := utils.Uint64ToHexQty(event.BlockNumber)
+		blocks = append(blocks, block)
+		result, err := client.(rpc.CallRPC).Call(ctx, types.CallMsg{To: event.Address, Data: "0x313ce567"}, block)
+		if err != nil {
+			return err
+		}
+		decimals, ok := new(big.Int).SetString(result[2:], 16)
+		if !ok {
+			return fmt.Errorf("invalid decimals %s", result)
+		}
+		event.Fields["decimals"] = decimals.Uint64()
+		event.Fields["amount"] = new(big.Rat).SetFrac(event.Fields["value"].(*big.Int), new(big.Int).Exp(big.NewInt(10), decimals, nil)).FloatString(4)
+		return nil
+	}
+
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, LogsBufferSize: 10, DecoderConcurrency: 1, Enricher: enricher},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.Run(ctx))
+
+	var eventTypes []string
+	for event := range indexer.Events() {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(t, uint64(6), event.Fields["decimals"])
+		assert.Equal(t, "0.0001", event.Fields["amount"])
+		assert.Equal(t, big.NewInt(100), event.Fields["value"])
+	}
+	assert.Equal(t, []string{"Approval", "Transfer"}, eventTypes)
+	assert.Equal(t, []string{"0x1", "0x1"}, blocks)
+	assert.Equal(t, uint64(0), indexer.Processor().Stats().Total.EnrichErrors)
+}
+
+func TestEventIndexer_EnricherError(t *testing.T) {
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	// Fails after updating a field, the update must not leak into the emitted event
+	enricher := func(ctx context.Context, client rpc.RPC, event *types.Event) error {
+		event.Fields["decimals"] = uint64(6)
+		return errors.New("execution reverted")
+	}
+
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, LogsBufferSize: 10, DecoderConcurrency: 4, Enricher: enricher},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.Run(ctx))
+
+	var eventTypes []string
+	for event := range indexer.Events() {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.NotContains(t, event.Fields, "decimals")
+		assert.Equal(t, big.NewInt(100), event.Fields["value"])
+	}
+	// Emitted unenriched, still in chain order
+	assert.Equal(t, []string{"Approval", "Transfer"}, eventTypes)
+	assert.Equal(t, uint64(2), indexer.Processor().Stats().Chains["1"].EnrichErrors)
+}
+
+// topicCounter is a custom decoder reading only the number of topics of a log
+type topicCounter struct {
+	// failOn is the name of the event failing to decode, if any
+	failOn string
+}
+
+func (d topicCounter) Decode(name string, log types.Log) (*types.Event, error) {
+	if name == d.failOn {
+		return nil, fmt.Errorf("cannot decode %s", name)
+	}
+	return &types.Event{EventType: name, Address: log.Address, Fields: types.EventFields{"topics": len(log.Topics)}}, nil
+}
+
+func (d topicCounter) DecodeBatch(name string, logs []types.Log) ([]*types.Event, error) {
+	events := make([]*types.Event, 0, len(logs))
+	for _, log := range logs {
+		event, err := d.Decode(name, log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func TestEventIndexer_CustomDecoder(t *testing.T) {
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	// The ABIs are not needed, only the event names and topics
+	abis := map[string]string{
+		"Transfer(address,address,uint256)": "",
+		"Approval(address,address,uint256)": "",
+	}
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, LogsBufferSize: 10, DecoderConcurrency: 2, Decoder: topicCounter{}},
+		abis,
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.Run(ctx))
+
+	var eventTypes []string
+	for event := range indexer.Events() {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(t, 3, event.Fields["topics"])
+	}
+	assert.Equal(t, []string{"Approval(address,address,uint256)", "Transfer(address,address,uint256)"}, eventTypes)
+	assert.Len(t, filterTopics[0], 2)
+
+	// A decoding error stops the indexer
+	indexer, err = NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, LogsBufferSize: 10, Decoder: topicCounter{failOn: "Transfer(address,address,uint256)"}},
+		abis,
+	)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, indexer.Run(ctx), "cannot decode Transfer")
+}
@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+type Backpressure string
+
+const (
+	BackpressureBlock Backpressure = "block" // A slow subscriber slows the chain down, no log is lost
+	BackpressureDrop  Backpressure = "drop"  // A slow subscriber misses the logs sent while its buffer is full
+)
+
+// subscriber is a channel of the fan-out, done is closed on unsubscribe to release a blocked send
+type subscriber struct {
+	ch   chan types.Log
+	done chan struct{}
+	once sync.Once
+}
+
+// fanout broadcasts the logs of a chain to its subscribers
+type fanout struct {
+	// mu is held for a whole send, so a subscriber channel is never closed while being sent to
+	mu         sync.Mutex
+	subs       map[*subscriber]struct{}
+	bufferSize uint64
+	closed     bool
+}
+
+func newFanout(bufferSize uint64) *fanout {
+	return &fanout{
+		subs:       make(map[*subscriber]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// subscribe adds a subscriber, its channel is closed right away if the chain is completed
+func (f *fanout) subscribe() (<-chan types.Log, func()) {
+	s := &subscriber{
+		ch:   make(chan types.Log, f.bufferSize),
+		done: make(chan struct{}),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	f.subs[s] = struct{}{}
+
+	unsubscribe := func() {
+		s.once.Do(func() {
+			close(s.done)
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if _, ok := f.subs[s]; ok {
+				delete(f.subs, s)
+				close(s.ch)
+			}
+		})
+	}
+	return s.ch, unsubscribe
+}
+
+// active reports whether the chain has subscribers
+func (f *fanout) active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs) > 0
+}
+
+// send sends l to every subscriber and returns the number of subscribers that missed it,
+// or false if ctx was cancelled before it was sent
+func (f *fanout) send(ctx context.Context, l types.Log, policy Backpressure) (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var dropped uint64
+	for s := range f.subs {
+		if policy == BackpressureDrop {
+			select {
+			case s.ch <- l:
+			default:
+				dropped++
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return dropped, false
+		case <-s.done:
+		case s.ch <- l:
+		}
+	}
+	return dropped, true
+}
+
+// close closes the channels of the subscribers once the chain is completed
+func (f *fanout) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for s := range f.subs {
+		delete(f.subs, s)
+		close(s.ch)
+	}
+}
+
+// Subscribe returns a channel receiving every log of the chain, independent of the other subscribers.
+// The logs are still delivered to Logs or LogsBatched as well, which must be read,
+// unless Options.SubscribersOnly delivers them only to the subscribers while there are any.
+// A slow subscriber is handled according to Options.SubscriberBackpressure.
+// The channel is closed once the chain reaches EndBlock, when the chain is removed or when the returned func unsubscribes.
+func (p *Processor) Subscribe(chainId string) (<-chan types.Log, func(), error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return nil, nil, fmt.Errorf("chain %s not found", chainId)
+	}
+	ch, unsubscribe := chain.fanout.subscribe()
+	return ch, unsubscribe, nil
+}
@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe_TwoSubscribersReceiveEveryLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0xa"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			// One log per block of the window
+			filter := req.Params[0].(map[string]any)
+			from, _ := utils.HexQtyToUint64(filter["fromBlock"].(string))
+			to, _ := utils.HexQtyToUint64(filter["toBlock"].(string))
+			var logs []map[string]any
+			for b := from; b <= to; b++ {
+				logs = append(logs, map[string]any{"address": "0xabc", "blockNumber": utils.Uint64ToHexQty(b), "logIndex": "0x0"})
+			}
+			result = logs
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{RangeSize: 3, EndBlock: 10, LogsBufferSize: 16})
+	assert.NoError(t, err)
+
+	// A sink and a dashboard, each reading at its own pace
+	received := make([][]string, 2)
+	var wg sync.WaitGroup
+	for i := range received {
+		ch, unsubscribe, err := processor.Subscribe("1")
+		assert.NoError(t, err)
+		defer unsubscribe()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for l := range ch {
+				if i == 1 {
+					time.Sleep(time.Millisecond)
+				}
+				received[i] = append(received[i], l.BlockNumber)
+			}
+		}(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+	// Closed once EndBlock is committed
+	wg.Wait()
+
+	var expected []string
+	for b := uint64(1); b <= 10; b++ {
+		expected = append(expected, utils.Uint64ToHexQty(b))
+	}
+	assert.Equal(t, expected, received[0])
+	assert.Equal(t, expected, received[1])
+
+	// The shared channel received every log too
+	assert.Len(t, processor.DrainLogs("1"), 10)
+
+	_, _, err = processor.Subscribe("unknown")
+	assert.Error(t, err)
+}
+
+func TestFanout_Backpressure(t *testing.T) {
+	ctx := context.Background()
+	l := types.Log{BlockNumber: "0x1"}
+
+	// A full subscriber misses the log with BackpressureDrop
+	f := newFanout(1)
+	fast, _ := f.subscribe()
+	slow, _ := f.subscribe()
+	dropped, ok := f.send(ctx, l, BackpressureDrop)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), dropped)
+	<-fast
+	dropped, ok = f.send(ctx, l, BackpressureDrop)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), dropped)
+	assert.Len(t, slow, 1)
+
+	// With BackpressureBlock, unsubscribing releases a send blocked on a full subscriber
+	f = newFanout(0)
+	_, unsubscribe := f.subscribe()
+	sent := make(chan bool)
+	go func() {
+		_, ok := f.send(ctx, l, BackpressureBlock)
+		sent <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	unsubscribe()
+	assert.True(t, <-sent)
+	assert.False(t, f.active())
+
+	// And so does the cancellation
+	f = newFanout(0)
+	f.subscribe()
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, ok = f.send(cancelCtx, l, BackpressureBlock)
+	assert.False(t, ok)
+
+	// Subscribing to a completed chain returns a closed channel
+	f.close()
+	ch, _ := f.subscribe()
+	_, open := <-ch
+	assert.False(t, open)
+}
@@ -0,0 +1,41 @@
+package processor
+
+// chainFinalityProfiles maps common chainIds to a recommended confirmation depth.
+// The values trade a little latency for practical finality on each chain:
+// - Ethereum and most PoS L1s settle reorgs within a few epochs of blocks.
+// - Polygon PoS can reorg deeply, so a larger depth is needed.
+// - Rollups have soft finality from the sequencer, so 0 is enough.
+// It is read by the running chains and never written.
+var chainFinalityProfiles = map[string]uint64{
+	"1":     12,  // Ethereum
+	"10":    0,   // Optimism
+	"56":    15,  // BNB Smart Chain
+	"137":   128, // Polygon PoS
+	"250":   5,   // Fantom
+	"592":   16,  // Astar
+	"8453":  0,   // Base
+	"42161": 0,   // Arbitrum One
+	"43114": 1,   // Avalanche C-Chain
+}
+
+// ChainFinalityProfiles returns a copy of the recommended confirmation depth by chainId
+// used by UseRecommendedConfirmations. Changing it doesn't affect the processor.
+func ChainFinalityProfiles() map[string]uint64 {
+	profiles := make(map[string]uint64, len(chainFinalityProfiles))
+	for chainId, conf := range chainFinalityProfiles {
+		profiles[chainId] = conf
+	}
+	return profiles
+}
+
+// resolveConfirmations returns the confirmation depth to use for a chain.
+// When UseRecommendedConfirmations is set, the chain profile wins,
+// otherwise (or when no profile exists) the explicit Confimation is used.
+func resolveConfirmations(chainId string, opts *Options) uint64 {
+	if opts.UseRecommendedConfirmations {
+		if conf, ok := chainFinalityProfiles[chainId]; ok {
+			return conf
+		}
+	}
+	return opts.Confimation
+}
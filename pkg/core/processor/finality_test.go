@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveConfirmations_KnownChain(t *testing.T) {
+	opts := &Options{
+		Confimation:                 5,
+		UseRecommendedConfirmations: true,
+	}
+
+	assert.Equal(t, uint64(128), resolveConfirmations("137", opts))
+	assert.Equal(t, uint64(0), resolveConfirmations("42161", opts))
+}
+
+func TestResolveConfirmations_UnknownChain(t *testing.T) {
+	opts := &Options{
+		Confimation:                 5,
+		UseRecommendedConfirmations: true,
+	}
+
+	// No profile for this chain, fallback to the explicit value
+	assert.Equal(t, uint64(5), resolveConfirmations("999999", opts))
+}
+
+func TestResolveConfirmations_Disabled(t *testing.T) {
+	opts := &Options{
+		Confimation: 5,
+	}
+
+	assert.Equal(t, uint64(5), resolveConfirmations("137", opts))
+}
+
+func TestChainFinalityProfiles_Copy(t *testing.T) {
+	profiles := ChainFinalityProfiles()
+	assert.Equal(t, uint64(12), profiles["1"])
+
+	// The copy doesn't change the depth used by the chains
+	profiles["1"] = 0
+	assert.Equal(t, uint64(12), resolveConfirmations("1", &Options{UseRecommendedConfirmations: true}))
+	assert.Equal(t, uint64(12), ChainFinalityProfiles()["1"])
+}
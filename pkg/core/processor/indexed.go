@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxIndexedTopics is the number of topic positions after the event signature
+const maxIndexedTopics = 3
+
+// compileIndexedTopics validates Options.IndexedTopics and lowercases its values
+func compileIndexedTopics(positions [][]string) ([][]string, error) {
+	if len(positions) > maxIndexedTopics {
+		return nil, fmt.Errorf("IndexedTopics has %d positions, a log has at most %d indexed topics", len(positions), maxIndexedTopics)
+	}
+	if len(positions) == 0 {
+		return nil, nil
+	}
+
+	compiled := make([][]string, len(positions))
+	for i, values := range positions {
+		compiled[i] = make([]string, len(values))
+		for j, value := range values {
+			if !isTopicHex(value) {
+				return nil, fmt.Errorf("IndexedTopics[%d] value %q is not a 32 bytes hex topic", i, value)
+			}
+			compiled[i][j] = strings.ToLower(value)
+		}
+	}
+	return compiled, nil
+}
+
+// isTopicHex reports whether value is a 0x prefixed 32 bytes hex string
+func isTopicHex(value string) bool {
+	if len(value) != 66 || !strings.HasPrefix(value, "0x") {
+		return false
+	}
+	for _, c := range value[2:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesIndexedTopics checks the topics after the event signature against the IndexedTopics positions,
+// the same way eth_getLogs does so both fetch modes keep the same logs
+func (c *chainState) matchesIndexedTopics(topics []string) bool {
+	for i, values := range c.indexedTopics {
+		if len(values) == 0 {
+			continue
+		}
+		if len(topics) <= i+1 {
+			return false
+		}
+		matched := false
+		for _, value := range values {
+			if strings.EqualFold(topics[i+1], value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
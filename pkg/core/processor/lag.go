@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"log"
+)
+
+// lag returns how many blocks the last committed block is behind the last fetched head
+func (c *chainState) lag() uint64 {
+	head, watermark := c.head.Load(), c.watermark.Load()
+	if head <= watermark {
+		return 0
+	}
+	return head - watermark
+}
+
+// checkLag raises the lag alert of the chain when its lag exceeds LagAlertThreshold and clears it
+// once the lag is back under the threshold. Each crossing is reported once, not on every poll.
+func (c *chainState) checkLag() {
+	threshold := c.opts.LagAlertThreshold
+	if threshold == 0 {
+		return
+	}
+
+	lag := c.lag()
+	lagging := lag > threshold
+	// Only the caller flipping the state reports it
+	if !c.lagging.CompareAndSwap(!lagging, lagging) {
+		return
+	}
+
+	if lagging {
+		c.stats.lagAlerts.Add(1)
+		log.Printf("Chain %s is lagging %d blocks behind head, above the threshold of %d\n", c.chainInfo.ChainId, lag, threshold)
+	} else {
+		log.Printf("Chain %s lag is back to %d blocks, within the threshold of %d\n", c.chainInfo.ChainId, lag, threshold)
+	}
+	if c.opts.OnLagAlert != nil {
+		c.opts.OnLagAlert(c.chainInfo.ChainId, lag, lagging)
+	}
+}
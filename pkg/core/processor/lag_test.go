@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLagAlert_FiresOnceAndClears(t *testing.T) {
+	var head atomic.Uint64
+	head.Store(5)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = utils.Uint64ToHexQty(head.Load())
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	type alert struct {
+		lag     uint64
+		lagging bool
+	}
+	var mu sync.Mutex
+	var alerts []alert
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:         1000,
+		PollInterval:      5 * time.Millisecond,
+		LagAlertThreshold: 10,
+		OnLagAlert: func(chainId string, lag uint64, lagging bool) {
+			assert.Equal(t, "1", chainId)
+			mu.Lock()
+			alerts = append(alerts, alert{lag, lagging})
+			mu.Unlock()
+		},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- processor.Run(ctx) }()
+
+	// waitWatermark waits for the chain to commit block then lets it poll a few more times
+	waitWatermark := func(block uint64) {
+		assert.Eventually(t, func() bool {
+			watermark, err := processor.Watermark("1")
+			return err == nil && watermark == block
+		}, 5*time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// A lag of 5 is within the threshold
+	waitWatermark(5)
+	mu.Lock()
+	assert.Empty(t, alerts)
+	mu.Unlock()
+
+	// The head jumps 95 blocks ahead of the cursor, then the chain catches up
+	head.Store(100)
+	waitWatermark(100)
+
+	cancel()
+	assert.NoError(t, <-runErr)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []alert{{95, true}, {0, false}}, alerts)
+	assert.Equal(t, uint64(1), processor.Stats().Chains["1"].LagAlerts)
+
+	health, err := processor.Health("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), health.Head)
+	assert.Equal(t, uint64(0), health.Lag)
+	assert.False(t, health.Lagging)
+}
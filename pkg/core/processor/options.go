@@ -1,49 +1,249 @@
 package processor
 
-import "github.com/ryuux05/godex/pkg/core/rpc"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/decoder"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
 
 type FetchMode string
 
 const (
 	FetchModeLogs     FetchMode = "logs"     // Use eth_getlogs for efficiency
 	FetchModeReceipts FetchMode = "receipts" // Use eth_getBlockReceipts for reliability
+	// Use eth_getTransactionReceipt for every transaction, for providers without eth_getBlockReceipts.
+	// It is the heaviest mode, one call per transaction, and requires an RPC implementing rpc.TxReceiptsRPC.
+	FetchModeTxReceipts FetchMode = "txreceipts"
+)
+
+type StartFrom string
+
+const (
+	StartFromGenesis StartFrom = "genesis" // Index the whole chain history
+	StartFromHead    StartFrom = "head"    // Skip history and only follow blocks after the head at start
+	StartFromBlock   StartFrom = "block"   // Start from Options.StartBlock
 )
 
+// defaultPollInterval is the PollInterval used when it is not set
+const defaultPollInterval = time.Second
+
+// defaultOutageProbeInterval is the OutageProbeInterval used when it is not set
+const defaultOutageProbeInterval = 30 * time.Second
+
 type Options struct {
 	// BatchSize controls how many decoded events are buffered and written to sinks at once.
 	BatchSize int
 	// RangeSize is the number of blocks requested per eth_getLogs window.
 	// Larger ranges reduce round-trips but may exceed provider limits; tune per provider.
 	RangeSize int
+	// AlignRangesTo cuts the windows at the multiples of this value, for providers requiring aligned ranges
+	// or rejecting ranges spanning a boundary such as a hard fork height: a window never contains both
+	// block k*AlignRangesTo-1 and k*AlignRangesTo. Windows may be shorter than RangeSize. 0 disables it.
+	AlignRangesTo uint64
+	// SortWindowLogs sorts the logs of every window by block number, transaction index and log index
+	// before they are emitted, for providers or proxies not returning eth_getLogs in chain order.
+	// Defaults to false, trusting the provider order.
+	SortWindowLogs bool
+	// AllowOutOfOrderCommit emits the logs of a window as soon as it is fetched instead of waiting for the earlier windows.
+	// It speeds up consumers that don't need ordering, e.g. an idempotent store keyed by log identity.
+	// The cursor, OnCommit and Blocks still advance in order, but reorg detection is coarser:
+	// logs of a window may be emitted before the reorg check of the windows before it,
+	// and they are emitted again when the windows are fetched again after a reorg.
+	AllowOutOfOrderCommit bool
+	// WindowRetries is the number of times a failed window is fetched again on its own,
+	// once RetryConfig is exhausted, before the failure stops the chain.
+	// The other windows in flight keep going meanwhile. 0 disables it.
+	WindowRetries int
+	// OutageThreshold is the number of consecutive failures, of a window or of the head fetch once RetryConfig
+	// and WindowRetries are exhausted, after which the provider is considered down.
+	// The chain then pauses in a cooldown, probing the head every OutageProbeInterval until the provider answers,
+	// and resumes from its cursor. Failures below the threshold fetch the windows again.
+	// Only outages count, e.g. 5xx, 429 or network errors, other errors still stop the chain. 0 disables it.
+	OutageThreshold int
+	// OutageProbeInterval is the wait between two head probes during a cooldown, defaults to 30s.
+	OutageProbeInterval time.Duration
+	// LagAlertThreshold raises a lag alert when the chain falls more than this many blocks behind the head,
+	// and clears it once the lag is back under it. Each crossing is logged, counted in ChainStats.LagAlerts
+	// and passed to OnLagAlert once, not on every poll. 0 disables it.
+	LagAlertThreshold uint64
+	// OnLagAlert is called when the lag alert is raised, lagging true, or cleared, lagging false.
+	// It runs on the chain goroutines so it must not block.
+	OnLagAlert func(chainId string, lag uint64, lagging bool)
+	// MaxBufferedWindows caps the windows that are fetched or waiting to commit.
+	// Fetchers finishing ahead of the commit cursor buffer their logs until the earlier windows commit,
+	// the planner stops issuing windows at this bound to cap memory on high log volume chains.
+	// 0 means unbounded.
+	MaxBufferedWindows int
 	// DecoderConcurrency spawns number of goroutine for decoder
 	// Set to 1 for strictly serial processing.
+	// In an EventIndexer, it bounds the logs decoded and enriched at the same time, defaults to 1.
 	DecoderConcurrency int
+	// Decoder decodes the logs of an EventIndexer instead of a StandardDecoder built from its ABIs,
+	// e.g. for a contract with a bespoke non-ABI encoding. Every log is passed to Decode with the name
+	// of its event in the ABIs map, a nil event skips the log and an error stops the indexer.
+	Decoder decoder.Decoder
+	// Enricher is run by an EventIndexer on every decoded event before it is emitted, nil disables it.
+	// See Enricher for the error handling.
+	Enricher Enricher
 	// FetcherConcurrency spwawns number of goroutine for fetcher.
 	// Set 1 for strictly serial fetching.
 	FetcherConcurrency int
+	// ConcurrencyScaling adjusts the number of fetchers at runtime from the observed RPC latency and error rate,
+	// starting from FetcherConcurrency. Nil keeps FetcherConcurrency fixed.
+	ConcurrencyScaling *ConcurrencyScaling
+	// Logger receives the per-window timings at debug level: block range, logs count, fetch and commit durations.
+	// Enable debug on its handler to tune RangeSize and FetcherConcurrency. Nil discards them.
+	Logger *slog.Logger
+	// VerifyChainID makes AddChain ask the node its chain id with eth_chainId and fail if it isn't ChainInfo.ChainId,
+	// to catch an endpoint of another chain. The RPC must implement rpc.ChainIDRPC.
+	VerifyChainID bool
+	// MaxInitialGap makes the first Run fetch the head and stop the chain if it would start more than MaxInitialGap blocks below it,
+	// e.g. a StartBlock of 0 on mainnet by mistake. The start is the cursor after SetCursor, ImportState or RestoreFromSink.
+	// 0 disables the check.
+	MaxInitialGap uint64
+	// AllowLargeBackfill acknowledges a backfill larger than MaxInitialGap, Run doesn't check the gap then
+	AllowLargeBackfill bool
+	// FailFast cancels the other chains of the processor when this chain fails, their stop reason is then ErrChainCanceled.
+	// By default chains are isolated: a failing chain stops alone and the others keep indexing until Run's context is done.
+	FailFast bool
+	// StartFrom selects where indexing begins.
+	// When empty, StartFromBlock is used if StartBlock is set, StartFromGenesis otherwise.
+	StartFrom StartFrom
+	// IndexGenesis also indexes block 0 with StartFromGenesis, for chains with meaningful genesis logs.
+	// By default indexing starts at block 1 since the cursor is the last processed block and starts at 0.
+	IndexGenesis bool
 	// StartBlock is the inclusive block height to begin indexing from.
-	// Use 0 to let the processor derive it (e.g., from a stored cursor).
+	// Only used with StartFromBlock.
 	StartBlock uint64
 	// EndBlock is an optional inclusive block height to stop indexing at.
+	// Once it is committed the chain stops and its output channels are closed.
 	// Use 0 to run continuously toward the moving head.
 	EndBlock uint64
 	// Confimation is range of block to wait.
 	// Confirmation is used to avoid most reorgs.
 	// Eth PoS confirmation is around 5-15 for "safe"
 	Confimation uint64
+	// UseRecommendedConfirmations looks up the confirmation depth in ChainFinalityProfiles() by ChainId.
+	// Falls back to Confimation when the chain has no profile.
+	UseRecommendedConfirmations bool
 	//How many Log items can be buffered in the processor’s logs channel.
 	// 0 makes it unbuffered.
 	// use a sane default (e.g., 1024).
 	LogsBufferSize uint64
+	// SubscriberBackpressure is how a subscriber whose buffer of LogsBufferSize logs is full is handled, see Subscribe.
+	// BackpressureBlock (default) waits for it, slowing the chain down, BackpressureDrop skips it
+	// and counts the missed logs in ChainStats.LogsDropped.
+	SubscriberBackpressure Backpressure
+	// SubscribersOnly delivers the logs only to the subscribers while the chain has any, see Subscribe.
+	// Logs and LogsBatched receive nothing meanwhile. Defaults to false, every log also goes to them.
+	SubscribersOnly bool
+	// TipOverlapBlocks re-scans the last N blocks on each poll once the chain is caught up to head.
+	// Logs that the provider indexed late are emitted, the ones already emitted are skipped.
+	// 0 disables the re-scan.
+	TipOverlapBlocks uint64
+	// Sink stores the logs of every committed window directly, for ETL pipelines without a consumer of the channels.
+	// The logs are stored as raw events, with RawLog set and no Fields, or decoded by an EventIndexer.
+	// They are not sent on Logs or to the subscribers, so an undrained channel can't stall the chain,
+	// and the logs channel has no buffer. A store error stops the chain before its cursor passes the window,
+	// RestoreFromSink then resumes after the stored blocks. It can't be combined with EmitBatches,
+	// and AllowOutOfOrderCommit has no effect since the logs are stored when their window commits.
+	Sink sink.Sink
+	// EmitBatches sends the logs of each committed window as one []types.Log on LogsBatched
+	// instead of one by one on Logs. Useful for consumers doing bulk inserts.
+	EmitBatches bool
+	// EmitBlocks sends every committed block header on Blocks, including the blocks without logs.
+	// It costs one GetBlock call per block, shared with the reorg checks through the block cache.
+	EmitBlocks bool
 	// ReorgLookbackBlocks is the maximum number of blocks to walk back when detecting a reorg. Used to bound header lookups and the size of stored window hashes.
 	// Default: 64 (good starting point)
 	ReorgLookbackBlocks uint64
+	// WindowHashStride also stores the hashes of the blocks inside the windows at the multiples of this value,
+	// so a reorg is resolved to the closest stride instead of the closest window end. It decouples the
+	// reorg resolution from RangeSize at the cost of one GetBlock per stride block and more stored hashes,
+	// still bounded by ReorgLookbackBlocks. 0 only stores the window ends.
+	WindowHashStride uint64
+	// MaxReorgDepth halts the chain with a ReorgError when the common ancestor of a reorg isn't found
+	// within this many blocks below the cursor, instead of rewinding blindly, so an operator can investigate.
+	// 0 keeps the hard fallback, rewinding 1000 blocks below the cursor.
+	MaxReorgDepth uint64
+	// PollInterval is the wait before polling the head again once the chain is caught up, defaults to 1s.
+	PollInterval time.Duration
+	// ReorgCheckInterval re-verifies the hash of the cursor block at this interval while windows are being fetched,
+	// so a reorg is caught before the next window commits. Useful with a large RangeSize.
+	// 0 disables the check, reorgs are then only detected when a window commits.
+	ReorgCheckInterval time.Duration
+	// AuditSampleRate is the fraction of committed blocks, between 0 and 1, fetched again with the other fetch mode
+	// to compare their logs count. It catches providers silently dropping logs without the cost of fetching everything twice.
+	// Discrepancies are counted in ChainStats.AuditDiscrepancies and reported to OnAuditDiscrepancy. 0 disables the audit.
+	AuditSampleRate float64
+	// OnAuditDiscrepancy is called for every audited block whose logs count differs between the fetch modes, optional.
+	// Audits run in the background after the commit, so it may be called concurrently.
+	OnAuditDiscrepancy func(AuditDiscrepancy)
+	// OnCommit is called when a window commits, before its logs are emitted and the cursor advances to toBlock.
+	// With AllowOutOfOrderCommit the logs are emitted as soon as the window is fetched, possibly before OnCommit,
+	// only the cursor is then guaranteed to wait for it.
+	// Use it to persist the cursor and the logs together in your own store.
+	// It is also called for windows without logs, so progress is visible on quiet chains.
+	// Returning an error aborts the commit, the callback is then called again up to WindowRetries times,
+	// waiting the backoff of RetryConfig, before the error stops the chain. Failures are kept in RecentErrors.
+	OnCommit func(chainId string, toBlock uint64, logs []types.Log) error
+	// BlockCacheSize is the number of recently fetched blocks kept in memory.
+	// The reorg check, the reorg walk back and the window hash storage share the cached blocks.
+	// Cached blocks are dropped when a reorg is detected. 0 disables the cache.
+	BlockCacheSize int
+	// RecentErrorsSize is the number of failed RPC calls kept per chain for RecentErrors, defaults to 32.
+	RecentErrorsSize int
 	// Topics is the event for indexer to listen and get the log
 	Topics []string
+	// IndexedTopics filters the logs on their indexed arguments, IndexedTopics[i] lists the accepted values of topic i+1,
+	// e.g. utils.AddressToTopic of the zero address at IndexedTopics[0] keeps the Transfer logs of mints.
+	// An empty position matches any value. It applies to the logs of every fetch mode.
+	IndexedTopics [][]string
+	// Addresses restricts the logs to the ones emitted by these contracts.
+	// Matching is case-insensitive. Leave empty to accept logs from any address.
+	Addresses []string
+	// Contracts watches each contract for its own events, e.g. Transfer on a token and Swap on a pool.
+	// It replaces Topics and Addresses, which must be empty when it is set.
+	Contracts []ContractFilter
+	// MaxAddressesPerFilter is the provider limit of addresses in a single eth_getLogs filter.
+	// Larger Addresses are split in several calls whose logs are merged. 0 means no limit.
+	MaxAddressesPerFilter int
 	// FetchMode determines which RPC method to use for fetching logs
 	// - "logs": Uses eth_getLogs (default, more efficient)
 	// - "receipts": Uses eth_getBlockReceipts (more reliable, higher bandwidth)
+	// - "txreceipts": Uses eth_getTransactionReceipt per transaction (fallback, heaviest)
 	FetchMode FetchMode
+	// TxReceiptsConcurrency bounds the eth_getTransactionReceipt calls in flight per block
+	// in FetchModeTxReceipts, defaults to 8.
+	TxReceiptsConcurrency int
+	// StreamReceipts parses the eth_getBlockReceipts responses of FetchModeReceipts one receipt at a time
+	// and drops the logs not matching Topics, Addresses or Contracts while parsing, instead of reading
+	// the whole response then every log of the block. It lowers the peak memory on busy blocks.
+	// The RPC must implement rpc.FilteredReceiptsRPC, as the HTTP client does.
+	StreamReceipts bool
+	// ValidateReceipts checks in the receipts modes that receipts and their logs belong to the requested block,
+	// and that the logs of a window carry the hash of the header of their block.
+	// A mismatching window is fetched again with fresh headers up to WindowRetries times,
+	// then the chain stops with an errors.ReceiptMismatchError.
+	ValidateReceipts bool
+	// BloomPrecheck skips in the receipts modes the receipts whose logs bloom proves
+	// that none of their logs match the configured topics and addresses.
+	// It saves the per-log matching on busy chains with sparse filters.
+	BloomPrecheck bool
+	// TagTxType sets Log.TxType to the type of the originating transaction.
+	// Only supported in the receipts modes since eth_getLogs doesn't return the transaction type.
+	TagTxType bool
+	// TagChainId sets Log.ChainId, and Event.ChainId of the EventIndexer, to the id of the chain,
+	// so a single consumer merging the logs of several chains can route them.
+	TagChainId bool
+	// Clock times the waits of the chain: the retry backoffs, the ReorgCheckInterval and the outage cooldown
+	// probing every OutageProbeInterval. When nil the real clock is used.
+	// It is also used by RetryConfig when its own Clock is not set. Tests can inject an rpc.FakeClock.
+	Clock rpc.Clock
 	// RetryConfig manage how to handle retry on retriable errors.
 	// Use pointer since it nillable
 	// There is default settings
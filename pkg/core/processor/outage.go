@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+)
+
+// ChainHealth is a snapshot of the liveness of a chain
+type ChainHealth struct {
+	// Cooldown is true while the provider is considered down and only its head is probed, see Options.OutageThreshold
+	Cooldown bool
+	// ConsecutiveFailures is the number of outage failures since the last window committed or the chain caught up
+	ConsecutiveFailures uint64
+	// Watermark is the last committed block
+	Watermark uint64
+	// Head is the last fetched head, 0 before the first fetch
+	Head uint64
+	// Lag is the number of blocks between Watermark and Head
+	Lag uint64
+	// Lagging is true while the lag alert is raised, see Options.LagAlertThreshold
+	Lagging bool
+	// CaughtUp is true once the chain finished its backfill and follows the tip, see Processor.CaughtUp
+	CaughtUp bool
+	// FetcherConcurrency is the number of fetchers allowed to fetch at the same time,
+	// adjusted at runtime with Options.ConcurrencyScaling
+	FetcherConcurrency int
+}
+
+// Health returns the liveness of the chain. It is safe to call while the processor is running.
+func (p *Processor) Health(chainId string) (ChainHealth, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return ChainHealth{}, fmt.Errorf("chain %s not found", chainId)
+	}
+	return ChainHealth{
+		Cooldown:            chain.cooldown.Load(),
+		ConsecutiveFailures: chain.consecutiveFailures.Load(),
+		Watermark:           chain.watermark.Load(),
+		Head:                chain.head.Load(),
+		Lag:                 chain.lag(),
+		Lagging:             chain.lagging.Load(),
+		CaughtUp:            chain.isCaughtUp(),
+		FetcherConcurrency:  chain.fetcherConcurrency(),
+	}, nil
+}
+
+// recoverFromFailure counts a failure of the chain and tells whether it can go on.
+// Once OutageThreshold consecutive outage failures are reached, it waits in a cooldown,
+// probing the head every OutageProbeInterval, until the provider answers again.
+// It returns nil when the chain should fetch again from its cursor, the error stopping the chain otherwise.
+func (p *Processor) recoverFromFailure(ctx context.Context, stop <-chan struct{}, chain *chainState, err error) error {
+	if chain.opts.OutageThreshold <= 0 || !errors.IsOutageError(err) {
+		return err
+	}
+
+	failures := chain.consecutiveFailures.Add(1)
+	if failures < uint64(chain.opts.OutageThreshold) {
+		log.Printf("Chain %s failed (%d/%d before cooldown), fetching again: %v\n", chain.chainInfo.ChainId, failures, chain.opts.OutageThreshold, err)
+		return nil
+	}
+
+	log.Printf("Chain %s failed %d times in a row, provider considered down, probing head every %s: %v\n", chain.chainInfo.ChainId, failures, chain.opts.OutageProbeInterval, err)
+	chain.cooldown.Store(true)
+	defer chain.cooldown.Store(false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ErrChainCanceled
+		case <-stop:
+			return ErrChainCanceled
+		case <-chain.opts.Clock.After(chain.opts.OutageProbeInterval):
+		}
+
+		// A single call, retrying would defeat the point of probing occasionally
+		_, err := chain.chainInfo.RPC.Head(ctx)
+		if chain.recordError("eth_blockNumber", 0, 0, chain.stats.recordRPC(err)) == nil {
+			log.Printf("Chain %s provider recovered, resuming from block %d\n", chain.chainInfo.ChainId, chain.cursor)
+			chain.consecutiveFailures.Store(0)
+			return nil
+		}
+		if !errors.IsOutageError(err) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutage_CooldownThenRecovery(t *testing.T) {
+	var down atomic.Bool
+	var requests atomic.Int64
+	down.Store(true)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			http.Error(w, "provider down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x5"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:           2,
+		EndBlock:            5,
+		OutageThreshold:     2,
+		OutageProbeInterval: 20 * time.Millisecond,
+		RetryConfig:         &rpc.RetryConfig{MaxAttempts: 1},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- processor.Run(ctx) }()
+
+	// The chain enters the cooldown after 2 failed head fetches
+	assert.Eventually(t, func() bool {
+		health, err := processor.Health("1")
+		return err == nil && health.Cooldown
+	}, time.Second, 5*time.Millisecond)
+	health, err := processor.Health("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), health.ConsecutiveFailures)
+
+	// Only the head is probed occasionally meanwhile
+	before := requests.Load()
+	time.Sleep(200 * time.Millisecond)
+	assert.LessOrEqual(t, requests.Load()-before, int64(12))
+
+	// The chain resumes once the provider is back
+	down.Store(false)
+	assert.NoError(t, <-runErr)
+	assert.ErrorIs(t, processor.StopReason("1"), ErrChainCompleted)
+
+	health, err = processor.Health("1")
+	assert.NoError(t, err)
+	assert.False(t, health.Cooldown)
+	assert.Equal(t, uint64(0), health.ConsecutiveFailures)
+	assert.Equal(t, uint64(5), health.Watermark)
+
+	_, err = processor.Health("unknown")
+	assert.Error(t, err)
+}
+
+func TestOutage_NonOutageErrorStopsChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:       2,
+		OutageThreshold: 2,
+		RetryConfig:     &rpc.RetryConfig{MaxAttempts: 1},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Error(t, processor.Run(ctx))
+
+	health, err := processor.Health("1")
+	assert.NoError(t, err)
+	assert.False(t, health.Cooldown)
+	assert.Equal(t, uint64(0), health.ConsecutiveFailures)
+}
@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"context"
+	"log"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// logKey identifies a log across scans.
+// The block hash is part of the key so a log re-included on another fork is emitted again.
+// A removal has its own key so it isn't mistaken for the log it removes.
+func logKey(l types.Log) string {
+	key := l.BlockHash + ":" + l.TransactionHash + ":" + l.LogIndex
+	if l.Removed {
+		key += ":removed"
+	}
+	return key
+}
+
+// markSeen remembers an emitted log so the tip re-scan doesn't emit it twice.
+// Only logs inside the re-scan window of target are kept to bound memory during backfill.
+// Emitting a removal forgets the removed log, so it is emitted again if it comes back.
+func (c *chainState) markSeen(l types.Log, target uint64) {
+	if c.opts.TipOverlapBlocks == 0 {
+		return
+	}
+	blockNumber, err := utils.HexQtyToUint64(l.BlockNumber)
+	if err != nil || blockNumber+c.opts.TipOverlapBlocks <= target {
+		return
+	}
+
+	opposite := l
+	opposite.Removed = !l.Removed
+	delete(c.seenLogs, logKey(opposite))
+	c.seenLogs[logKey(l)] = blockNumber
+}
+
+// pruneSeen forgets the logs that are below the re-scan window
+func (c *chainState) pruneSeen(from uint64) {
+	for key, blockNumber := range c.seenLogs {
+		if blockNumber < from {
+			delete(c.seenLogs, key)
+		}
+	}
+}
+
+// rescanTip fetches the last TipOverlapBlocks blocks up to target again and emits the logs
+// that were not emitted before. This catches logs that the provider indexed after our first scan.
+// When the cursor block was reorged meanwhile nothing is emitted, the cursor moves back to the common ancestor instead.
+func (p *Processor) rescanTip(ctx context.Context, logsCh chan types.Log, batchCh chan []types.Log, chain *chainState, target uint64) error {
+	// Same lower bound as the planner, block 0 is only indexed with IndexGenesis
+	from := uint64(1)
+	if chain.opts.StartFrom == StartFromGenesis && chain.opts.IndexGenesis {
+		from = 0
+	}
+	if target < from {
+		return nil
+	}
+	if target >= from+chain.opts.TipOverlapBlocks {
+		from = target - chain.opts.TipOverlapBlocks + 1
+	}
+	chain.pruneSeen(from)
+
+	var logs []types.Log
+	err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+		var err error
+		logs, err = p.fetchRange(ctx, from, target, chain)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The range may have been reorged since it was committed, its logs would be of the wrong fork.
+	// The re-scan is below the cursor so the cursor block hash covers every block of it.
+	var reorged bool
+	err = rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+		var err error
+		reorged, err = p.cursorReorged(ctx, chain)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if reorged {
+		log.Println("Cursor block hash changed during the tip re-scan, reorg happened...")
+		chain.stats.reorgs.Add(1)
+		ancestor, err := p.handleReorg(ctx, chain)
+		if err != nil {
+			return err
+		}
+		chain.setCursor(ancestor)
+		return nil
+	}
+
+	var unseen []types.Log
+	for _, l := range logs {
+		if _, seen := chain.seenLogs[logKey(l)]; !seen {
+			unseen = append(unseen, l)
+		}
+	}
+	if chain.opts.Sink != nil {
+		return p.storeLogs(ctx, chain, unseen, target)
+	}
+	if !p.emitLogs(ctx, logsCh, batchCh, chain, unseen, target) {
+		return ctx.Err()
+	}
+
+	return nil
+}
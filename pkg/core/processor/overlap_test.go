@@ -0,0 +1,358 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTipOverlap_EmitsLateLogs(t *testing.T) {
+	transferLog := func(blockNumber string, logIndex string) map[string]any {
+		return map[string]any{
+			"address":         "0xabc",
+			"topics":          []any{"0xddf252ad"},
+			"data":            "0x",
+			"blockNumber":     blockNumber,
+			"blockHash":       "0xbh" + blockNumber,
+			"transactionHash": "0xth" + blockNumber,
+			"logIndex":        logIndex,
+		}
+	}
+
+	var mu sync.Mutex
+	tipScans := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  "0x14",
+			})
+
+		case "eth_getBlockByNumber":
+			var number string
+			_ = json.Unmarshal(req.Params[0], &number)
+			blockNum, err := utils.HexQtyToUint64(number)
+			assert.NoError(t, err)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result": map[string]any{
+					"Number":     number,
+					"Hash":       number,
+					"ParentHash": utils.Uint64ToHexQty(blockNum - 1),
+				},
+			})
+
+		case "eth_getLogs":
+			var filter types.Filter
+			_ = json.Unmarshal(req.Params[0], &filter)
+			to, _ := utils.HexQtyToUint64(filter.ToBlock)
+
+			result := []map[string]any{}
+			if to == 20 {
+				mu.Lock()
+				tipScans++
+				scans := tipScans
+				mu.Unlock()
+
+				result = append(result, transferLog("0x12", "0x0"))
+				// The provider indexed this log late, only visible from the second scan
+				if scans > 1 {
+					result = append(result, transferLog("0x13", "0x0"))
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  result,
+			})
+
+		default:
+			http.Error(w, fmt.Sprintf("method %s not supported", req.Method), http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 2,
+		LogsBufferSize:     1024,
+		TipOverlapBlocks:   5,
+	}
+	chain := ChainInfo{
+		ChainId: "1",
+		RPC:     rpc.NewHTTPRPC(srv.URL, 0),
+	}
+
+	processor := NewProcessor()
+	processor.AddChain(chain, &opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	logsCh, err := processor.Logs(chain.ChainId)
+	assert.NoError(t, err)
+
+	var logs []types.Log
+	for len(logsCh) > 0 {
+		logs = append(logs, <-logsCh)
+	}
+
+	// Each log is emitted once even though the tip was scanned many times
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "0x12", logs[0].BlockNumber)
+	assert.Equal(t, "0x13", logs[1].BlockNumber)
+
+	mu.Lock()
+	assert.Greater(t, tipScans, 1)
+	mu.Unlock()
+}
+
+func TestTipOverlap_ReorgDuringRescan(t *testing.T) {
+	var mu sync.Mutex
+	forked := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x14"
+
+		case "eth_getBlockByNumber":
+			var number string
+			_ = json.Unmarshal(req.Params[0], &number)
+			blockNum, err := utils.HexQtyToUint64(number)
+			assert.NoError(t, err)
+
+			// Blocks from 18 are replaced once the tip is re-scanned
+			mu.Lock()
+			onFork := forked && blockNum >= 18
+			mu.Unlock()
+			hash := number
+			parentHash := utils.Uint64ToHexQty(blockNum - 1)
+			if onFork {
+				hash = fmt.Sprintf("0xf%d", blockNum)
+				if blockNum > 18 {
+					parentHash = fmt.Sprintf("0xf%d", blockNum-1)
+				}
+			}
+			result = map[string]any{"number": number, "hash": hash, "parentHash": parentHash}
+
+		case "eth_getLogs":
+			var filter types.Filter
+			_ = json.Unmarshal(req.Params[0], &filter)
+			from, _ := utils.HexQtyToUint64(filter.FromBlock)
+			to, _ := utils.HexQtyToUint64(filter.ToBlock)
+
+			mu.Lock()
+			// The first re-scan of the tip, from 16, sees the new fork
+			if from == 16 {
+				forked = true
+			}
+			onFork := forked
+			mu.Unlock()
+
+			logs := []map[string]any{}
+			if onFork && from <= 19 && to >= 19 {
+				logs = append(logs, map[string]any{
+					"address":         "0xabc",
+					"blockNumber":     "0x13",
+					"blockHash":       "0xf19",
+					"transactionHash": "0xth19",
+					"logIndex":        "0x0",
+				})
+			}
+			result = logs
+
+		default:
+			http.Error(w, fmt.Sprintf("method %s not supported", req.Method), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:        10,
+		LogsBufferSize:   1024,
+		TipOverlapBlocks: 5,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	// The re-scan caught the reorg and the window is committed again on the new fork
+	assert.Equal(t, uint64(1), processor.Stats().Total.Reorgs)
+	logs := processor.DrainLogs(chain.ChainId)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, "0xf19", logs[0].BlockHash)
+	}
+	assert.Equal(t, "0xf20", processor.chains[chain.ChainId].storedWindowHash[20])
+}
+
+func TestMarkSeen_Removal(t *testing.T) {
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)}, &Options{
+		RangeSize:        10,
+		TipOverlapBlocks: 5,
+	})
+	assert.NoError(t, err)
+	chain := processor.chains["1"]
+
+	added := types.Log{BlockNumber: "0x64", BlockHash: "0xbh", TransactionHash: "0xth", LogIndex: "0x0"}
+	removed := added
+	removed.Removed = true
+
+	chain.markSeen(added, 100)
+	_, seen := chain.seenLogs[logKey(removed)]
+	assert.False(t, seen, "a removal must not be deduped against the log it removes")
+
+	chain.markSeen(removed, 100)
+	_, seen = chain.seenLogs[logKey(added)]
+	assert.False(t, seen, "the log must be emitted again if it comes back")
+	_, seen = chain.seenLogs[logKey(removed)]
+	assert.True(t, seen)
+}
+
+// lateLogServer answers head 0x2 and, from the getLogs call number late on, a log in block lateBlock.
+// The getLogs call number failAt answers 503.
+func lateLogServer(t *testing.T, lateBlock uint64, late int64, failAt int64) *httptest.Server {
+	var calls atomic.Int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x2"
+
+		case "eth_getBlockByNumber":
+			var number string
+			_ = json.Unmarshal(req.Params[0], &number)
+			result = map[string]any{"number": number, "hash": number, "parentHash": "0x0"}
+
+		case "eth_getLogs":
+			var filter types.Filter
+			_ = json.Unmarshal(req.Params[0], &filter)
+			from, _ := utils.HexQtyToUint64(filter.FromBlock)
+
+			call := calls.Add(1)
+			if call == failAt {
+				http.Error(w, "provider down", http.StatusServiceUnavailable)
+				return
+			}
+			logs := []map[string]any{}
+			if call >= late && from <= lateBlock {
+				logs = append(logs, map[string]any{
+					"address":         "0xabc",
+					"blockNumber":     utils.Uint64ToHexQty(lateBlock),
+					"blockHash":       utils.Uint64ToHexQty(lateBlock),
+					"transactionHash": "0xth",
+					"logIndex":        "0x0",
+				})
+			}
+			result = logs
+
+		default:
+			http.Error(w, fmt.Sprintf("method %s not supported", req.Method), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestTipOverlap_RescansGenesis(t *testing.T) {
+	// The genesis log is indexed late, only the re-scan sees it
+	srv := lateLogServer(t, 0, 2, 0)
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:        10,
+		LogsBufferSize:   1024,
+		TipOverlapBlocks: 5,
+		IndexGenesis:     true,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	logs := processor.DrainLogs("1")
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, "0x0", logs[0].BlockNumber)
+	}
+}
+
+func TestTipOverlap_RescanOutageRecovers(t *testing.T) {
+	// The first re-scan fails, the chain goes on and a later re-scan emits the late log
+	srv := lateLogServer(t, 1, 3, 2)
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:        10,
+		LogsBufferSize:   1024,
+		TipOverlapBlocks: 5,
+		OutageThreshold:  3,
+		RetryConfig:      &rpc.RetryConfig{MaxAttempts: 1},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	logs := processor.DrainLogs("1")
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, "0x1", logs[0].BlockNumber)
+	}
+}
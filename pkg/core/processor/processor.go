@@ -2,11 +2,18 @@ package processor
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ryuux05/godex/pkg/core/errors"
 	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
 	"github.com/ryuux05/godex/pkg/core/utils"
 	"github.com/ryuux05/godex/pkg/core/types"
 	"golang.org/x/sync/errgroup"
@@ -18,6 +25,8 @@ type chainState struct {
 	chainInfo ChainInfo
 	// cursor is a pointer that points the current block where the indexer is pointing 
 	cursor uint64
+	// watermark mirrors cursor for readers outside the chain goroutines
+	watermark atomic.Uint64
 	
 	// FIFO of endHeights in commit order
 	windowOrder []uint64
@@ -30,8 +39,61 @@ type chainState struct {
 	hardFallbackBlocks uint64
 	// Storage to store the formatted topics
 	topics []string
+	// Lowercased values accepted per topic position after the event signature, from IndexedTopics
+	indexedTopics [][]string
+	// Lowercased set of contract addresses to filter on, empty means any address
+	addresses map[string]struct{}
+	// Addresses of the logs filter, from Addresses or Contracts
+	filterAddresses []string
+	// Events watched per contract, nil when Contracts is not set
+	contracts contractFilters
+	// topics and addresses as bytes for the logs bloom check of BloomPrecheck
+	bloomTopics [][]byte
+	bloomAddresses [][]byte
 	// options for processor
 	opts *Options
+	// counters exposed through Stats
+	stats chainCounters
+	// Emitted logs near the tip with their block number, used to dedup the tip re-scan
+	seenLogs map[string]uint64
+	// startResolved is true once the StartFromHead cursor has been set from the head
+	startResolved bool
+	// gapChecked is true once the gap to the head was checked against MaxInitialGap, on the first run
+	gapChecked bool
+	// genesisPending is true until the first window commits when block 0 is indexed, see Options.IndexGenesis
+	genesisPending bool
+	// Recently fetched blocks, nil when BlockCacheSize is 0
+	blockCache *blockCache
+	// completed is true once the chain reached EndBlock and its channels are closed
+	completed bool
+	// stopReason is why the chain stopped during the last Run, nil while running. Guarded by Processor.mu.
+	stopReason error
+	// completion delivers how the chain terminated, buffered so the chain never waits on a reader
+	completion chan CompletionInfo
+	// Outage failures since the last commit and whether the chain is in cooldown, exposed through Health
+	consecutiveFailures atomic.Uint64
+	cooldown atomic.Bool
+	// Window starting at mismatchWindow whose receipts disagreed with the headers mismatchCount times in a row,
+	// see ValidateReceipts. Only used by the arbiter.
+	mismatchWindow uint64
+	mismatchCount int
+	// Subscribers of the logs, see Subscribe
+	fanout *fanout
+	// retryConfig is the RetryConfig in use, replaced at runtime by SetRetryConfig while the fetchers read it
+	retryMu sync.RWMutex
+	retryConfig rpc.RetryConfig
+	// Last failed RPC calls, see RecentErrors
+	errorLog *errorLog
+	// head is the last fetched head, lagging is true while the lag alert is raised, see checkLag
+	head atomic.Uint64
+	lagging atomic.Bool
+	// caughtUp is closed the first time the cursor reaches the head, see checkCaughtUp
+	caughtUp chan struct{}
+	caughtUpOnce sync.Once
+	// scaler bounds the fetchers in flight, nil without ConcurrencyScaling
+	scaler *concurrencyScaler
+	// sinkEvents converts the committed logs to the events stored in Options.Sink, raw events when nil
+	sinkEvents func(ctx context.Context, logs []types.Log) ([]types.Event, error)
 }
 
 type Processor struct {
@@ -41,6 +103,12 @@ type Processor struct {
 	// logsChan is a channel where processor will store the indexed logs
 	// It's a map with chainId as key.
 	logsCh map[string]chan types.Log
+	// logsBatchCh is a channel where processor will store the indexed logs batched per window.
+	// Only created for chains with EmitBatches enabled.
+	logsBatchCh map[string]chan []types.Log
+	// blocksCh is a channel where processor will store every committed block header.
+	// Only created for chains with EmitBlocks enabled.
+	blocksCh map[string]chan types.Block
 	// isRunning track the processor state if it's running or stopped.
 	// False by default until the processor run.
 	isRunning bool
@@ -52,12 +120,26 @@ func NewProcessor() *Processor {
 	return &Processor{
 		chains: make(map[string]*chainState),
 		logsCh: make(map[string]chan types.Log),
+		logsBatchCh: make(map[string]chan []types.Log),
+		blocksCh: make(map[string]chan types.Block),
 		isRunning: false,
 	}
 }
 
 
 func (p *Processor) AddChain(chain ChainInfo, opts *Options) error {
+	return p.AddChainContext(context.Background(), chain, opts)
+}
+
+// AddChainContext is AddChain with a context bounding the chain id check of VerifyChainID
+func (p *Processor) AddChainContext(ctx context.Context, chain ChainInfo, opts *Options) error {
+	// Ask the node before locking, a slow endpoint doesn't block the processor
+	if opts != nil && opts.VerifyChainID {
+		if err := verifyChainID(ctx, chain); err != nil {
+			return err
+		}
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	
@@ -65,27 +147,132 @@ func (p *Processor) AddChain(chain ChainInfo, opts *Options) error {
         return fmt.Errorf("cannot add chain while processor is running")
     }
 
-	cursor := opts.StartBlock; if cursor == 0 { cursor = 0 }
+	chainState, err := newChainState(chain, opts)
+	if err != nil {
+		return err
+	}
+	p.register(chainState)
+	return nil
+}
+
+// newChainState validates opts, fills in their defaults and returns the state of chain, not registered yet
+func newChainState(chain ChainInfo, opts *Options) (*chainState, error) {
+	// Resolve where to start, StartFromHead is resolved when the chain starts running
+	if opts.StartFrom == "" {
+		opts.StartFrom = StartFromGenesis
+		if opts.StartBlock > 0 {
+			opts.StartFrom = StartFromBlock
+		}
+	}
+	var cursor uint64
+	switch opts.StartFrom {
+	case StartFromGenesis, StartFromHead:
+		cursor = 0
+	case StartFromBlock:
+		cursor = opts.StartBlock
+	default:
+		return nil, fmt.Errorf("unknown start mode %q", opts.StartFrom)
+	}
 
 	// Clamp the max storedwindowhash bound.
 	rs := uint64(opts.RangeSize)         // assume >0
+	// A hash every WindowHashStride blocks when it is finer than the windows
+	if opts.WindowHashStride > 0 && opts.WindowHashStride < rs {
+		rs = opts.WindowHashStride
+	}
+	// Aligned windows may be as short as AlignRangesTo
+	if opts.AlignRangesTo > 0 && opts.AlignRangesTo < rs {
+		rs = opts.AlignRangesTo
+	}
 	base := (opts.ReorgLookbackBlocks + rs - 1) / rs // ceil
 	cap := base + 1
 	if cap < 8 { cap = 8 }
 	if cap > 256 { cap = 256 }
 
 	topics := utils.ConvertToTopics(opts.Topics)
+	filterAddresses := opts.Addresses
+
+	// Contracts replace the flat Topics and Addresses
+	var contracts contractFilters
+	if len(opts.Contracts) > 0 {
+		if len(opts.Topics) > 0 || len(opts.Addresses) > 0 {
+			return nil, fmt.Errorf("contracts can't be combined with topics or addresses")
+		}
+		var err error
+		contracts, filterAddresses, topics, err = compileContracts(opts.Contracts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	indexedTopics, err := compileIndexedTopics(opts.IndexedTopics)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make(map[string]struct{}, len(filterAddresses))
+	for _, address := range filterAddresses {
+		addresses[strings.ToLower(address)] = struct{}{}
+	}
 
 	// Check if fetch mode exists, fallback to logs as default if not specified
 	if opts.FetchMode == "" {
 		opts.FetchMode = FetchModeLogs
 	}
+	if opts.FetchMode == FetchModeTxReceipts {
+		if _, ok := chain.RPC.(rpc.TxReceiptsRPC); !ok {
+			return nil, fmt.Errorf("fetch mode %q requires an RPC implementing rpc.TxReceiptsRPC, got %T", opts.FetchMode, chain.RPC)
+		}
+		if opts.TxReceiptsConcurrency <= 0 {
+			opts.TxReceiptsConcurrency = defaultTxReceiptsConcurrency
+		}
+	}
+
+	if opts.Sink != nil && opts.EmitBatches {
+		return nil, fmt.Errorf("Sink can't be combined with EmitBatches, the logs are stored instead of emitted")
+	}
+
+	if opts.StreamReceipts {
+		if _, ok := chain.RPC.(rpc.FilteredReceiptsRPC); !ok {
+			return nil, fmt.Errorf("StreamReceipts requires an RPC implementing rpc.FilteredReceiptsRPC, got %T", chain.RPC)
+		}
+	}
 
 	// Check if retryconfig exists, use default if not specified
 	if opts.RetryConfig == nil {
 		defaultCfg := rpc.DefaultRetryConfig()
     	opts.RetryConfig = &defaultCfg
 	}
+	if opts.Clock == nil {
+		opts.Clock = rpc.RealClock{}
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.SubscriberBackpressure == "" {
+		opts.SubscriberBackpressure = BackpressureBlock
+	}
+	if opts.OutageProbeInterval <= 0 {
+		opts.OutageProbeInterval = defaultOutageProbeInterval
+	}
+	if opts.RecentErrorsSize <= 0 {
+		opts.RecentErrorsSize = defaultRecentErrorsSize
+	}
+	// Copy so the clock doesn't leak into a config shared with other chains
+	if opts.RetryConfig.Clock == nil {
+		retryCfg := *opts.RetryConfig
+		retryCfg.Clock = opts.Clock
+		opts.RetryConfig = &retryCfg
+	}
+
+	var scaler *concurrencyScaler
+	if opts.ConcurrencyScaling != nil {
+		var err error
+		scaler, err = newConcurrencyScaler(chain.ChainId, *opts.ConcurrencyScaling, opts.FetcherConcurrency)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	chainState := &chainState{
 		chainInfo: chain,
@@ -95,35 +282,300 @@ func (p *Processor) AddChain(chain ChainInfo, opts *Options) error {
 		storedWindowHash: make(map[uint64]string, cap),
 		hardFallbackBlocks: 1000,
 		topics: topics,
+		indexedTopics: indexedTopics,
+		addresses: addresses,
+		bloomTopics: hexToBytes(topics),
+		bloomAddresses: hexToBytes(filterAddresses),
+		filterAddresses: filterAddresses,
+		contracts: contracts,
+		seenLogs: make(map[string]uint64),
+		blockCache: newBlockCache(opts.BlockCacheSize),
+		fanout: newFanout(opts.LogsBufferSize),
+		retryConfig: *opts.RetryConfig,
+		errorLog: newErrorLog(opts.RecentErrorsSize),
+		caughtUp: make(chan struct{}),
+		completion: make(chan CompletionInfo, 1),
+		genesisPending: opts.StartFrom == StartFromGenesis && opts.IndexGenesis,
+		scaler: scaler,
+	}
+
+	chainState.watermark.Store(cursor)
+	return chainState, nil
+}
+
+// register adds the chain and its output channels, the caller holds the lock
+func (p *Processor) register(chain *chainState) {
+	chainId, opts := chain.chainInfo.ChainId, chain.opts
+	p.chains[chainId] = chain
+	// Nothing is sent on the logs channel of a chain with a Sink, don't allocate its buffer
+	logsBufferSize := opts.LogsBufferSize
+	if opts.Sink != nil {
+		logsBufferSize = 0
+	}
+	p.logsCh[chainId] = make(chan types.Log, logsBufferSize)
+	if opts.EmitBatches {
+		p.logsBatchCh[chainId] = make(chan []types.Log, opts.LogsBufferSize)
+	}
+	if opts.EmitBlocks {
+		p.blocksCh[chainId] = make(chan types.Block, opts.LogsBufferSize)
+	}
+}
+
+// retry returns the retry config of the chain
+func (c *chainState) retry() rpc.RetryConfig {
+	c.retryMu.RLock()
+	defer c.retryMu.RUnlock()
+	return c.retryConfig
+}
+
+// SetRetryConfig replaces the retry config of the chain, it takes effect on the next RPC call.
+// It is safe to call while the processor is running, e.g. to loosen the retries of a flaky provider.
+// A nil cfg.Clock keeps the chain Clock.
+func (p *Processor) SetRetryConfig(chainId string, cfg rpc.RetryConfig) error {
+	if cfg.Strategy == nil && cfg.MaxAttempts < 1 {
+		return fmt.Errorf("MaxAttempts must be at least 1, got %d", cfg.MaxAttempts)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainId)
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = chain.opts.Clock
+	}
+
+	chain.retryMu.Lock()
+	chain.retryConfig = cfg
+	chain.retryMu.Unlock()
+	return nil
+}
+
+// setCursor moves the cursor and publishes it as the watermark
+func (c *chainState) setCursor(cursor uint64) {
+	c.cursor = cursor
+	c.genesisPending = false
+	c.watermark.Store(cursor)
+}
+
+// windowEnd returns the last block of the window starting at from: RangeSize blocks capped at target,
+// and cut at the next multiple of AlignRangesTo so a window never spans an alignment boundary.
+func (c *chainState) windowEnd(from uint64, target uint64) uint64 {
+	to := from + uint64(c.opts.RangeSize) - 1
+	if align := c.opts.AlignRangesTo; align > 0 {
+		boundary := (from/align+1)*align - 1
+		if to > boundary {
+			to = boundary
+		}
+	}
+	if to > target {
+		to = target
+	}
+	return to
+}
+
+// nextBlock returns the first block to fetch. The cursor is the last processed block,
+// so it is the block after it, except block 0 itself while the genesis is still to index.
+func (c *chainState) nextBlock() uint64 {
+	if c.genesisPending {
+		return 0
+	}
+	return c.cursor + 1
+}
+
+// tagChainId sets the chain id on logs when TagChainId is enabled
+func (c *chainState) tagChainId(logs []types.Log) {
+	if !c.opts.TagChainId {
+		return
+	}
+	for i := range logs {
+		logs[i].ChainId = c.chainInfo.ChainId
+	}
+}
+
+// Watermark returns the last block committed for the chain, including the blocks without logs.
+// Use it to tell a quiet chain from a stuck one. It is safe to call while the processor is running.
+func (p *Processor) Watermark(chainId string) (uint64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return 0, fmt.Errorf("chain %s not found", chainId)
+	}
+	return chain.watermark.Load(), nil
+}
+
+// GetCursor returns the last block processed for the chain, the next run starts after it.
+// It is safe to call while the processor is running.
+func (p *Processor) GetCursor(chainId string) (uint64, error) {
+	return p.Watermark(chainId)
+}
+
+// SetCursor seeks the chain to block, the next run starts after it. Call it before Run.
+// The window hashes and cached blocks beyond block are dropped, so the reorg checks
+// only compare against blocks of the new history.
+func (p *Processor) SetCursor(chainId string, block uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isRunning {
+		return fmt.Errorf("cannot set cursor while processor is running")
+	}
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainId)
+	}
+	// The channels of a completed chain are closed
+	if chain.completed {
+		return fmt.Errorf("cannot set cursor of completed chain %s", chainId)
+	}
+
+	p.seekCursor(chain, block)
+	return nil
+}
+
+// seekCursor moves the cursor of chain to block before a run, dropping the window hashes,
+// cached blocks and seen logs beyond it so the reorg checks only compare against the new history.
+// The cursor wins over resolving the start from the head.
+func (p *Processor) seekCursor(chain *chainState, block uint64) {
+	p.dropWindowHash(block, chain)
+	chain.blockCache.invalidateFrom(block + 1)
+	for key, blockNumber := range chain.seenLogs {
+		if blockNumber > block {
+			delete(chain.seenLogs, key)
+		}
 	}
+	chain.setCursor(block)
+	chain.startResolved = true
+}
+
+// RestoreFromSink resumes every chain after the last block stored in the sink,
+// so a restarted processor doesn't process the stored blocks again.
+// Chains without stored data keep their start block. Call it before Run.
+func (p *Processor) RestoreFromSink(ctx context.Context, s sink.Sink) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	p.chains[chain.ChainId] = chainState
-	p.logsCh[chain.ChainId] = make(chan types.Log, opts.LogsBufferSize)
+	if p.isRunning {
+		return fmt.Errorf("cannot restore while processor is running")
+	}
 
+	for chainId, chain := range p.chains {
+		last, ok, err := s.GetLastBlock(ctx, chainId)
+		if err != nil {
+			return fmt.Errorf("error getting last block of chain %s: %w", chainId, err)
+		}
+		if !ok {
+			continue
+		}
+		p.seekCursor(chain, last)
+	}
 	return nil
 }
 
-func (p *Processor) GetChain(chainId string) ChainInfo {
-	return p.chains[chainId].chainInfo
+// GetChain returns the info the chain was added with.
+// It is safe to call while the processor is running.
+func (p *Processor) GetChain(chainId string) (ChainInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return ChainInfo{}, fmt.Errorf("chain %s not found", chainId)
+	}
+	return chain.chainInfo, nil
 }
 
+// Run runs every chain until ctx is done. A failing chain stops alone, the others keep running
+// unless it has FailFast. Run returns once all the chains stopped, with the failures if any.
 func (p *Processor) Run(ctx context.Context) error{
+	return p.run(ctx, nil)
+}
+
+// RunFor runs the chains for at most total then stops them gracefully.
+// At total - grace the chains stop planning new windows and commit the ones in flight,
+// at total the chains still running are canceled.
+func (p *Processor) RunFor(ctx context.Context, total time.Duration, grace time.Duration) error {
+	if total <= 0 || grace < 0 || grace > total {
+		return fmt.Errorf("invalid run duration %v with grace %v", total, grace)
+	}
+
+	hardCtx, cancel := context.WithTimeout(ctx, total)
+	defer cancel()
+
+	stop := make(chan struct{})
+	timer := time.AfterFunc(total-grace, func() { close(stop) })
+	defer timer.Stop()
+
+	return p.run(hardCtx, stop)
+}
+
+// run runs every chain until ctx is done, or until stop is closed and the windows in flight are committed.
+// A nil stop never fires.
+func (p *Processor) run(ctx context.Context, stop <-chan struct{}) error {
+	// The chains and their channels, read under the lock since they are registered together
+	type chainRun struct {
+		chain    *chainState
+		logsCh   chan types.Log
+		batchCh  chan []types.Log
+		blocksCh chan types.Block
+	}
+	runs := make(map[string]chainRun)
+
+	// Set along with the snapshot so RemoveChain either happens before it or is refused until Run returns.
+	// Stats are counted since Run started
+	p.mu.Lock()
 	p.isRunning = true
-    defer func() { p.isRunning = false }()
+	defer func() {
+		p.mu.Lock()
+		p.isRunning = false
+		p.mu.Unlock()
+	}()
+	for chainId, chain := range p.chains {
+		chain.stats.reset()
+		chain.stopReason = nil
+		chain.consecutiveFailures.Store(0)
+		runs[chainId] = chainRun{chain, p.logsCh[chainId], p.logsBatchCh[chainId], p.blocksCh[chainId]}
+	}
+	p.mu.Unlock()
+
+	// Chains are isolated, a failing chain doesn't cancel the others unless it has FailFast
+	chainsCtx, cancelChains := context.WithCancel(ctx)
+	defer cancelChains()
 
 	g := errgroup.Group{}
-	for chainId, chain := range p.chains {
+	for chainId, run := range runs {
 		id := chainId
-        c := chain
-		ch := p.logsCh[id]
+        c := run.chain
+		ch := run.logsCh
+		batchCh := run.batchCh
+		blocksCh := run.blocksCh
         
 		g.Go(func () error  {	
-			err := p.runChain(ctx, ch, c)
-			if err != nil {
-                log.Printf("Chain %s stopped: %v", id, err)
-                // Error logged but doesn't stop other chains
-            }
-            return err  
+			err := p.runChain(chainsCtx, stop, ch, batchCh, blocksCh, c)
+			// Errors caused by the cancellation itself, e.g. an aborted head fetch, are a cancellation
+			if !isStopReason(err) && chainsCtx.Err() != nil {
+				err = ErrChainCanceled
+			}
+
+			p.mu.Lock()
+			c.stopReason = err
+			p.mu.Unlock()
+			c.complete(newCompletionInfo(c.watermark.Load(), err))
+
+			if isStopReason(err) {
+				return nil
+			}
+			log.Printf("Chain %s stopped: %v", id, err)
+			// Error logged but doesn't stop other chains
+			if c.opts.FailFast {
+				log.Printf("Chain %s fails fast, stopping the other chains", id)
+				cancelChains()
+			}
+			return err
 		})
 
 	}
@@ -143,46 +595,206 @@ func (p *Processor) Logs(chainId string) (<-chan types.Log, error) {
     return ch, nil
 }
 
-func (p *Processor) runChain(ctx context.Context, logsCh chan types.Log, chain *chainState) error {
-outer:
-	for {		
-		rpcCtx, rpcCancel := context.WithCancel(ctx)
+// DrainLogs returns the logs currently buffered in the logs channel without blocking.
+// Useful after a bounded run (EndBlock) to collect the whole backfill at once.
+// It returns nil if the chain doesn't exist.
+func (p *Processor) DrainLogs(chainId string) []types.Log {
+	p.mu.RLock()
+	ch, exists := p.logsCh[chainId]
+	p.mu.RUnlock()
+	if !exists {
+		return nil
+	}
 
-		// compute for new head
-		var headHex string
-		err := rpc.RetryWithBackoff(rpcCtx, *chain.opts.RetryConfig, func() error {
+	var logs []types.Log
+	for {
+		select {
+		case l, ok := <-ch:
+			if !ok {
+				return logs
+			}
+			logs = append(logs, l)
+		default:
+			return logs
+		}
+	}
+}
+
+// LogsBatched returns the read-only channel of logs batched per committed window.
+// The chain must be added with EmitBatches enabled.
+func (p *Processor) LogsBatched(chainId string) (<-chan []types.Log, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, exists := p.chains[chainId]; !exists {
+		return nil, fmt.Errorf("chain %s not found", chainId)
+	}
+	ch, exists := p.logsBatchCh[chainId]
+	if !exists {
+		return nil, fmt.Errorf("batched logs not enabled for chain %s", chainId)
+	}
+	return ch, nil
+}
+
+// Blocks returns the read-only channel of committed block headers, including the blocks without logs.
+// The chain must be added with EmitBlocks enabled.
+func (p *Processor) Blocks(chainId string) (<-chan types.Block, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, exists := p.chains[chainId]; !exists {
+		return nil, fmt.Errorf("chain %s not found", chainId)
+	}
+	ch, exists := p.blocksCh[chainId]
+	if !exists {
+		return nil, fmt.Errorf("blocks not enabled for chain %s", chainId)
+	}
+	return ch, nil
+}
+
+// IndexBlocks fetches the logs of exactly the given blocks, bypassing the continuous loop.
+// It respects the chain FetchMode and topic/address filters and is meant for targeted re-scans.
+// Logs are returned in the order of blocks, duplicate block numbers are fetched once.
+func (p *Processor) IndexBlocks(ctx context.Context, chainId string, blocks []uint64) ([]types.Log, error) {
+	p.mu.RLock()
+	chain, exists := p.chains[chainId]
+	p.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("chain %s not found", chainId)
+	}
+
+	var allLogs []types.Log
+	fetched := make(map[uint64]struct{}, len(blocks))
+	for _, blockNum := range blocks {
+		if _, ok := fetched[blockNum]; ok {
+			continue
+		}
+		fetched[blockNum] = struct{}{}
+
+		var logs []types.Log
+		err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
 			var err error
-			headHex, err = chain.chainInfo.RPC.Head(rpcCtx)
+			logs, err = p.fetchRange(ctx, blockNum, blockNum, chain)
 			return err
 		})
 		if err != nil {
-			rpcCancel()
+			return nil, fmt.Errorf("failed to index block %d: %w", blockNum, err)
+		}
+		allLogs = append(allLogs, logs...)
+	}
+
+	return allLogs, nil
+}
+
+func (p *Processor) runChain(ctx context.Context, stop <-chan struct{}, logsCh chan types.Log, batchCh chan []types.Log, blocksCh chan types.Block, chain *chainState) error {
+	// Sending on a nil channel blocks forever, fail instead of hanging the arbiter.
+	// register creates the channels along with the chain so it is a bug if it happens.
+	if logsCh == nil {
+		return fmt.Errorf("chain %s has no logs channel", chain.chainInfo.ChainId)
+	}
+	if chain.opts.EmitBatches && batchCh == nil {
+		return fmt.Errorf("chain %s has no logs batch channel", chain.chainInfo.ChainId)
+	}
+	if chain.opts.EmitBlocks && blocksCh == nil {
+		return fmt.Errorf("chain %s has no blocks channel", chain.chainInfo.ChainId)
+	}
+
+	if chain.opts.StartFrom == StartFromHead && !chain.startResolved {
+		head, err := p.fetchHead(ctx, chain)
+		if err != nil {
 			return err
 		}
+		chain.setCursor(head)
+		chain.startResolved = true
+		// A chain starting at the head has no backfill
+		chain.gapChecked = true
+	}
+
+	// Guard against an accidental full-chain backfill, from the cursor the chain really starts at
+	if chain.opts.MaxInitialGap > 0 && !chain.opts.AllowLargeBackfill && !chain.gapChecked {
+		if err := p.checkInitialGap(ctx, chain); err != nil {
+			return err
+		}
+		chain.gapChecked = true
+	}
+
+outer:
+	for {		
+		// Bounded run, the chain is done once EndBlock is committed
+		if chain.opts.EndBlock > 0 && chain.cursor >= chain.opts.EndBlock {
+			p.completeChain(logsCh, batchCh, blocksCh, chain)
+			return ErrChainCompleted
+		}
+
+		// Graceful stop, the windows in flight were committed by the previous iteration
+		select {
+		case <-stop:
+			return ErrChainCanceled
+		default:
+		}
+
+		rpcCtx, rpcCancel := context.WithCancel(ctx)
 
-		head, err := utils.HexQtyToUint64(headHex)
+		// compute for new head
+		head, err := p.fetchHead(rpcCtx, chain)
 		if err != nil {
-			log.Println("Error in converting hex to uint64", err)
 			rpcCancel()
-			return err
+			if err := p.recoverFromFailure(ctx, stop, chain, err); err != nil {
+				return err
+			}
+			continue
 		}
+		chain.head.Store(head)
+		chain.checkLag()
 
 		// look for block confimation
-		var conf uint64
-		if chain.opts.Confimation > 0 {
-			conf = chain.opts.Confimation
-		}
+		conf := resolveConfirmations(chain.chainInfo.ChainId, chain.opts)
+		chain.checkCaughtUp(conf)
 
 		// Get the target block
 		target := uint64(0)
 		if head > conf {
 			target = head - conf
 		}
+		if chain.opts.EndBlock > 0 && target > chain.opts.EndBlock {
+			target = chain.opts.EndBlock
+		}
+
+		// Caught up to head, re-scan the tip for logs indexed late by the provider
+		if chain.opts.TipOverlapBlocks > 0 && chain.nextBlock() > target {
+			err := p.rescanTip(rpcCtx, logsCh, batchCh, chain, target)
+			if err != nil {
+				rpcCancel()
+				if err := p.recoverFromFailure(ctx, stop, chain, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		// Nothing to plan, e.g. caught up or a fresh chain at block 0: wait for the head to move
+		start := chain.nextBlock()
+		if start > target {
+			rpcCancel()
+			chain.consecutiveFailures.Store(0)
+			select {
+			case <-ctx.Done():
+				return ErrChainCanceled
+			case <-stop:
+				return ErrChainCanceled
+			case <-chain.opts.Clock.After(chain.opts.PollInterval):
+			}
+			continue
+		}
 
 		n := chain.opts.FetcherConcurrency
 		if n <= 0 {
 			n = 1
 		}
+		// The scaler lets at most its current limit of the fetchers fetch at the same time
+		if chain.scaler != nil {
+			n = chain.scaler.cfg.MaxConcurrency
+		}
 		
 		// plan jobs
 		type blockRange struct {
@@ -190,22 +802,38 @@ outer:
 			to uint64
 		}
 		jobs := make(chan blockRange ,n)
+
+		// A slot is taken per planned window and given back when the window commits, nil when unbounded
+		var windowSlots chan struct{}
+		if chain.opts.MaxBufferedWindows > 0 {
+			windowSlots = make(chan struct{}, chain.opts.MaxBufferedWindows)
+		}
+
 		go func() {
 			defer close(jobs)
-			rs := uint64(chain.opts.RangeSize)
+			for from := start; from <= target; {
+				to := chain.windowEnd(from, target)
 
-			for from := chain.cursor + 1; from <= target; from += rs {
-				to := from + rs - 1
-				if to > target {
-					to = target
+				if windowSlots != nil {
+					select {
+					case <-rpcCtx.Done():
+						return
+					case <-stop:
+						return
+					case windowSlots <- struct{}{}:
+					}
 				}
 
 				select {
 				case <-rpcCtx.Done():
 					return
+				case <-stop:
+					// Stop planning, the planned windows still commit
+					return
 				case jobs <- blockRange{from, to}:
 				//log.Printf("planned job from block %d to block %d...\n", from, to)
 				}
+				from = to + 1
 			} 
 		}()	
 				
@@ -218,6 +846,9 @@ outer:
 			from uint64
 			to uint64
 			logs []types.Log
+			blocks []types.Block
+			// Time spent fetching the window, retries included
+			fetchDuration time.Duration
 		}
 		
 		doneCh := make(chan doneMsg, n)
@@ -226,25 +857,40 @@ outer:
 			go func(){
 				defer wg.Done()
 				for job := range jobs {
+					if !chain.scaler.acquire(rpcCtx) {
+						return
+					}
 					var logs []types.Log
+					var blocks []types.Block
 					var err error
-					err = rpc.RetryWithBackoff(rpcCtx, *chain.opts.RetryConfig, func() error {	
-						switch chain.opts.FetchMode {
-						case FetchModeLogs:
-							filter := types.Filter{
-								FromBlock: utils.Uint64ToHexQty(job.from),
-								ToBlock: utils.Uint64ToHexQty(job.to),
-								Topics: chain.topics,
+					fetchStart := time.Now()
+					// Retry just this window before tearing down the whole batch
+					for attempt := 0; ; attempt++ {
+						err = rpc.RetryWithBackoff(rpcCtx, chain.retry(), func() error {	
+							callStart := time.Now()
+							logs, err = p.fetchRange(rpcCtx, job.from, job.to, chain)
+							if err == nil && chain.opts.EmitBlocks {
+								blocks, err = p.getBlocks(rpcCtx, job.from, job.to, chain)
 							}
-							logs, err = chain.chainInfo.RPC.GetLogs(rpcCtx, filter)
-
-						case FetchModeReceipts:
-							logs, err = p.fetchLogsFromReceipts(rpcCtx, job.from, job.to, chain)
+							if rpcCtx.Err() == nil {
+								chain.scaler.observe(time.Since(callStart), err)
+							}
+							return err
+						})
+						if err == nil || rpcCtx.Err() != nil || attempt >= chain.opts.WindowRetries {
+							break
 						}
-
-						return err
-					})
+						log.Printf("Window %d-%d failed, retrying it (%d/%d): %v\n", job.from, job.to, attempt+1, chain.opts.WindowRetries, err)
+						select {
+						case <-rpcCtx.Done():
+						case <-chain.opts.Clock.After(chain.retry().Backoff(attempt+1, err)):
+						}
+					}
+					chain.scaler.release()
 						if err != nil {
+							if rpcCtx.Err() != nil {
+								return // batch was canceled, e.g. by a reorg
+							}
 							log.Println("Error fetching logs: ", err)
 							select {
 							case errCh <- err:
@@ -257,7 +903,7 @@ outer:
 						select {
 							case <-rpcCtx.Done():
 								return
-							case doneCh <- doneMsg{from: job.from, to: job.to, logs: logs}:
+							case doneCh <- doneMsg{from: job.from, to: job.to, logs: logs, blocks: blocks, fetchDuration: time.Since(fetchStart)}:
 								//log.Printf("sending log to arbiter from block %d to block %d...\n", job.from, job.to)
 						}
 			
@@ -279,27 +925,72 @@ outer:
 			defer close(arbiterDone)
 			window := make(map[uint64]uint64)
 			windowLogs:= make(map[uint64][]types.Log)
-			next := chain.cursor + 1
+			windowBlocks := make(map[uint64][]types.Block)
+			// Windows whose logs were emitted as soon as fetched, with AllowOutOfOrderCommit
+			emittedEarly := make(map[uint64]bool)
+			windowFetchDurations := make(map[uint64]time.Duration)
+			next := start
+			// Audits run with the batch context, the batch ends once they are done
+			audits := newAuditQueue()
+			defer audits.wait()
+
+			// Re-verify the cursor block while waiting for windows, nil channel when disabled.
+			// Re-armed on every tick from the clock of the chain, so tests can drive it with an rpc.FakeClock.
+			var reorgTick <-chan time.Time
+			if chain.opts.ReorgCheckInterval > 0 {
+				reorgTick = chain.opts.Clock.After(chain.opts.ReorgCheckInterval)
+			}
 
 			for {
 				select {
 				case <-rpcCtx.Done():
 					return
+				case <-reorgTick:
+					reorgTick = chain.opts.Clock.After(chain.opts.ReorgCheckInterval)
+					reorged, err := p.cursorReorged(rpcCtx, chain)
+					if err != nil {
+						if rpcCtx.Err() != nil {
+							return
+						}
+						// Transient failure, the next tick checks again
+						log.Println("Error checking cursor block hash: ", err)
+						continue
+					}
+					if reorged {
+						log.Println("Cursor block hash changed, reorg happened...")
+						chain.stats.reorgs.Add(1)
+						rpcCancel()
+						ancestor, err := p.handleReorg(ctx, chain)
+						if err != nil {
+							select { case errCh <- err: default: }
+							return
+						}
+						chain.setCursor(ancestor)
+						return
+					}
 				case dm, ok := <-doneCh:
 					if !ok {return};
 					
 					window[dm.from] = dm.to
 					windowLogs[dm.from] = dm.logs
+					windowBlocks[dm.from] = dm.blocks
+					windowFetchDurations[dm.from] = dm.fetchDuration
+
+					// Don't wait for the earlier windows, the cursor still only advances in order below
+					if chain.opts.AllowOutOfOrderCommit && chain.opts.Sink == nil {
+						if !p.emitLogs(rpcCtx, logsCh, batchCh, chain, dm.logs, target) {
+							return
+						}
+						emittedEarly[dm.from] = true
+					}
 
 					for end, ok2 := window[next]; ok2; end, ok2 = window[next] {
+						windowStart := next
+						commitStart := time.Now()
+						logCount := len(windowLogs[next])
 						
 						// Get start window blockhash and compare it with the stored blockhash
-						var block types.Block
-						err := rpc.RetryWithBackoff(ctx, *chain.opts.RetryConfig, func() error {
-							var err error
-							block, err = chain.chainInfo.RPC.GetBlock(rpcCtx, utils.Uint64ToHexQty(next))
-							return err
-						})
+						block, err := p.getCommitBlock(rpcCtx, chain, next)
 
 						if err != nil {
 							if rpcCtx.Err() != nil { 
@@ -313,46 +1004,103 @@ outer:
 							}
 						}
 
-						
-						if next == 0 {
-							break
+
+						// Receipts may disagree with the headers of their blocks, fetch the window again with fresh headers
+						if chain.opts.ValidateReceipts && chain.opts.FetchMode != FetchModeLogs {
+							mismatch, ok, err := p.checkWindowHashes(rpcCtx, chain, next, end, block, windowLogs[next])
+							if err != nil {
+								if rpcCtx.Err() != nil { return }
+								log.Println("Error getting window block: ", err)
+								select { case errCh <- err: default: }
+								return
+							}
+							if !ok {
+								chain.stats.receiptMismatches.Add(1)
+								chain.blockCache.invalidateRange(next, end)
+								if err := chain.receiptMismatch(next, mismatch); err != nil {
+									select { case errCh <- err: default: }
+									return
+								}
+								rpcCancel()
+								return
+							}
 						}
 						
 						//Compare to parents
 						parent, ok := chain.storedWindowHash[next - 1]
 						if (ok && block.ParentHash != parent) {
 							log.Println("Hash mismatch, reorg happened...")
+							chain.stats.reorgs.Add(1)
 							rpcCancel()
-							ancestor := p.handleReorg(ctx, chain)
+							ancestor, err := p.handleReorg(ctx, chain)
+							if err != nil {
+								select { case errCh <- err: default: }
+								return
+							}
 
-							chain.cursor = ancestor
+							chain.setCursor(ancestor)
 							return
 
 						} else {
 							log.Printf("Processed log from block %d to block %d...\n", next, end)
-							// Commit logs to log channel
-							if logs := windowLogs[next]; len(logs) > 0 {
-								for _, l:= range logs {
-								select {
-								case <-rpcCtx.Done():
+							// Let the user persist the window before the cursor moves, a callback failing every retry stops the chain
+							if chain.opts.OnCommit != nil {
+								if err := p.runOnCommit(rpcCtx, chain, next, end, windowLogs[next]); err != nil {
+									if rpcCtx.Err() != nil { return }
+									log.Println("Commit callback failed: ", err)
+									select { case errCh <- err: default: }
 									return
-								case logsCh <- l:
 								}
+							}
+
+							// Commit logs to the sink, or to log channel
+							if chain.opts.Sink != nil {
+								if err := p.storeLogs(rpcCtx, chain, windowLogs[next], target); err != nil {
+									if rpcCtx.Err() != nil { return }
+									log.Println("Error storing window logs: ", err)
+									select { case errCh <- err: default: }
+									return
 								}
+							} else if !emittedEarly[next] && !p.emitLogs(rpcCtx, logsCh, batchCh, chain, windowLogs[next], target) {
+								return
+							}
+							if !p.emitBlocks(rpcCtx, blocksCh, windowBlocks[next]) {
+								return
+							}
+							if chain.opts.AuditSampleRate > 0 {
+								from, logs := next, windowLogs[next]
+								audits.run(func() { p.auditWindow(rpcCtx, chain, from, end, logs) })
 							}
 							
 							delete(windowLogs, next)
+							delete(windowBlocks, next)
+							delete(emittedEarly, next)
+							fetchDuration := windowFetchDurations[next]
+							delete(windowFetchDurations, next)
+							if windowSlots != nil {
+								<-windowSlots
+							}
 							delete(window, next)	
-							chain.cursor = end
+							chain.stats.blocksProcessed.Add(end - next + 1)
+							chain.setCursor(end)
+							chain.consecutiveFailures.Store(0)
+							chain.mismatchCount = 0
+							chain.checkLag()
+							chain.checkCaughtUp(conf)
+							chain.logWindow(next, end, logCount, fetchDuration, time.Since(commitStart))
 							next = end + 1
 						}
 						
+						// Hashes inside the window first, the stored hashes stay in block order
+						if err := p.storeStrideHashes(rpcCtx, chain, windowStart, end); err != nil {
+							if rpcCtx.Err() != nil { return }
+							log.Println("Error getting window stride block: ", err)
+							select { case errCh <- err: default: }
+							return
+						}
+
 						// Get the end block blockhash after committing
-						err = rpc.RetryWithBackoff(ctx, *chain.opts.RetryConfig, func() error {
-							var err error
-							block, err = chain.chainInfo.RPC.GetBlock(rpcCtx, utils.Uint64ToHexQty(end))
-							return err
-						})
+						block, err = p.getCommitBlock(rpcCtx, chain, end)
 						if err != nil {
 							if rpcCtx.Err() != nil { return }        // batch was canceled; ignore
 							log.Println("Error getting window end block: ", err)
@@ -372,64 +1120,181 @@ outer:
 			case <-rpcCtx.Done():
 				<- done
 				<- arbiterDone
+				// The batch may have been canceled by a failure, e.g. a reorg deeper than MaxReorgDepth
+				select {
+				case err := <-errCh:
+					if err := p.recoverFromFailure(ctx, stop, chain, err); err != nil {
+						return err
+					}
+				default:
+				}
 				continue outer
 			case <-done:
 				<- arbiterDone
+				// The arbiter may have failed on the last windows
+				select {
+				case err := <-errCh:
+					if err := p.recoverFromFailure(ctx, stop, chain, err); err != nil {
+						return err
+					}
+				default:
+				}
 				continue outer
 			case err := <-errCh:
 				log.Println("Error received cancelling context")
 				rpcCancel()
 				<-done
 				<- arbiterDone
-				return err
+				if err := p.recoverFromFailure(ctx, stop, chain, err); err != nil {
+					return err
+				}
+				continue outer
 			case <- ctx.Done():
 				rpcCancel()
 				<- done
 				<- arbiterDone
-				return nil
+				return ErrChainCanceled
 			}
 
 		}
 	}
 }
 
-// During ancestor lookup we start from the cursor window and get to the window head and compare to the previous window
-func (p *Processor) handleReorg(ctx context.Context, chain *chainState) uint64 {
+// cursorReorged fetches the cursor block again and compares its hash to the stored one.
+// The cache is bypassed since the point is to see what the provider has now.
+func (p *Processor) cursorReorged(ctx context.Context, chain *chainState) (bool, error) {
+	stored, ok := chain.storedWindowHash[chain.cursor]
+	if !ok {
+		return false, nil
+	}
+
+	block, err := chain.chainInfo.RPC.GetBlock(ctx, utils.Uint64ToHexQty(chain.cursor))
+	if chain.recordError("eth_getBlockByNumber", chain.cursor, chain.cursor, chain.stats.recordRPC(err)) != nil {
+		return false, err
+	}
+
+	return block.Hash != stored, nil
+}
+
+// completeChain closes the output channels of a chain that reached EndBlock,
+// so consumers ranging over them stop once the buffered logs are read
+func (p *Processor) completeChain(logsCh chan types.Log, batchCh chan []types.Log, blocksCh chan types.Block, chain *chainState) {
+	if chain.completed {
+		return
+	}
+	chain.completed = true
+
+	chain.fanout.close()
+	close(logsCh)
+	if batchCh != nil {
+		close(batchCh)
+	}
+	if blocksCh != nil {
+		close(blocksCh)
+	}
+}
+
+// Helper function to get the current head of the chain with retry
+func (p *Processor) fetchHead(ctx context.Context, chain *chainState) (uint64, error) {
+	var head uint64
+	err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+		headHex, err := chain.chainInfo.RPC.Head(ctx)
+		if chain.recordError("eth_blockNumber", 0, 0, chain.stats.recordRPC(err)) != nil {
+			return err
+		}
+		head, err = utils.HexQtyToUint64(headHex)
+		if err != nil {
+			// A buggy provider may answer an empty or malformed head, ask again
+			return &errors.ConsistencyError{Message: fmt.Sprintf("malformed head %q", headHex)}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return head, nil
+}
+
+// checkInitialGap fails when the chain would start more than MaxInitialGap blocks below its head, or below EndBlock if it is lower
+func (p *Processor) checkInitialGap(ctx context.Context, chain *chainState) error {
+	head, err := p.fetchHead(ctx, chain)
+	if err != nil {
+		return fmt.Errorf("error getting head of chain %s: %w", chain.chainInfo.ChainId, err)
+	}
+
+	end := head
+	if chain.opts.EndBlock > 0 && chain.opts.EndBlock < end {
+		end = chain.opts.EndBlock
+	}
+	cursor := chain.cursor
+	if end > cursor && end-cursor > chain.opts.MaxInitialGap {
+		return fmt.Errorf("chain %s would backfill %d blocks from block %d to %d, more than MaxInitialGap %d: "+
+			"check StartBlock or set AllowLargeBackfill", chain.chainInfo.ChainId, end-cursor, cursor, end, chain.opts.MaxInitialGap)
+	}
+	return nil
+}
+
+// During ancestor lookup we start from the cursor window and get to the window head and compare to the previous window.
+// Without MaxReorgDepth, an ancestor that can't be found falls back hardFallbackBlocks below the cursor.
+// With it, a reorg deeper than MaxReorgDepth returns a ReorgError instead and the cursor is left untouched.
+func (p *Processor) handleReorg(ctx context.Context, chain *chainState) (uint64, error) {
+	// We don't know how deep the reorg is yet, every cached block may be stale
+	chain.blockCache.invalidateFrom(0)
+
+	maxDepth := chain.opts.MaxReorgDepth
+	fallback := chain.cursor; if fallback > chain.hardFallbackBlocks { fallback -= chain.hardFallbackBlocks } else { fallback = 0 }
+	// Don't rewind blindly when the depth is bounded, the next commit detects the reorg again
+	if maxDepth > 0 {
+		fallback = chain.cursor
+	}
+
 	ancestor := chain.cursor
 	for i := uint64(0); i < chain.storedWindowHashCap; i++ {
+		if maxDepth > 0 && chain.cursor-ancestor > maxDepth {
+			break
+		}
 
-		fallback := chain.cursor; if fallback > chain.hardFallbackBlocks { fallback -= chain.hardFallbackBlocks } else { fallback = 0 }
-
-		windowHeadBlock, err := chain.chainInfo.RPC.GetBlock(ctx, utils.Uint64ToHexQty(ancestor + 1))
+		windowHeadBlock, err := p.getBlock(ctx, chain, ancestor + 1)
 		if err != nil {
-			return fallback
+			return fallback, nil
 		}
 		
 		if windowHeadBlock.ParentHash == chain.storedWindowHash[ancestor] {
 			p.dropWindowHash(ancestor, chain)
 			log.Println("Found ancestor: ", ancestor)
-			return ancestor
+			return ancestor, nil
 		}
 		
-		if ancestor < uint64(chain.opts.RangeSize) {
-			ancestor = 0
-			break
+		// With WindowHashStride or AlignRangesTo the stored hashes aren't RangeSize apart, step to the previous one
+		if chain.opts.WindowHashStride > 0 || chain.opts.AlignRangesTo > 0 {
+			previous, ok := chain.previousHashedBlock(ancestor)
+			if !ok {
+				ancestor = 0
+				break
+			}
+			ancestor = previous
+		} else {
+			if ancestor < uint64(chain.opts.RangeSize) {
+				ancestor = 0
+				break
+			}
+			ancestor -= uint64(chain.opts.RangeSize)
 		}
-		ancestor -= uint64(chain.opts.RangeSize)
 
 		select{
 		case<- ctx.Done():
-			return fallback
+			return fallback, nil
 		default:
 		}
 	}
-	fallback := chain.cursor; if fallback > chain.hardFallbackBlocks { fallback -= chain.hardFallbackBlocks } else { fallback = 0 }
-	log.Println("Hard fallback triggered...")
-	if fallback <= 0 {
-		fallback = 0
+	if maxDepth > 0 {
+		log.Printf("Common ancestor not found within %d blocks, halting for manual intervention...\n", maxDepth)
+		return chain.cursor, &errors.ReorgError{Cursor: chain.cursor, MaxDepth: maxDepth}
 	}
+	log.Println("Hard fallback triggered...")
 	p.dropWindowHash(fallback, chain)
-	return fallback
+	return fallback, nil
 }
 
 func (p *Processor) storeWindowHash(to uint64, blockHash string, chain *chainState) {
@@ -460,32 +1325,173 @@ func (p *Processor) dropWindowHash(after uint64, chain *chainState) {
 		chain.windowOrder = chain.windowOrder[:i+1]
 }
 
+// Helper function to fetch the logs of [from..to] with the configured fetch mode.
+// Errors are wrapped in an errors.FilterError with the failing filter.
+func(p *Processor) fetchRange(ctx context.Context, from uint64, to uint64, chain *chainState) ([]types.Log, error) {
+	return p.fetchRangeWith(ctx, from, to, chain.opts.FetchMode, chain)
+}
+
+// fetchRangeWith fetches the logs of [from..to] with the given fetch mode
+func(p *Processor) fetchRangeWith(ctx context.Context, from uint64, to uint64, mode FetchMode, chain *chainState) ([]types.Log, error) {
+	filter := types.Filter{
+		FromBlock: utils.Uint64ToHexQty(from),
+		ToBlock: utils.Uint64ToHexQty(to),
+		Address: chain.filterAddresses,
+	}
+	// The topics are alternatives of the event signature, only OR them when there are several
+	if len(chain.topics) > 1 {
+		filter.Topic0 = chain.topics
+	} else {
+		filter.Topics = chain.topics
+	}
+	filter.IndexedTopics = chain.indexedTopics
+
+	var logs []types.Log
+	var err error
+	switch mode {
+	case FetchModeReceipts:
+		logs, err = p.fetchLogsFromReceipts(ctx, from, to, chain)
+	case FetchModeTxReceipts:
+		logs, err = p.fetchLogsFromTxReceipts(ctx, from, to, chain)
+	default:
+		if limit := chain.opts.MaxAddressesPerFilter; limit > 0 && len(filter.Address) > limit {
+			logs, err = p.getLogsSharded(ctx, filter, limit, chain)
+		} else {
+			logs, err = chain.chainInfo.RPC.GetLogs(ctx, filter)
+			chain.recordError("eth_getLogs", from, to, chain.stats.recordRPC(err))
+		}
+	}
+	if err != nil {
+		return nil, &errors.FilterError{Filter: filter, Err: err}
+	}
+	// The filter matches every watched event on every contract, keep the pairs actually watched
+	if chain.contracts != nil {
+		logs = chain.contracts.filter(logs)
+	}
+	if chain.opts.SortWindowLogs {
+		sortLogs(logs)
+	}
+	chain.tagChainId(logs)
+	return logs, nil
+}
+
+// getLogsSharded splits the addresses of filter in subsets of at most limit addresses,
+// one eth_getLogs per subset, and merges the logs back in chain order without duplicates.
+func (p *Processor) getLogsSharded(ctx context.Context, filter types.Filter, limit int, chain *chainState) ([]types.Log, error) {
+	addresses := filter.Address
+	from, _ := utils.HexQtyToUint64(filter.FromBlock)
+	to, _ := utils.HexQtyToUint64(filter.ToBlock)
+	seen := make(map[string]struct{})
+	var merged []types.Log
+
+	for start := 0; start < len(addresses); start += limit {
+		end := start + limit
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		shard := filter
+		shard.Address = addresses[start:end]
+
+		logs, err := chain.chainInfo.RPC.GetLogs(ctx, shard)
+		if chain.recordError("eth_getLogs", from, to, chain.stats.recordRPC(err)) != nil {
+			return nil, fmt.Errorf("addresses %v: %w", shard.Address, err)
+		}
+		for _, l := range logs {
+			key := logKey(l)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, l)
+		}
+	}
+
+	sortLogs(merged)
+	return merged, nil
+}
+
+// sortLogs orders logs by block number, transaction index then log index
+func sortLogs(logs []types.Log) {
+	position := func(l types.Log) (uint64, uint64, uint64) {
+		blockNumber, _ := utils.HexQtyToUint64(l.BlockNumber)
+		txIndex, _ := utils.HexQtyToUint64(l.TransactionIndex)
+		logIndex, _ := utils.HexQtyToUint64(l.LogIndex)
+		return blockNumber, txIndex, logIndex
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		bi, ti, li := position(logs[i])
+		bj, tj, lj := position(logs[j])
+		if bi != bj {
+			return bi < bj
+		}
+		if ti != tj {
+			return ti < tj
+		}
+		return li < lj
+	})
+}
+
 // Helper function to get logs from receipts
 func(p *Processor) fetchLogsFromReceipts(ctx context.Context, from uint64, to uint64, chain *chainState) ([]types.Log, error){
 	var allLogs []types.Log
 	for blockNum := from; blockNum <= to; blockNum ++ {
 		s_blockNum := utils.Uint64ToHexQty(blockNum)
-		receipts, err := chain.chainInfo.RPC.GetBlockReceipts(ctx, s_blockNum)
+		receipts, err := p.getBlockReceipts(ctx, s_blockNum, chain)
+		chain.recordError("eth_getBlockReceipts", blockNum, blockNum, chain.stats.recordRPC(err))
 		if err != nil {
 			return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNum, err)
 		}
 
-		for _, receipt := range receipts {
-			for _, log := range receipt.Logs {
-				if p.matchesTopicFilter(log, chain) {
-                    allLogs = append(allLogs, log)
-                }
+		if chain.opts.ValidateReceipts {
+			if err := validateReceipts(receipts, blockNum); err != nil {
+				log.Println("Receipts validation failed: ", err)
+				chain.stats.receiptMismatches.Add(1)
+				return nil, err
 			}
 		}
+
+		allLogs = p.appendReceiptsLogs(allLogs, receipts, chain)
 	}
 	return allLogs, nil
 }
 
+// getBlockReceipts fetches the receipts of a block, with StreamReceipts they only keep the logs matching the chain filters
+func(p *Processor) getBlockReceipts(ctx context.Context, blockNumber string, chain *chainState) ([]types.Receipt, error) {
+	if !chain.opts.StreamReceipts {
+		return chain.chainInfo.RPC.GetBlockReceipts(ctx, blockNumber)
+	}
+	// Checked by AddChain
+	client := chain.chainInfo.RPC.(rpc.FilteredReceiptsRPC)
+	return client.GetBlockReceiptsFiltered(ctx, blockNumber, func(log types.Log) bool {
+		return p.matchesTopicFilter(log, chain) && p.matchesAddressFilter(log, chain) && (chain.contracts == nil || chain.contracts.matches(log))
+	})
+}
+
+// appendReceiptsLogs appends to logs the logs of receipts matching the filters of the chain, in receipts order
+func(p *Processor) appendReceiptsLogs(logs []types.Log, receipts []types.Receipt, chain *chainState) []types.Log {
+	for _, receipt := range receipts {
+		if chain.opts.BloomPrecheck && !p.bloomMayMatch(receipt.LogsBloom, chain) {
+			chain.stats.receiptsSkipped.Add(1)
+			continue
+		}
+		txType := receipt.TxType()
+		for _, log := range receipt.Logs {
+			if p.matchesTopicFilter(log, chain) && p.matchesAddressFilter(log, chain) && (chain.contracts == nil || chain.contracts.matches(log)) {
+				if chain.opts.TagTxType {
+					log.TxType = &txType
+				}
+				logs = append(logs, log)
+			}
+		}
+	}
+	return logs
+}
+
 // Checks if a log matches the configurated topic
 func(p *Processor) matchesTopicFilter(log types.Log, chain *chainState) bool {
 	// If there is no topic specified then its true by default
-	if len(chain.opts.Topics) == 0 {
-		return true
+	if len(chain.topics) == 0 {
+		return chain.matchesIndexedTopics(log.Topics)
 	}
 
 	// Check if log has enough topics
@@ -498,7 +1504,7 @@ func(p *Processor) matchesTopicFilter(log types.Log, chain *chainState) bool {
         if len(log.Topics) > 0 {
             logTopic := log.Topics[0]
 			if logTopic == filterTopic {
-				return true
+				return chain.matchesIndexedTopics(log.Topics)
 			}
         }
     }
@@ -506,7 +1512,42 @@ func(p *Processor) matchesTopicFilter(log types.Log, chain *chainState) bool {
     return false
 }
 
+// Checks if a logs bloom may contain a log matching the configurated topics and addresses.
+// False means that none of the logs behind the bloom match.
+func(p *Processor) bloomMayMatch(bloom string, chain *chainState) bool {
+	anyIn := func(values [][]byte) bool {
+		if len(values) == 0 {
+			return true
+		}
+		for _, v := range values {
+			if utils.BloomContains(bloom, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return anyIn(chain.bloomTopics) && anyIn(chain.bloomAddresses)
+}
 
+// hexToBytes decodes 0x prefixed hex strings, the invalid ones are skipped
+func hexToBytes(values []string) [][]byte {
+	var out [][]byte
+	for _, v := range values {
+		b, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(v), "0x"))
+		if err == nil {
+			out = append(out, b)
+		}
+	}
+	return out
+}
 
+// Checks if a log is emitted by one of the configurated addresses
+func(p *Processor) matchesAddressFilter(log types.Log, chain *chainState) bool {
+	// If there is no address specified then its true by default
+	if len(chain.addresses) == 0 {
+		return true
+	}
 
-
+	_, ok := chain.addresses[strings.ToLower(log.Address)]
+	return ok
+}
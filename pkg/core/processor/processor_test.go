@@ -2,16 +2,23 @@ package processor
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/processor/processortest"
 	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
 	"github.com/ryuux05/godex/pkg/core/utils"
 	"github.com/ryuux05/godex/pkg/core/types"
 	"github.com/stretchr/testify/assert"
@@ -50,10 +57,10 @@ func TestRunWithOneLog_Success(t *testing.T) {
 				"jsonrpc": "2.0",
 				"id":      1,
 				"result": map[string]any {
-					"Number": req.Params[0],
-					"Hash": req.Params[0],
-					"ParentHash": utils.Uint64ToHexQty(blockNum - 1), 
-					"Timestamp": fmt.Sprintf("%d",time.Now().Unix()),
+					"number": req.Params[0],
+					"hash": req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1), 
+					"timestamp": fmt.Sprintf("%d",time.Now().Unix()),
 				},
 			})
 
@@ -63,15 +70,15 @@ func TestRunWithOneLog_Success(t *testing.T) {
 				"id":      1,
 				"result": []map[string]any{
 					{
-						"Address":          "0xabc",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabc",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 				},
 			})
@@ -83,49 +90,49 @@ func TestRunWithOneLog_Success(t *testing.T) {
 				"result": []map[string]any{
 					// Receipt 1: Transaction with Transfer event log
 					{
-						"BlockHash":         "0xbh1",
-						"BlockNumber":       "0x1",
-						"ContractAddress":   nil,
-						"CumulativeGasUsed": "0x5208",
-						"EffectiveGasPrice": "0x3b9aca00",
-						"From":              "0xsender",
-						"GasUsed":           "0x5208",
-						"Logs": []map[string]any{
+						"blockHash":         "0xbh1",
+						"blockNumber":       "0x1",
+						"contractAddress":   nil,
+						"cumulativeGasUsed": "0x5208",
+						"effectiveGasPrice": "0x3b9aca00",
+						"from":              "0xsender",
+						"gasUsed":           "0x5208",
+						"logs": []map[string]any{
 							{
-								"Address":          "0xabc",
-								"Topics":           []any{"0xddf252ad"},
-								"Data":             "0x",
-								"BlockNumber":      "0x1",
-								"TransactionHash":  "0xth1",
-								"TransactionIndex": "0x0",
-								"BlockHash":        "0xbh1",
-								"LogIndex":         "0x0",
-								"Removed":          false,
+								"address":          "0xabc",
+								"topics":           []any{"0xddf252ad"},
+								"data":             "0x",
+								"blockNumber":      "0x1",
+								"transactionHash":  "0xth1",
+								"transactionIndex": "0x0",
+								"blockHash":        "0xbh1",
+								"logIndex":         "0x0",
+								"removed":          false,
 							},
 						},
-						"LogsBloom":        "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
-						"Status":           "0x1",
-						"To":               "0xabc",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0x0",
-						"Type":             "0x2",
+						"logsBloom":        "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+						"status":           "0x1",
+						"to":               "0xabc",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0x0",
+						"type":             "0x2",
 					},
 					// Receipt 2: Transaction with no logs
 					{
-						"BlockHash":         "0xbh1",
-						"BlockNumber":       "0x1",
-						"ContractAddress":   nil,
-						"CumulativeGasUsed": "0xa410",
-						"EffectiveGasPrice": "0x3b9aca00",
-						"From":              "0xsender2",
-						"GasUsed":           "0x5208",
-						"Logs":              []map[string]any{},
-						"LogsBloom":         "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
-						"Status":            "0x1",
-						"To":                "0xreceiver",
-						"TransactionHash":   "0xth2",
-						"TransactionIndex":  "0x1",
-						"Type":              "0x2",
+						"blockHash":         "0xbh1",
+						"blockNumber":       "0x1",
+						"contractAddress":   nil,
+						"cumulativeGasUsed": "0xa410",
+						"effectiveGasPrice": "0x3b9aca00",
+						"from":              "0xsender2",
+						"gasUsed":           "0x5208",
+						"logs":              []map[string]any{},
+						"logsBloom":         "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+						"status":            "0x1",
+						"to":                "0xreceiver",
+						"transactionHash":   "0xth2",
+						"transactionIndex":  "0x1",
+						"type":              "0x2",
 					},
 				},
 			})
@@ -215,10 +222,10 @@ func TestRunWithMultipleLog_Success(t *testing.T) {
 				"jsonrpc": "2.0",
 				"id":      1,
 				"result": map[string]any {
-					"Number": req.Params[0],
-					"Hash": req.Params[0],
-					"ParentHash": utils.Uint64ToHexQty(blockNum - 1), 
-					"Timestamp": fmt.Sprintf("%d",time.Now().Unix()),
+					"number": req.Params[0],
+					"hash": req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1), 
+					"timestamp": fmt.Sprintf("%d",time.Now().Unix()),
 				},
 			})
 
@@ -228,59 +235,59 @@ func TestRunWithMultipleLog_Success(t *testing.T) {
 				"id":      1,
 				"result": []map[string]any{
 					{
-						"Address":          "0xabc",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabc",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 					{
-						"Address":          "0xabcd",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabcd",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 					{
-						"Address":          "0xabcde",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabcde",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 					{
-						"Address":          "0xabcdef",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabcdef",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 					{
-						"Address":          "0xabcdefg",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabcdefg",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 				},
 			})
@@ -392,10 +399,10 @@ func TestReorg_Success(t *testing.T) {
 					"jsonrpc": "2.0",
 					"id":      1,
 					"result": map[string]any {
-						"Number": req.Params[0],
-						"Hash": req.Params[0],
-						"ParentHash": "somerandomshit", 
-						"Timestamp": fmt.Sprintf("%d",time.Now().Unix()),
+						"number": req.Params[0],
+						"hash": req.Params[0],
+						"parentHash": "somerandomshit", 
+						"timestamp": fmt.Sprintf("%d",time.Now().Unix()),
 					},
 				})
 			} else {
@@ -403,10 +410,10 @@ func TestReorg_Success(t *testing.T) {
 					"jsonrpc": "2.0",
 					"id":      1,
 					"result": map[string]any {
-						"Number": req.Params[0],
-						"Hash": req.Params[0],
-						"ParentHash": utils.Uint64ToHexQty(blockNum - 1), 
-						"Timestamp": fmt.Sprintf("%d",time.Now().Unix()),
+						"number": req.Params[0],
+						"hash": req.Params[0],
+						"parentHash": utils.Uint64ToHexQty(blockNum - 1), 
+						"timestamp": fmt.Sprintf("%d",time.Now().Unix()),
 					},
 				})
 			}
@@ -417,15 +424,15 @@ func TestReorg_Success(t *testing.T) {
 				"id":      1,
 				"result": []map[string]any{
 					{
-						"Address":          "0xabc",
-						"Topics": []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabc",
+						"topics": []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 				},
 			})
@@ -539,15 +546,15 @@ func TestRunWithRetry_Success(t *testing.T) {
 				"id":      1,
 				"result": []map[string]any{
 					{
-						"Address":          "0xabc",
-						"Topics":           []any{"0xddf252ad"},
-						"Data":             "0x",
-						"BlockNumber":      "0x1",
-						"TransactionHash":  "0xth1",
-						"TransactionIndex": "0",
-						"BlockHash":        "0xbh1",
-						"LogIndex":         "0x0",
-						"Removed":          false,
+						"address":          "0xabc",
+						"topics":           []any{"0xddf252ad"},
+						"data":             "0x",
+						"blockNumber":      "0x1",
+						"transactionHash":  "0xth1",
+						"transactionIndex": "0",
+						"blockHash":        "0xbh1",
+						"logIndex":         "0x0",
+						"removed":          false,
 					},
 				},
 			})
@@ -558,10 +565,10 @@ func TestRunWithRetry_Success(t *testing.T) {
 				"jsonrpc": "2.0",
 				"id":      1,
 				"result": map[string]any{
-					"Number":     req.Params[0],
-					"Hash":       req.Params[0],
-					"ParentHash": utils.Uint64ToHexQty(blockNum - 1),
-					"Timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+					"timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
 				},
 			})
 
@@ -682,15 +689,15 @@ func TestMultiChainRun_Success(t *testing.T) {
                 "id":      1,
                 "result": []map[string]any{
                     {
-                        "Address":          "0xeth",
-                        "Topics":           []any{"0xddf252ad"},
-                        "Data":             "0x",
-                        "BlockNumber":      "0x1",
-                        "TransactionHash":  "0xeth_tx",
-                        "TransactionIndex": "0x0",
-                        "BlockHash":        "0xeth_block",
-                        "LogIndex":         "0x0",
-                        "Removed":          false,
+                        "address":          "0xeth",
+                        "topics":           []any{"0xddf252ad"},
+                        "data":             "0x",
+                        "blockNumber":      "0x1",
+                        "transactionHash":  "0xeth_tx",
+                        "transactionIndex": "0x0",
+                        "blockHash":        "0xeth_block",
+                        "logIndex":         "0x0",
+                        "removed":          false,
                     },
                 },
             })
@@ -701,10 +708,10 @@ func TestMultiChainRun_Success(t *testing.T) {
                 "jsonrpc": "2.0",
                 "id":      1,
                 "result": map[string]any{
-                    "Number":     req.Params[0],
-                    "Hash":       req.Params[0],
-                    "ParentHash": utils.Uint64ToHexQty(blockNum - 1),
-                    "Timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+                    "number":     req.Params[0],
+                    "hash":       req.Params[0],
+                    "parentHash": utils.Uint64ToHexQty(blockNum - 1),
+                    "timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
                 },
             })
         }
@@ -847,15 +854,15 @@ func TestMultiChain_IndependentErrors(t *testing.T) {
                 "id":      1,
                 "result": []map[string]any{
                     {
-                        "Address":          "0xpoly",
-                        "Topics":           []any{"0xddf252ad"},
-                        "Data":             "0x",
-                        "BlockNumber":      "0x1",
-                        "TransactionHash":  "0xpoly_tx",
-                        "TransactionIndex": "0x0",
-                        "BlockHash":        "0xpoly_bh",
-                        "LogIndex":         "0x0",
-                        "Removed":          false,
+                        "address":          "0xpoly",
+                        "topics":           []any{"0xddf252ad"},
+                        "data":             "0x",
+                        "blockNumber":      "0x1",
+                        "transactionHash":  "0xpoly_tx",
+                        "transactionIndex": "0x0",
+                        "blockHash":        "0xpoly_bh",
+                        "logIndex":         "0x0",
+                        "removed":          false,
                     },
                 },
             })
@@ -866,10 +873,10 @@ func TestMultiChain_IndependentErrors(t *testing.T) {
                 "jsonrpc": "2.0",
                 "id":      1,
                 "result": map[string]any{
-                    "Number":     req.Params[0],
-                    "Hash":       req.Params[0],
-                    "ParentHash": utils.Uint64ToHexQty(blockNum - 1),
-                    "Timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+                    "number":     req.Params[0],
+                    "hash":       req.Params[0],
+                    "parentHash": utils.Uint64ToHexQty(blockNum - 1),
+                    "timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
                 },
             })
             
@@ -992,15 +999,15 @@ func TestMultiChain_BothChainsSucceed(t *testing.T) {
                     "id":      1,
                     "result": []map[string]any{
                         {
-                            "Address":          fmt.Sprintf("0x%s", chainName),
-                            "Topics":           []any{"0xddf252ad"},
-                            "Data":             "0x",
-                            "BlockNumber":      "0x1",
-                            "TransactionHash":  fmt.Sprintf("0x%s_tx", chainName),
-                            "TransactionIndex": "0x0",
-                            "BlockHash":        fmt.Sprintf("0x%s_bh", chainName),
-                            "LogIndex":         "0x0",
-                            "Removed":          false,
+                            "address":          fmt.Sprintf("0x%s", chainName),
+                            "topics":           []any{"0xddf252ad"},
+                            "data":             "0x",
+                            "blockNumber":      "0x1",
+                            "transactionHash":  fmt.Sprintf("0x%s_tx", chainName),
+                            "transactionIndex": "0x0",
+                            "blockHash":        fmt.Sprintf("0x%s_bh", chainName),
+                            "logIndex":         "0x0",
+                            "removed":          false,
                         },
                     },
                 })
@@ -1011,10 +1018,10 @@ func TestMultiChain_BothChainsSucceed(t *testing.T) {
                     "jsonrpc": "2.0",
                     "id":      1,
                     "result": map[string]any{
-                        "Number":     req.Params[0],
-                        "Hash":       req.Params[0],
-                        "ParentHash": utils.Uint64ToHexQty(blockNum - 1),
-                        "Timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+                        "number":     req.Params[0],
+                        "hash":       req.Params[0],
+                        "parentHash": utils.Uint64ToHexQty(blockNum - 1),
+                        "timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
                     },
                 })
             }
@@ -1050,51 +1057,112 @@ func TestMultiChain_BothChainsSucceed(t *testing.T) {
     ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
     defer cancel()
     
-    // Collect logs from both chains
     ethCh, _ := processor.Logs("1")
     polyCh, _ := processor.Logs("137")
-    
-    ethLogs := []types.Log{}
-    polyLogs := []types.Log{}
-    var mu sync.Mutex
-    
-    go func() {
-        for log := range ethCh {
-            mu.Lock()
-            ethLogs = append(ethLogs, log)
-            mu.Unlock()
-        }
-    }()
-    
+
+    runErr := make(chan error, 1)
+    go func() { runErr <- processor.Run(ctx) }()
+
+    // Both chains should have logs, the chains are read concurrently so neither blocks the other
+    var ethLogs []types.Log
+    var ethErr error
+    collected := make(chan struct{})
     go func() {
-        for log := range polyCh {
-            mu.Lock()
-            polyLogs = append(polyLogs, log)
-            mu.Unlock()
-        }
+        defer close(collected)
+        ethLogs, ethErr = processortest.CollectLogs(ctx, ethCh, 1)
     }()
-    
-    go processor.Run(ctx)
-    
-    // Wait for processing
-    time.Sleep(500 * time.Millisecond)
+    polyLogs, polyErr := processortest.CollectLogs(ctx, polyCh, 1)
+    <-collected
     cancel()
-    time.Sleep(100 * time.Millisecond)
-    
-    // Both chains should have logs
-    mu.Lock()
-    assert.GreaterOrEqual(t, len(ethLogs), 1, "Ethereum should have logs")
-    assert.GreaterOrEqual(t, len(polyLogs), 1, "Polygon should have logs")
-    
+    assert.NoError(t, <-runErr)
+
     // Verify logs are from correct chains
-    if len(ethLogs) > 0 {
+    assert.NoError(t, ethErr)
+    assert.NoError(t, polyErr)
+    if assert.Len(t, ethLogs, 1) {
         assert.Contains(t, ethLogs[0].Address, "eth")
     }
-    if len(polyLogs) > 0 {
+    if assert.Len(t, polyLogs, 1) {
         assert.Contains(t, polyLogs[0].Address, "poly")
     }
-    mu.Unlock()
 }
+func TestMultiChain_TagChainId(t *testing.T) {
+	// One log at block 1 per chain, from the same contract and transaction on both
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x2"
+		case "eth_getLogs":
+			logs := []map[string]any{}
+			if req.Params[0].(map[string]any)["fromBlock"] == "0x1" {
+				logs = append(logs, map[string]any{
+					"address":         "0xtoken",
+					"topics":          []any{"0xddf252ad"},
+					"blockNumber":     "0x1",
+					"transactionHash": "0xtx",
+					"blockHash":       "0x1",
+					"logIndex":        "0x0",
+				})
+			}
+			result = logs
+		case "eth_getBlockByNumber":
+			blockNum, _ := utils.HexQtyToUint64(fmt.Sprintf("%s", req.Params[0]))
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	for _, chainId := range []string{"1", "137"} {
+		err := processor.AddChain(ChainInfo{ChainId: chainId, RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+			RangeSize:      1,
+			EndBlock:       2,
+			LogsBufferSize: 10,
+			Topics:         []string{"0xddf252ad"},
+			TagChainId:     true,
+		})
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A single consumer fed by both chains
+	merged := make(chan types.Log, 10)
+	for _, chainId := range []string{"1", "137"} {
+		logsCh, err := processor.Logs(chainId)
+		assert.NoError(t, err)
+		go func() {
+			for log := range logsCh {
+				merged <- log
+			}
+		}()
+	}
+	assert.NoError(t, processor.Run(ctx))
+
+	logs, err := processortest.CollectLogs(ctx, merged, 2)
+	assert.NoError(t, err)
+	chainIds := make([]string, 0, len(logs))
+	for _, log := range logs {
+		chainIds = append(chainIds, log.ChainId)
+	}
+	assert.ElementsMatch(t, []string{"1", "137"}, chainIds)
+}
+
 func TestMultiChain_AddChainWhileRunning(t *testing.T) {
     processor := NewProcessor()
     
@@ -1122,3 +1190,2614 @@ func TestMultiChain_AddChainWhileRunning(t *testing.T) {
     assert.Contains(t, err.Error(), "running")
 }
 
+
+func TestFetchLogsFromReceipts_AddressFilter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				{
+					"blockHash":   "0xbh1",
+					"blockNumber": "0x1",
+					"logs": []map[string]any{
+						{
+							"address":     "0xAbC0000000000000000000000000000000000001",
+							"topics":      []any{"0xddf252ad"},
+							"data":        "0x",
+							"blockNumber": "0x1",
+							"logIndex":    "0x0",
+						},
+						{
+							"address":     "0xdef0000000000000000000000000000000000002",
+							"topics":      []any{"0xddf252ad"},
+							"data":        "0x",
+							"blockNumber": "0x1",
+							"logIndex":    "0x1",
+						},
+					},
+					"status":           "0x1",
+					"transactionHash":  "0xth1",
+					"transactionIndex": "0x0",
+					"type":             "0x2",
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize: 10,
+		FetchMode: FetchModeReceipts,
+		Addresses: []string{"0xabc0000000000000000000000000000000000001"},
+	}
+	chain := ChainInfo{
+		ChainId: "1",
+		RPC:     rpc.NewHTTPRPC(srv.URL, 0),
+	}
+
+	processor := NewProcessor()
+	err := processor.AddChain(chain, &opts)
+	assert.NoError(t, err)
+
+	logs, err := processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "0xAbC0000000000000000000000000000000000001", logs[0].Address)
+}
+
+func TestFetchLogsFromReceipts_TagTxType(t *testing.T) {
+	receipt := func(txHash string, txType string) map[string]any {
+		return map[string]any{
+			"blockHash":   "0xbh1",
+			"blockNumber": "0x1",
+			"logs": []map[string]any{
+				{
+					"address":         "0xabc",
+					"topics":          []any{"0xddf252ad"},
+					"data":            "0x",
+					"blockNumber":     "0x1",
+					"transactionHash": txHash,
+				},
+			},
+			"status":          "0x1",
+			"transactionHash": txHash,
+			"type":            txType,
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				receipt("0xlegacy", "0x0"),
+				receipt("0xaccesslist", "0x1"),
+				receipt("0xdynamicfee", "0x2"),
+				receipt("0xblob", "0x3"),
+			},
+		})
+	}))
+	defer srv.Close()
+
+	chain := ChainInfo{
+		ChainId: "1",
+		RPC:     rpc.NewHTTPRPC(srv.URL, 0),
+	}
+
+	// Tagging disabled by default
+	processor := NewProcessor()
+	processor.AddChain(chain, &Options{RangeSize: 10, FetchMode: FetchModeReceipts})
+	logs, err := processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	assert.Len(t, logs, 4)
+	for _, l := range logs {
+		assert.Nil(t, l.TxType)
+	}
+
+	processor = NewProcessor()
+	processor.AddChain(chain, &Options{RangeSize: 10, FetchMode: FetchModeReceipts, TagTxType: true})
+	logs, err = processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	assert.Len(t, logs, 4)
+
+	expected := []uint8{types.TxTypeLegacy, types.TxTypeAccessList, types.TxTypeDynamicFee, types.TxTypeBlob}
+	for i, l := range logs {
+		assert.NotNil(t, l.TxType)
+		assert.Equal(t, expected[i], *l.TxType)
+	}
+}
+
+func TestFetchLogsFromReceipts_StreamReceipts(t *testing.T) {
+	const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	receipt := func(txHash string, addresses ...string) map[string]any {
+		logs := make([]map[string]any, 0, len(addresses))
+		for i, address := range addresses {
+			logs = append(logs, map[string]any{
+				"address":         address,
+				"topics":          []any{transferTopic},
+				"blockNumber":     "0x1",
+				"transactionHash": txHash,
+				"logIndex":        utils.Uint64ToHexQty(uint64(i)),
+			})
+		}
+		return map[string]any{"blockNumber": "0x1", "transactionHash": txHash, "status": "0x1", "logs": logs}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				receipt("0xtx1", "0xtoken", "0xother"),
+				receipt("0xtx2", "0xother"),
+				receipt("0xtx3", "0xToken"),
+			},
+		})
+	}))
+	defer srv.Close()
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	// Both paths return the same logs
+	var results [][]types.Log
+	for _, stream := range []bool{false, true} {
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(chain, &Options{RangeSize: 10, FetchMode: FetchModeReceipts, Addresses: []string{"0xtoken"}, StreamReceipts: stream}))
+		logs, err := processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+		assert.NoError(t, err)
+		results = append(results, logs)
+	}
+	assert.Len(t, results[1], 2)
+	assert.Equal(t, results[0], results[1])
+
+	// The RPC must support it
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: &rpc.QuorumRPC{}}, &Options{RangeSize: 10, FetchMode: FetchModeReceipts, StreamReceipts: true})
+	assert.Error(t, err)
+}
+
+func TestFetchLogsFromReceipts_IndexedTopics(t *testing.T) {
+	const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	const zero = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	const holder = "0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60"
+	transfer := func(logIndex uint64, from string) map[string]any {
+		return map[string]any{
+			"address":         "0xtoken",
+			"topics":          []any{transferTopic, from, holder},
+			"blockNumber":     "0x1",
+			"transactionHash": "0xtx1",
+			"logIndex":        utils.Uint64ToHexQty(logIndex),
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{{
+				"blockNumber":     "0x1",
+				"transactionHash": "0xtx1",
+				"status":          "0x1",
+				"logs": []map[string]any{
+					transfer(0, zero),
+					transfer(1, holder),
+					// Not a Transfer, too few topics for the position
+					{"address": "0xtoken", "topics": []any{transferTopic}, "blockNumber": "0x1", "transactionHash": "0xtx1", "logIndex": "0x2"},
+				},
+			}},
+		})
+	}))
+	defer srv.Close()
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	// Only the mints, from the zero address, pass in both receipts paths
+	for _, stream := range []bool{false, true} {
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(chain, &Options{
+			RangeSize:      10,
+			FetchMode:      FetchModeReceipts,
+			Topics:         []string{transferTopic},
+			IndexedTopics:  [][]string{{zero}},
+			StreamReceipts: stream,
+		}))
+		logs, err := processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+		assert.NoError(t, err)
+		if assert.Len(t, logs, 1) {
+			assert.Equal(t, zero, logs[0].Topics[1])
+		}
+	}
+
+	// Values must be 32 bytes topics
+	processor := NewProcessor()
+	err := processor.AddChain(chain, &Options{RangeSize: 10, IndexedTopics: [][]string{{"0x0"}}})
+	assert.Error(t, err)
+}
+
+func TestAddChain_StartFrom(t *testing.T) {
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		startFrom StartFrom
+		cursor    uint64
+	}{
+		{"default genesis", Options{RangeSize: 10}, StartFromGenesis, 0},
+		{"default block", Options{RangeSize: 10, StartBlock: 500}, StartFromBlock, 500},
+		{"explicit genesis", Options{RangeSize: 10, StartFrom: StartFromGenesis, StartBlock: 500}, StartFromGenesis, 0},
+		{"explicit block", Options{RangeSize: 10, StartFrom: StartFromBlock, StartBlock: 500}, StartFromBlock, 500},
+		{"head", Options{RangeSize: 10, StartFrom: StartFromHead}, StartFromHead, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewProcessor()
+			err := processor.AddChain(chain, &tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.startFrom, tt.opts.StartFrom)
+			assert.Equal(t, tt.cursor, processor.chains[chain.ChainId].cursor)
+		})
+	}
+
+	processor := NewProcessor()
+	err := processor.AddChain(chain, &Options{RangeSize: 10, StartFrom: "tomorrow"})
+	assert.Error(t, err)
+}
+
+func TestRun_StartFromHead(t *testing.T) {
+	var mu sync.Mutex
+	getLogsCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  "0x64",
+			})
+		case "eth_getLogs":
+			mu.Lock()
+			getLogsCalls++
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  []map[string]any{},
+			})
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize: 10,
+		StartFrom: StartFromHead,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	processor.AddChain(chain, &opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	// The head doesn't move so there is nothing new to index
+	assert.Equal(t, uint64(100), processor.chains[chain.ChainId].cursor)
+	mu.Lock()
+	assert.Equal(t, 0, getLogsCalls)
+	mu.Unlock()
+}
+
+func TestIndexBlocks_NonContiguous(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method != "eth_getLogs" {
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+
+		var filter types.Filter
+		_ = json.Unmarshal(req.Params[0], &filter)
+		assert.Equal(t, filter.FromBlock, filter.ToBlock)
+		assert.Equal(t, []string{"0xabc"}, filter.Address)
+
+		mu.Lock()
+		requested = append(requested, filter.FromBlock)
+		mu.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				{
+					"address":     "0xabc",
+					"topics":      []any{"0xddf252ad"},
+					"data":        "0x",
+					"blockNumber": filter.FromBlock,
+					"logIndex":    "0x0",
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize: 10,
+		Addresses: []string{"0xabc"},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	processor.AddChain(chain, &opts)
+
+	logs, err := processor.IndexBlocks(context.Background(), chain.ChainId, []uint64{42, 3, 7, 3})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "0x2a", logs[0].BlockNumber)
+	assert.Equal(t, "0x3", logs[1].BlockNumber)
+	assert.Equal(t, "0x7", logs[2].BlockNumber)
+
+	mu.Lock()
+	assert.Equal(t, []string{"0x2a", "0x3", "0x7"}, requested)
+	mu.Unlock()
+
+	_, err = processor.IndexBlocks(context.Background(), "unknown", []uint64{1})
+	assert.Error(t, err)
+}
+
+func TestReorgCheckInterval_DetectsCursorReorg(t *testing.T) {
+	var mu sync.Mutex
+	forked := false
+	slowed := false
+	getLogsFrom := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  "0x64",
+			})
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+
+			// Blocks from 50 are replaced once block 50 was stored by the first commit
+			mu.Lock()
+			onFork := forked && blockNum >= 50
+			if blockNum == 50 {
+				forked = true
+			}
+			mu.Unlock()
+
+			hash := utils.Uint64ToHexQty(blockNum)
+			parentHash := utils.Uint64ToHexQty(blockNum - 1)
+			if onFork {
+				hash = fmt.Sprintf("0xf%d", blockNum)
+				if blockNum > 50 {
+					parentHash = fmt.Sprintf("0xf%d", blockNum-1)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result": map[string]any{
+					"number":     req.Params[0],
+					"hash":       hash,
+					"parentHash": parentHash,
+				},
+			})
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			mu.Lock()
+			getLogsFrom[from]++
+			// Hold the window after block 50 until the request is canceled,
+			// the reorg can only be caught by the interval check
+			slow := from == utils.Uint64ToHexQty(51) && !slowed
+			if slow {
+				slowed = true
+			}
+			mu.Unlock()
+			if slow {
+				<-r.Context().Done()
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  []map[string]any{},
+			})
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	// Virtual time, the check fires although an hour never passes during the test
+	clock := rpc.NewFakeClock(time.Now())
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 1,
+		ReorgCheckInterval: time.Hour,
+		Clock:              clock,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	assert.Equal(t, uint64(1), processor.Stats().Total.Reorgs)
+	mu.Lock()
+	defer mu.Unlock()
+	// Block 40 is the last common ancestor so the window starting at 41 is indexed again
+	assert.Equal(t, 2, getLogsFrom[utils.Uint64ToHexQty(41)])
+	assert.Equal(t, 1, getLogsFrom[utils.Uint64ToHexQty(31)])
+	assert.Equal(t, uint64(100), processor.chains[chain.ChainId].cursor)
+	assert.Contains(t, clock.Waits(), time.Hour)
+}
+
+func TestRun_RemovedLogs(t *testing.T) {
+	logs := []map[string]any{
+		{
+			"address":         "0xabc",
+			"topics":          []any{"0xddf252ad"},
+			"data":            "0x",
+			"blockNumber":     "0x1",
+			"blockHash":       "0xbh1",
+			"transactionHash": "0xth1",
+			"logIndex":        "0x0",
+		},
+		{
+			"address":         "0xabc",
+			"topics":          []any{"0xddf252ad"},
+			"data":            "0x",
+			"blockNumber":     "0x1",
+			"blockHash":       "0xoldbh1",
+			"transactionHash": "0xth1",
+			"logIndex":        "0x1",
+			"removed":         true,
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x1"
+		case "eth_getBlockByNumber":
+			result = map[string]any{"number": req.Params[0], "hash": "0xbh1", "parentHash": "0xbh0"}
+		case "eth_getLogs":
+			result = logs
+		case "eth_getBlockReceipts":
+			result = []map[string]any{
+				{
+					"blockHash":        "0xbh1",
+					"blockNumber":      "0x1",
+					"logs":             logs,
+					"status":           "0x1",
+					"transactionHash":  "0xth1",
+					"transactionIndex": "0x0",
+				},
+			}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	for _, mode := range []FetchMode{FetchModeLogs, FetchModeReceipts} {
+		t.Run(string(mode), func(t *testing.T) {
+			opts := Options{
+				RangeSize:        10,
+				LogsBufferSize:   16,
+				FetchMode:        mode,
+				ValidateReceipts: true,
+			}
+			chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+			processor := NewProcessor()
+			assert.NoError(t, processor.AddChain(chain, &opts))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			_ = processor.Run(ctx)
+
+			logsCh, err := processor.Logs(chain.ChainId)
+			assert.NoError(t, err)
+			if !assert.Len(t, logsCh, 2) {
+				return
+			}
+
+			emitted := <-logsCh
+			assert.False(t, emitted.Removed)
+			removed := <-logsCh
+			assert.True(t, removed.Removed)
+			assert.Equal(t, "0xoldbh1", removed.BlockHash)
+
+			stats := processor.Stats().Total
+			assert.Equal(t, uint64(2), stats.LogsEmitted)
+			assert.Equal(t, uint64(1), stats.LogsRemoved)
+			assert.Equal(t, uint64(0), stats.ReceiptMismatches)
+		})
+	}
+}
+
+func TestOnCommit_MonotonicAndRetriedOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x32"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var commits []uint64
+	failed := false
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 4,
+		LogsBufferSize:     1024,
+		WindowRetries:      1,
+		Clock:              rpc.NewFakeClock(time.Now()),
+		OnCommit: func(chainId string, toBlock uint64, logs []types.Log) error {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, "1", chainId)
+			assert.Len(t, logs, 1)
+			// Fail the window ending at 20 once
+			if toBlock == 20 && !failed {
+				failed = true
+				return fmt.Errorf("store unavailable")
+			}
+			commits = append(commits, toBlock)
+			return nil
+		},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, failed)
+	assert.Equal(t, []uint64{10, 20, 30, 40, 50}, commits)
+
+	// The failed commit didn't emit its logs
+	logsCh, err := processor.Logs(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Len(t, logsCh, 5)
+	assert.Equal(t, uint64(50), processor.chains[chain.ChainId].cursor)
+	assert.Len(t, processor.RecentErrors(chain.ChainId), 1)
+}
+
+func TestOnCommit_StopsChainAfterWindowRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x32"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	var calls atomic.Int32
+	clock := rpc.NewFakeClock(time.Now())
+	opts := Options{
+		RangeSize:      10,
+		LogsBufferSize: 16,
+		WindowRetries:  2,
+		Clock:          clock,
+		OnCommit: func(chainId string, toBlock uint64, logs []types.Log) error {
+			calls.Add(1)
+			return fmt.Errorf("store unavailable")
+		},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := processor.Run(ctx)
+
+	assert.ErrorContains(t, err, "store unavailable")
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+	// The first call and its 2 retries, the cursor didn't move
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, uint64(0), processor.chains[chain.ChainId].cursor)
+	assert.Len(t, processor.RecentErrors(chain.ChainId), 3)
+	assert.Len(t, clock.Waits(), 2)
+}
+
+func TestBlocks_EmitsBlocksWithoutLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x19"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			// No log matches the topics
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 3,
+		LogsBufferSize:     64,
+		EmitBlocks:         true,
+		BlockCacheSize:     64,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	blocksCh, err := processor.Blocks(chain.ChainId)
+	assert.NoError(t, err)
+	if !assert.Len(t, blocksCh, 25) {
+		return
+	}
+	for i := uint64(1); i <= 25; i++ {
+		block := <-blocksCh
+		assert.Equal(t, utils.Uint64ToHexQty(i), block.Number)
+	}
+
+	logsCh, err := processor.Logs(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Len(t, logsCh, 0)
+
+	_, err = NewProcessor().Blocks("1")
+	assert.Error(t, err)
+	other := NewProcessor()
+	assert.NoError(t, other.AddChain(chain, &Options{RangeSize: 10}))
+	_, err = other.Blocks("1")
+	assert.Error(t, err)
+}
+
+func TestWatermark_AdvancesWithoutLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var commits []uint64
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 4,
+		Confimation:        5,
+		Topics:             []string{"Transfer(address,address,uint256)"},
+		OnCommit: func(chainId string, toBlock uint64, logs []types.Log) error {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Empty(t, logs)
+			commits = append(commits, toBlock)
+			return nil
+		},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	watermark, err := processor.Watermark(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), watermark)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	// Target is head minus the confirmations
+	watermark, err = processor.Watermark(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(95), watermark)
+	assert.Equal(t, uint64(95), processor.Stats().Total.BlocksProcessed)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []uint64{10, 20, 30, 40, 50, 60, 70, 80, 90, 95}, commits)
+
+	_, err = processor.Watermark("unknown")
+	assert.Error(t, err)
+}
+
+func TestMaxBufferedWindows_Bounded(t *testing.T) {
+	var mu sync.Mutex
+	release := make(chan struct{})
+	requestedWhileBlocked := 0
+	blocked := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			mu.Lock()
+			if blocked {
+				requestedWhileBlocked++
+			}
+			mu.Unlock()
+			// Hold the first window so the others can only pile up behind it
+			if from == "0x1" {
+				<-release
+			}
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 8,
+		MaxBufferedWindows: 3,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		mu.Lock()
+		blocked = false
+		mu.Unlock()
+		close(release)
+	}()
+	_ = processor.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The first window and the 2 after it, the planner waits for a commit before the 4th
+	assert.Equal(t, 3, requestedWhileBlocked)
+	assert.Equal(t, uint64(100), processor.chains[chain.ChainId].cursor)
+}
+
+func TestFetchRange_ShardsAddresses(t *testing.T) {
+	var mu sync.Mutex
+	var filters [][]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string           `json:"method"`
+			Params []map[string]any `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		addresses := req.Params[0]["address"].([]any)
+		mu.Lock()
+		filters = append(filters, addresses)
+		mu.Unlock()
+
+		// One log per address, plus a log every shard returns
+		logs := []map[string]any{
+			{"address": "0xshared", "blockNumber": "0x1", "blockHash": "0xbh1", "transactionHash": "0xth", "logIndex": "0x0"},
+		}
+		for i, address := range addresses {
+			logs = append(logs, map[string]any{
+				"address":         address,
+				"blockNumber":     "0x2",
+				"blockHash":       "0xbh2",
+				"transactionHash": "0xth" + address.(string),
+				"logIndex":        fmt.Sprintf("0x%x", len(filters)*10+i),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": logs})
+	}))
+	defer srv.Close()
+
+	addresses := []string{"0xa1", "0xa2", "0xa3", "0xa4", "0xa5"}
+	opts := Options{
+		RangeSize:             10,
+		Addresses:             addresses,
+		MaxAddressesPerFilter: 2,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	logs, err := processor.fetchRange(context.Background(), 1, 2, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [][]any{{"0xa1", "0xa2"}, {"0xa3", "0xa4"}, {"0xa5"}}, filters)
+
+	// The shared log once, then the per-address logs in order
+	if !assert.Len(t, logs, 6) {
+		return
+	}
+	assert.Equal(t, "0xshared", logs[0].Address)
+	for i, address := range addresses {
+		assert.Equal(t, address, logs[i+1].Address)
+	}
+}
+
+func TestDrainLogs_AfterEndBlock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			// One log at the first block of every window
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 2,
+		LogsBufferSize:     64,
+		EndBlock:           35,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	assert.NoError(t, processor.Run(ctx))
+	// Run returns once EndBlock is committed, not at the deadline
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, uint64(35), processor.chains[chain.ChainId].cursor)
+
+	logs := processor.DrainLogs(chain.ChainId)
+	if !assert.Len(t, logs, 4) {
+		return
+	}
+	for i, blockNumber := range []string{"0x1", "0xb", "0x15", "0x1f"} {
+		assert.Equal(t, blockNumber, logs[i].BlockNumber)
+	}
+
+	// The channel is closed and empty now
+	assert.Empty(t, processor.DrainLogs(chain.ChainId))
+	assert.Nil(t, processor.DrainLogs("unknown"))
+
+	// Running again is a no-op
+	assert.NoError(t, processor.Run(ctx))
+}
+
+func TestWindowRetries_RefetchesOnlyFailedWindow(t *testing.T) {
+	var mu sync.Mutex
+	getLogsFrom := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			mu.Lock()
+			getLogsFrom[from]++
+			calls := getLogsFrom[from]
+			mu.Unlock()
+			// The window starting at 11 fails once with a non-retryable error
+			if from == "0xb" && calls == 1 {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	// Virtual time, and a strategy without InitialBackoff: the window waits the delay of the strategy
+	clock := rpc.NewFakeClock(time.Now())
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 4,
+		LogsBufferSize:     64,
+		EndBlock:           100,
+		WindowRetries:      2,
+		Clock:              clock,
+		RetryConfig:        &rpc.RetryConfig{Strategy: rpc.ConstantBackoff{MaxAttempts: 3, Delay: 250 * time.Millisecond}},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	assert.Len(t, processor.DrainLogs(chain.ChainId), 10)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for from, calls := range getLogsFrom {
+		if from == "0xb" {
+			assert.Equal(t, 2, calls)
+		} else {
+			assert.Equal(t, 1, calls, from)
+		}
+	}
+	// A single wait, before the window is fetched again
+	assert.Equal(t, []time.Duration{250 * time.Millisecond}, clock.Waits())
+}
+
+func TestAllowOutOfOrderCommit_EmitsBeforeSlowWindow(t *testing.T) {
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			var req struct {
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "eth_blockNumber":
+				result = "0x28"
+			case "eth_getBlockByNumber":
+				blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+				assert.NoError(t, err)
+				result = map[string]any{
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+				}
+			case "eth_getLogs":
+				from := req.Params[0].(map[string]any)["fromBlock"].(string)
+				// The first window is slow
+				if from == "0x1" {
+					time.Sleep(300 * time.Millisecond)
+				}
+				result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+			default:
+				http.Error(w, "method no supported", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		}))
+	}
+
+	// firstLog runs a bounded chain and returns the first emitted log and how long it took
+	firstLog := func(outOfOrder bool) (types.Log, time.Duration, []types.Log) {
+		srv := newServer()
+		defer srv.Close()
+
+		opts := Options{
+			RangeSize:             10,
+			FetcherConcurrency:    4,
+			LogsBufferSize:        64,
+			EndBlock:              40,
+			AllowOutOfOrderCommit: outOfOrder,
+		}
+		chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(chain, &opts))
+		logsCh, err := processor.Logs(chain.ChainId)
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		start := time.Now()
+		go func() { _ = processor.Run(ctx) }()
+
+		first := <-logsCh
+		elapsed := time.Since(start)
+		var rest []types.Log
+		for l := range logsCh {
+			rest = append(rest, l)
+		}
+		return first, elapsed, rest
+	}
+
+	first, elapsed, rest := firstLog(false)
+	assert.Equal(t, "0x1", first.BlockNumber)
+	assert.GreaterOrEqual(t, elapsed, 300*time.Millisecond)
+	assert.Len(t, rest, 3)
+
+	first, elapsed, rest = firstLog(true)
+	assert.NotEqual(t, "0x1", first.BlockNumber)
+	assert.Less(t, elapsed, 300*time.Millisecond)
+	// Every window is still emitted exactly once
+	assert.Len(t, rest, 3)
+}
+
+func TestRun_FetchErrorContainsFilter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x5"})
+		case "eth_getLogs":
+			// Non retryable so the chain stops right away
+			http.Error(w, "bad filter", http.StatusBadRequest)
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 1,
+		Addresses:          []string{"0xa1"},
+		Topics:             []string{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := processor.Run(ctx)
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Contains(t, err.Error(), "0x1-0x5")
+	assert.Contains(t, err.Error(), "0xa1")
+	assert.Contains(t, err.Error(), "0xddf252ad")
+
+	var filterErr *errors.FilterError
+	if assert.ErrorAs(t, err, &filterErr) {
+		assert.Equal(t, "0x1", filterErr.Filter.FromBlock)
+		assert.Equal(t, "0x5", filterErr.Filter.ToBlock)
+		assert.Equal(t, []string{"0xa1"}, filterErr.Filter.Address)
+	}
+}
+
+// testBloom builds the logs bloom of the given hex addresses and topics
+func testBloom(values ...string) string {
+	bloom := make([]byte, 256)
+	for _, v := range values {
+		b, _ := hex.DecodeString(strings.TrimPrefix(v, "0x"))
+		hash := utils.Keccak256(b)
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+			bloom[255-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return "0x" + hex.EncodeToString(bloom)
+}
+
+func TestFetchLogsFromReceipts_BloomPrecheck(t *testing.T) {
+	const (
+		watched = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		other   = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	)
+	transferTopic := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		receipt := func(txHash string, bloom string) map[string]any {
+			return map[string]any{
+				"blockNumber":     "0x1",
+				"transactionHash": txHash,
+				"logsBloom":       bloom,
+				// The logs match, only the bloom tells whether they are looked at
+				"logs": []map[string]any{
+					{"address": watched, "topics": []any{transferTopic}, "blockNumber": "0x1", "transactionHash": txHash, "logIndex": "0x0"},
+				},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				receipt("0xth1", testBloom(other, transferTopic)),
+				receipt("0xth2", testBloom(watched, transferTopic)),
+				receipt("0xth3", ""),
+			},
+		})
+	}))
+	defer srv.Close()
+
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	opts := Options{
+		RangeSize: 10,
+		FetchMode: FetchModeReceipts,
+		Addresses: []string{watched},
+		Topics:    []string{"Transfer(address,address,uint256)"},
+	}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+	logs, err := processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+
+	// The bloom of the first receipt excludes the watched address so its logs are never scanned
+	withBloom := opts
+	withBloom.BloomPrecheck = true
+	processor = NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &withBloom))
+	logs, err = processor.fetchLogsFromReceipts(context.Background(), 1, 1, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	if !assert.Len(t, logs, 2) {
+		return
+	}
+	assert.Equal(t, "0xth2", logs[0].TransactionHash)
+	assert.Equal(t, "0xth3", logs[1].TransactionHash)
+	assert.Equal(t, uint64(1), processor.Stats().Chains[chain.ChainId].ReceiptsSkipped)
+}
+
+func TestRestoreFromSink(t *testing.T) {
+	store := sink.NewMemorySink()
+	ctx := context.Background()
+	assert.NoError(t, store.Store(ctx, "1", []types.Event{
+		{BlockNumber: 120, LogIndex: 0},
+		{BlockNumber: 150, LogIndex: 1},
+		{BlockNumber: 130, LogIndex: 0},
+	}))
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1"}, &Options{RangeSize: 10, StartFrom: StartFromBlock, StartBlock: 100}))
+	// Nothing stored for this one
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "2"}, &Options{RangeSize: 10, StartFrom: StartFromBlock, StartBlock: 100}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "3"}, &Options{RangeSize: 10, StartFrom: StartFromHead, BlockCacheSize: 8, TipOverlapBlocks: 100}))
+	assert.NoError(t, store.Store(ctx, "3", []types.Event{{BlockNumber: 42}}))
+
+	// State of a previous run that went further than the sink
+	chain3 := processor.chains["3"]
+	processor.storeWindowHash(40, "0x28", chain3)
+	processor.storeWindowHash(50, "0x32", chain3)
+	chain3.blockCache.put(50, types.Block{Hash: "0x32"})
+	chain3.seenLogs["0x32:0xth:0x0"] = 50
+
+	assert.NoError(t, processor.RestoreFromSink(ctx, store))
+
+	watermark, err := processor.Watermark("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(150), watermark)
+
+	watermark, err = processor.Watermark("2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), watermark)
+
+	watermark, err = processor.Watermark("3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), watermark)
+	// The restored cursor is not replaced by the head when the chain starts
+	assert.True(t, chain3.startResolved)
+	// Like SetCursor, nothing beyond the restored block is kept
+	assert.Equal(t, []uint64{40}, chain3.windowOrder)
+	_, ok := chain3.blockCache.get(50)
+	assert.False(t, ok)
+	assert.Empty(t, chain3.seenLogs)
+}
+
+func TestRunFor_CommitsWindowsInFlightWithinGrace(t *testing.T) {
+	var fetched atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x3e8"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			// Slow windows so some are in flight when the stop comes
+			fetched.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 2,
+		LogsBufferSize:     1000,
+	}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+	logsCh, err := processor.Logs(chain.ChainId)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	err = processor.RunFor(context.Background(), 2*time.Second, 1800*time.Millisecond)
+	elapsed := time.Since(start)
+	assert.NoError(t, err)
+
+	// Stopped gracefully long before the hard cancel, without indexing the whole range
+	assert.Less(t, elapsed, time.Second)
+	watermark, err := processor.Watermark(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Greater(t, watermark, uint64(0))
+	assert.Less(t, watermark, uint64(1000))
+
+	// Every window fetched before the stop was committed, the cursor ends on a window boundary
+	assert.Equal(t, uint64(0), watermark%10)
+	assert.Equal(t, int(fetched.Load()), int(watermark/10))
+	assert.Len(t, processor.DrainLogs(chain.ChainId), int(watermark/10))
+	assert.Len(t, logsCh, 0)
+
+	assert.Error(t, processor.RunFor(context.Background(), time.Second, 2*time.Second))
+}
+
+func TestRun_ZeroHeadWaitsWithoutSpinning(t *testing.T) {
+	var headCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method != "eth_blockNumber" {
+			t.Errorf("unexpected call to %s", req.Method)
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		headCalls.Add(1)
+		// Fresh devnet still at genesis
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x0"})
+	}))
+	defer srv.Close()
+
+	opts := Options{RangeSize: 10, PollInterval: 50 * time.Millisecond}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	// About one poll per interval instead of a busy loop
+	assert.GreaterOrEqual(t, int(headCalls.Load()), 2)
+	assert.LessOrEqual(t, int(headCalls.Load()), 8)
+	watermark, err := processor.Watermark(chain.ChainId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), watermark)
+}
+
+func TestRun_MalformedHeadIsRetried(t *testing.T) {
+	var headCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			// The first answers of the buggy provider are empty
+			if headCalls.Add(1) <= 2 {
+				result = ""
+			} else {
+				result = "0x2"
+			}
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{{"address": "0xabc", "blockNumber": "0x1", "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	clock := rpc.NewFakeClock(time.Now())
+	opts := Options{RangeSize: 10, LogsBufferSize: 10, EndBlock: 2, Clock: clock}
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &opts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	assert.Len(t, processor.DrainLogs(chain.ChainId), 1)
+	assert.Equal(t, int32(3), headCalls.Load())
+	// Two backoffs of the default retry config before the valid head
+	assert.Equal(t, 2, len(clock.Waits()))
+}
+
+func TestStopReason(t *testing.T) {
+	newServer := func(failLogs bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			var req struct {
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "eth_blockNumber":
+				result = "0x5"
+			case "eth_getBlockByNumber":
+				blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+				assert.NoError(t, err)
+				result = map[string]any{
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+				}
+			case "eth_getLogs":
+				if failLogs {
+					http.Error(w, "bad filter", http.StatusBadRequest)
+					return
+				}
+				result = []map[string]any{}
+			default:
+				http.Error(w, "method no supported", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		}))
+	}
+	ok := newServer(false)
+	defer ok.Close()
+	failing := newServer(true)
+	defer failing.Close()
+
+	processor := NewProcessor()
+	// Reaches EndBlock
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "completed", RPC: rpc.NewHTTPRPC(ok.URL, 0)}, &Options{RangeSize: 10, EndBlock: 5}))
+	// Follows the head until the context is done
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "canceled", RPC: rpc.NewHTTPRPC(ok.URL, 0)}, &Options{RangeSize: 10, PollInterval: 10 * time.Millisecond}))
+	// Fails with a non retryable error
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "failed", RPC: rpc.NewHTTPRPC(failing.URL, 0)}, &Options{RangeSize: 10}))
+
+	assert.Nil(t, processor.StopReason("completed"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := processor.Run(ctx)
+
+	// Only the failure is returned by Run
+	var filterErr *errors.FilterError
+	assert.ErrorAs(t, err, &filterErr)
+	assert.NotErrorIs(t, err, ErrChainCanceled)
+
+	assert.ErrorIs(t, processor.StopReason("completed"), ErrChainCompleted)
+	assert.ErrorIs(t, processor.StopReason("canceled"), ErrChainCanceled)
+	assert.ErrorAs(t, processor.StopReason("failed"), &filterErr)
+	assert.Nil(t, processor.StopReason("unknown"))
+
+	// A graceful stop of RunFor is a cancellation too
+	processor = NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "canceled", RPC: rpc.NewHTTPRPC(ok.URL, 0)}, &Options{RangeSize: 10, PollInterval: 10 * time.Millisecond}))
+	assert.NoError(t, processor.RunFor(context.Background(), 200*time.Millisecond, 100*time.Millisecond))
+	assert.ErrorIs(t, processor.StopReason("canceled"), ErrChainCanceled)
+}
+
+func TestReorg_DeeperThanMaxReorgDepthHalts(t *testing.T) {
+	// Until forked is set the chain is at head 6, then every block is replaced and the head moves to 10
+	var forked atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		prefix := "0x"
+		if forked.Load() {
+			prefix = "0xf"
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x6"
+			if forked.Load() {
+				result = "0xa"
+			}
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       fmt.Sprintf("%s%d", prefix, blockNum),
+				"parentHash": fmt.Sprintf("%s%d", prefix, blockNum-1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:           2,
+		MaxReorgDepth:       4,
+		ReorgLookbackBlocks: 64,
+		PollInterval:        10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- processor.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		watermark, _ := processor.Watermark("1")
+		return watermark == 6
+	}, time.Second, 5*time.Millisecond)
+	forked.Store(true)
+
+	// No ancestor within 4 blocks, the chain halts instead of rewinding
+	err = <-runErr
+	var reorgErr *errors.ReorgError
+	if assert.ErrorAs(t, err, &reorgErr) {
+		assert.Equal(t, uint64(6), reorgErr.Cursor)
+		assert.Equal(t, uint64(4), reorgErr.MaxDepth)
+	}
+	assert.ErrorAs(t, processor.StopReason("1"), &reorgErr)
+	watermark, _ := processor.Watermark("1")
+	assert.Equal(t, uint64(6), watermark)
+}
+
+func TestSetRetryConfig_WhileRunning(t *testing.T) {
+	// The first 3 eth_getLogs fail
+	var getLogsCalls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x14"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			if getLogsCalls.Add(1) <= 3 {
+				http.Error(w, "flaky provider", http.StatusServiceUnavailable)
+				return
+			}
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	clock := rpc.NewFakeClock(time.Unix(0, 0))
+	processor := NewProcessor()
+	// A single attempt would stop the chain on the first failure
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          2,
+		EndBlock:           20,
+		FetcherConcurrency: 2,
+		Clock:              clock,
+		RetryConfig:        &rpc.RetryConfig{MaxAttempts: 1},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, processor.SetRetryConfig("1", rpc.RetryConfig{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 1}))
+
+	// Keep replacing the config while the fetchers read it
+	done := make(chan struct{})
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			assert.NoError(t, processor.SetRetryConfig("1", rpc.RetryConfig{MaxAttempts: 5 + i%2, InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 1}))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+	close(done)
+	<-updated
+
+	assert.ErrorIs(t, processor.StopReason("1"), ErrChainCompleted)
+	assert.Error(t, processor.SetRetryConfig("1", rpc.RetryConfig{}))
+	assert.Error(t, processor.SetRetryConfig("unknown", rpc.RetryConfig{MaxAttempts: 1}))
+}
+
+func TestSetCursor_SeekForwardAndBackward(t *testing.T) {
+	// Records the fromBlock of every eth_getLogs
+	var mu sync.Mutex
+	var fromBlocks []uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x14"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			filter := req.Params[0].(map[string]any)
+			from, err := utils.HexQtyToUint64(filter["fromBlock"].(string))
+			assert.NoError(t, err)
+			mu.Lock()
+			fromBlocks = append(fromBlocks, from)
+			mu.Unlock()
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	// runUntil runs the chain until its cursor reaches block and returns the first block fetched
+	runUntil := func(processor *Processor, block uint64) uint64 {
+		mu.Lock()
+		fromBlocks = nil
+		mu.Unlock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() { runErr <- processor.Run(ctx) }()
+		assert.Eventually(t, func() bool {
+			cursor, err := processor.GetCursor("1")
+			return err == nil && cursor == block
+		}, 5*time.Second, 5*time.Millisecond)
+		cancel()
+		assert.NoError(t, <-runErr)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !assert.NotEmpty(t, fromBlocks) {
+			return 0
+		}
+		first := fromBlocks[0]
+		for _, from := range fromBlocks {
+			first = min(first, from)
+		}
+		return first
+	}
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          2,
+		FetcherConcurrency: 1,
+		PollInterval:       10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	// Seek forward before the first run
+	assert.NoError(t, processor.SetCursor("1", 10))
+	cursor, err := processor.GetCursor("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), cursor)
+	assert.Equal(t, uint64(11), runUntil(processor, 20))
+
+	// Seek backward, the window hashes beyond the new cursor are dropped
+	assert.NoError(t, processor.SetCursor("1", 4))
+	chain := processor.chains["1"]
+	for _, to := range chain.windowOrder {
+		assert.LessOrEqual(t, to, uint64(4))
+	}
+	assert.Len(t, chain.storedWindowHash, len(chain.windowOrder))
+	assert.Equal(t, uint64(5), runUntil(processor, 20))
+
+	assert.Error(t, processor.SetCursor("unknown", 1))
+	_, err = processor.GetCursor("unknown")
+	assert.Error(t, err)
+}
+
+func TestIndexGenesis(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x3"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			parentHash := "0x0000000000000000000000000000000000000000000000000000000000000000"
+			if blockNum > 0 {
+				parentHash = utils.Uint64ToHexQty(blockNum - 1)
+			}
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": parentHash,
+			}
+		case "eth_getLogs":
+			filter := req.Params[0].(map[string]any)
+			from, err := utils.HexQtyToUint64(filter["fromBlock"].(string))
+			assert.NoError(t, err)
+			to, err := utils.HexQtyToUint64(filter["toBlock"].(string))
+			assert.NoError(t, err)
+			// Genesis allocation log at block 0, a regular log at block 1
+			logs := []map[string]any{}
+			for _, block := range []uint64{0, 1} {
+				if block >= from && block <= to {
+					logs = append(logs, map[string]any{"address": "0xabc", "topics": []string{}, "data": "0x", "blockNumber": utils.Uint64ToHexQty(block), "logIndex": "0x0"})
+				}
+			}
+			result = logs
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	// indexedBlocks runs the chain from genesis up to block 3 and returns the blocks of its logs
+	indexedBlocks := func(indexGenesis bool) []string {
+		processor := NewProcessor()
+		err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+			RangeSize:      2,
+			StartBlock:     0,
+			EndBlock:       3,
+			IndexGenesis:   indexGenesis,
+			LogsBufferSize: 10,
+		})
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, processor.Run(ctx))
+
+		var blocks []string
+		for _, l := range processor.DrainLogs("1") {
+			blocks = append(blocks, l.BlockNumber)
+		}
+		return blocks
+	}
+
+	assert.Equal(t, []string{"0x1"}, indexedBlocks(false))
+	assert.Equal(t, []string{"0x0", "0x1"}, indexedBlocks(true))
+}
+
+func TestGetChain(t *testing.T) {
+	processor := NewProcessor()
+	client := rpc.NewHTTPRPC("http://localhost", 0)
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", Name: "mainnet", RPC: client}, &Options{RangeSize: 10}))
+
+	chain, err := processor.GetChain("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "mainnet", chain.Name)
+	assert.Equal(t, client, chain.RPC)
+
+	// An unregistered chain is an error, not a panic
+	assert.NotPanics(t, func() {
+		_, err = processor.GetChain("unknown")
+	})
+	assert.Error(t, err)
+}
+
+func TestAddChain_VerifyChainID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_chainId", req.Method)
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x1"})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	client := rpc.NewHTTPRPC(srv.URL, 0)
+
+	// A polygon chain pointed at a mainnet endpoint is refused
+	err := processor.AddChain(ChainInfo{ChainId: "137", Name: "polygon", RPC: client}, &Options{RangeSize: 10, VerifyChainID: true})
+	assert.ErrorContains(t, err, "chain id 1")
+	_, err = processor.GetChain("137")
+	assert.Error(t, err)
+
+	// Matching ids are accepted in decimal and hex
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", Name: "mainnet", RPC: client}, &Options{RangeSize: 10, VerifyChainID: true}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "0x1", Name: "mainnet-hex", RPC: client}, &Options{RangeSize: 10, VerifyChainID: true}))
+
+	// The hex prefix is case insensitive on both sides
+	upper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0X89"})
+	}))
+	defer upper.Close()
+	polygon := rpc.NewHTTPRPC(upper.URL, 0)
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "137", Name: "polygon", RPC: polygon}, &Options{RangeSize: 10, VerifyChainID: true}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "0X89", Name: "polygon-hex", RPC: polygon}, &Options{RangeSize: 10, VerifyChainID: true}))
+}
+
+func TestAddChainContext_VerifyChainIDDoesNotHoldLock(t *testing.T) {
+	// The endpoint hangs until the test ends
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	processor := NewProcessor()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- processor.AddChainContext(ctx, ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{RangeSize: 10, VerifyChainID: true})
+	}()
+
+	// The processor stays usable while the node is asked
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "2", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{RangeSize: 10}))
+	_, err := processor.GetChain("2")
+	assert.NoError(t, err)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("AddChainContext ignored its context")
+	}
+	_, err = processor.GetChain("1")
+	assert.Error(t, err)
+}
+
+func TestRun_MaxInitialGap(t *testing.T) {
+	// Mainnet-like head, the other calls fail so the chains stop right after the guard
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_blockNumber" {
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": utils.Uint64ToHexQty(20_000_000)})
+	}))
+	defer srv.Close()
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	for _, tc := range []struct {
+		name   string
+		opts   Options
+		cursor    uint64
+		setCursor bool
+		fails     bool
+	}{
+		// StartBlock left at 0 by mistake
+		{name: "genesis", opts: Options{MaxInitialGap: 1_000_000}, fails: true},
+		{name: "acknowledged", opts: Options{MaxInitialGap: 1_000_000, AllowLargeBackfill: true}},
+		{name: "start block", opts: Options{MaxInitialGap: 1_000_000, StartBlock: 19_500_000}},
+		{name: "end block", opts: Options{MaxInitialGap: 1_000_000, EndBlock: 500_000}},
+		{name: "head", opts: Options{MaxInitialGap: 1_000_000, StartFrom: StartFromHead}},
+		// The cursor set before Run is checked, not StartBlock
+		{name: "cursor set close", opts: Options{MaxInitialGap: 1_000_000}, cursor: 19_500_000, setCursor: true},
+		{name: "cursor set far", opts: Options{MaxInitialGap: 1_000_000, StartBlock: 19_500_000}, setCursor: true, fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.RangeSize = 10
+
+			processor := NewProcessor()
+			assert.NoError(t, processor.AddChain(chain, &opts))
+			if tc.setCursor {
+				assert.NoError(t, processor.SetCursor(chain.ChainId, tc.cursor))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_ = processor.Run(ctx)
+
+			reason := processor.StopReason(chain.ChainId)
+			if tc.fails {
+				assert.ErrorContains(t, reason, "AllowLargeBackfill")
+			} else if reason != nil {
+				assert.NotContains(t, reason.Error(), "AllowLargeBackfill")
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	newServer := func(failLogs bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			var req struct {
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "eth_blockNumber":
+				result = "0x20"
+			case "eth_getBlockByNumber":
+				blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+				assert.NoError(t, err)
+				result = map[string]any{
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+				}
+			case "eth_getLogs":
+				if failLogs {
+					http.Error(w, "bad filter", http.StatusBadRequest)
+					return
+				}
+				result = []map[string]any{}
+			default:
+				http.Error(w, "method no supported", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		}))
+	}
+	ok := newServer(false)
+	defer ok.Close()
+	failing := newServer(true)
+	defer failing.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "completed", RPC: rpc.NewHTTPRPC(ok.URL, 0)}, &Options{RangeSize: 4, EndBlock: 10}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "failed", RPC: rpc.NewHTTPRPC(failing.URL, 0)}, &Options{RangeSize: 4, StartBlock: 7}))
+	assert.Nil(t, processor.Completion("unknown"))
+
+	// Nothing is delivered before the chain terminates
+	select {
+	case info := <-processor.Completion("completed"):
+		t.Fatalf("unexpected completion %+v", info)
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Error(t, processor.Run(ctx))
+
+	// The logs channel is closed, the completion tells where the backfill ended
+	logs, err := processor.Logs("completed")
+	assert.NoError(t, err)
+	_, open := <-logs
+	assert.False(t, open)
+	info := <-processor.Completion("completed")
+	assert.Equal(t, CompletionInfo{FinalCursor: 10, Reason: CompletionReasonCompleted}, info)
+
+	info = <-processor.Completion("failed")
+	assert.Equal(t, uint64(7), info.FinalCursor)
+	assert.Equal(t, CompletionReasonFailed, info.Reason)
+	var filterErr *errors.FilterError
+	assert.ErrorAs(t, info.Err, &filterErr)
+
+	// Delivered once per termination
+	select {
+	case info := <-processor.Completion("completed"):
+		t.Fatalf("unexpected completion %+v", info)
+	default:
+	}
+}
+
+// newFailingSiblingsProcessor returns a processor with a chain failing on its first eth_getLogs
+// and a healthy chain following a head at block 5
+func newFailingSiblingsProcessor(t *testing.T, failFast bool) *Processor {
+	newServer := func(failLogs bool) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			var req struct {
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "eth_blockNumber":
+				result = "0x5"
+			case "eth_getBlockByNumber":
+				blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+				assert.NoError(t, err)
+				result = map[string]any{
+					"number":     req.Params[0],
+					"hash":       req.Params[0],
+					"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+				}
+			case "eth_getLogs":
+				if failLogs {
+					http.Error(w, "bad filter", http.StatusBadRequest)
+					return
+				}
+				result = []map[string]any{}
+			default:
+				http.Error(w, "method no supported", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "failing", RPC: rpc.NewHTTPRPC(newServer(true).URL, 0)},
+		&Options{RangeSize: 10, FailFast: failFast}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "healthy", RPC: rpc.NewHTTPRPC(newServer(false).URL, 0)},
+		&Options{RangeSize: 10, PollInterval: 10 * time.Millisecond}))
+	return processor
+}
+
+func TestRun_FailingChainDoesNotStopSiblings(t *testing.T) {
+	processor := newFailingSiblingsProcessor(t, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := processor.Run(ctx)
+
+	var filterErr *errors.FilterError
+	assert.ErrorAs(t, err, &filterErr)
+	assert.ErrorAs(t, processor.StopReason("failing"), &filterErr)
+	// The healthy chain indexed up to the head and kept following it until the context was done
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	assert.ErrorIs(t, processor.StopReason("healthy"), ErrChainCanceled)
+	watermark, err := processor.Watermark("healthy")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), watermark)
+}
+
+func TestRun_FailFastStopsSiblings(t *testing.T) {
+	processor := newFailingSiblingsProcessor(t, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := processor.Run(ctx)
+
+	// Run returns the failure as soon as the healthy chain stopped, long before the context is done
+	var filterErr *errors.FilterError
+	assert.ErrorAs(t, err, &filterErr)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.ErrorAs(t, processor.StopReason("failing"), &filterErr)
+	assert.ErrorIs(t, processor.StopReason("healthy"), ErrChainCanceled)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestRun_MissingLogsChannelFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x5"})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{RangeSize: 10}))
+	// A chain registered without its channels, the arbiter would block forever sending its logs
+	delete(processor.logsCh, "1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := processor.Run(ctx)
+	assert.ErrorContains(t, err, "no logs channel")
+	assert.NoError(t, ctx.Err())
+}
+
+func TestRemoveChain_ConcurrentWithRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from := req.Params[0].(map[string]any)["fromBlock"].(string)
+			result = []map[string]any{{"address": "0xabc", "blockNumber": from, "logIndex": "0x0"}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	// The removal lands before the run starts or while the windows commit, depending on scheduling
+	for i := 0; i < 20; i++ {
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(chain, &Options{RangeSize: 10, LogsBufferSize: 1024, EndBlock: 100}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- processor.Run(ctx)
+		}()
+		removeErr := processor.RemoveChain(chain.ChainId)
+
+		select {
+		case err := <-runErr:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Run deadlocked after a concurrent RemoveChain")
+		}
+		cancel()
+
+		// Refused while running, the chain can be removed once Run returned
+		if removeErr != nil {
+			assert.ErrorContains(t, removeErr, "while processor is running")
+			assert.NoError(t, processor.RemoveChain(chain.ChainId))
+		}
+		_, err := processor.GetChain(chain.ChainId)
+		assert.Error(t, err)
+	}
+}
+
+func TestAlignRangesTo(t *testing.T) {
+	var mu sync.Mutex
+	var ranges [][2]uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x30"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			filter := req.Params[0].(map[string]any)
+			from, err := utils.HexQtyToUint64(filter["fromBlock"].(string))
+			assert.NoError(t, err)
+			to, err := utils.HexQtyToUint64(filter["toBlock"].(string))
+			assert.NoError(t, err)
+			mu.Lock()
+			ranges = append(ranges, [2]uint64{from, to})
+			mu.Unlock()
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          10,
+		AlignRangesTo:      8,
+		StartBlock:         2,
+		EndBlock:           30,
+		FetcherConcurrency: 3,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	// The first window is cut at the first boundary, the next ones start on a multiple of 8
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	assert.Equal(t, [][2]uint64{{3, 7}, {8, 15}, {16, 23}, {24, 30}}, ranges)
+	for _, r := range ranges {
+		assert.Equal(t, r[0]/8, r[1]/8, "window %v spans a boundary", r)
+	}
+
+	// RangeSize smaller than the alignment cuts windows too, without spanning a boundary
+	chain := &chainState{opts: &Options{RangeSize: 3, AlignRangesTo: 8}}
+	assert.Equal(t, uint64(7), chain.windowEnd(7, 100))
+	assert.Equal(t, uint64(10), chain.windowEnd(8, 100))
+	assert.Equal(t, uint64(15), chain.windowEnd(14, 100))
+	assert.Equal(t, uint64(14), chain.windowEnd(14, 14))
+}
+
+func TestAlignRangesTo_ReorgWalksStoredHashes(t *testing.T) {
+	// Blocks above 35 were replaced by the reorg
+	hash := func(number uint64) string {
+		if number > 35 {
+			return fmt.Sprintf("0xnew%d", number)
+		}
+		return fmt.Sprintf("0xcanonical%d", number)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_getBlockByNumber" {
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		number, err := utils.HexQtyToUint64(req.Params[0].(string))
+		assert.NoError(t, err)
+		result := map[string]any{"number": req.Params[0], "hash": hash(number), "parentHash": hash(number - 1)}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:           10,
+		AlignRangesTo:       8,
+		ReorgLookbackBlocks: 80,
+	}))
+	chain := processor.chains["1"]
+	// Sized from the aligned windows, shorter than RangeSize
+	assert.Equal(t, uint64(11), chain.storedWindowHashCap)
+
+	// The window ends of 1-7, 8-15, ... are 8 blocks apart, the ones above 35 are stale
+	for number := uint64(7); number <= 39; number += 8 {
+		stored := hash(number)
+		if number > 35 {
+			stored = fmt.Sprintf("0xold%d", number)
+		}
+		processor.storeWindowHash(number, stored, chain)
+	}
+	chain.setCursor(39)
+
+	ancestor, err := processor.handleReorg(context.Background(), chain)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(31), ancestor)
+}
+
+func TestSortWindowLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x14"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			log := func(block, txIndex, logIndex string) map[string]any {
+				return map[string]any{"address": "0xabc", "topics": []string{"0x01"}, "blockNumber": block, "transactionIndex": txIndex, "logIndex": logIndex}
+			}
+			// Shuffled by a proxy, with a hex block number that sorts differently as a string
+			result = []map[string]any{
+				log("0xa", "0x0", "0x0"),
+				log("0x2", "0x1", "0x3"),
+				log("0x9", "0x0", "0x0"),
+				log("0x2", "0x0", "0x1"),
+				log("0x2", "0x0", "0x0"),
+			}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	run := func(sort bool) []string {
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+			RangeSize:      10,
+			EndBlock:       10,
+			LogsBufferSize: 10,
+			SortWindowLogs: sort,
+		}))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, processor.Run(ctx))
+
+		var positions []string
+		for _, l := range processor.DrainLogs("1") {
+			positions = append(positions, l.BlockNumber+"/"+l.TransactionIndex+"/"+l.LogIndex)
+		}
+		return positions
+	}
+
+	assert.Equal(t, []string{"0x2/0x0/0x0", "0x2/0x0/0x1", "0x2/0x1/0x3", "0x9/0x0/0x0", "0xa/0x0/0x0"}, run(true))
+	// Trusting the provider by default
+	assert.Equal(t, []string{"0xa/0x0/0x0", "0x2/0x1/0x3", "0x9/0x0/0x0", "0x2/0x0/0x1", "0x2/0x0/0x0"}, run(false))
+}
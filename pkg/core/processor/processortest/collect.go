@@ -0,0 +1,49 @@
+// Package processortest provides helpers to read the channels of a processor in tests
+// without hand-rolled select loops and sleeps.
+package processortest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// ErrClosed is returned by CollectLogs when the channel is closed before n logs are read
+var ErrClosed = errors.New("logs channel closed")
+
+// CollectLogs reads logs from ch, in order, until it has n of them.
+// If ch is closed first it returns the logs read with ErrClosed,
+// if ctx is done first it returns them with the context error.
+func CollectLogs(ctx context.Context, ch <-chan types.Log, n int) ([]types.Log, error) {
+	logs := make([]types.Log, 0, n)
+	for len(logs) < n {
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		case l, ok := <-ch:
+			if !ok {
+				return logs, ErrClosed
+			}
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// DrainUntilClosed reads logs from ch, in order, until it is closed, e.g. by a chain completing its EndBlock.
+// If ctx is done first it returns the logs read with the context error.
+func DrainUntilClosed(ctx context.Context, ch <-chan types.Log) ([]types.Log, error) {
+	var logs []types.Log
+	for {
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		case l, ok := <-ch:
+			if !ok {
+				return logs, nil
+			}
+			logs = append(logs, l)
+		}
+	}
+}
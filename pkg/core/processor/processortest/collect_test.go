@@ -0,0 +1,79 @@
+package processortest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func logs(n int) []types.Log {
+	logs := make([]types.Log, n)
+	for i := range logs {
+		logs[i] = types.Log{LogIndex: string(rune('0' + i))}
+	}
+	return logs
+}
+
+func TestCollectLogs(t *testing.T) {
+	ch := make(chan types.Log)
+	go func() {
+		// More than requested, the rest stays in the channel
+		for _, l := range logs(4) {
+			ch <- l
+		}
+	}()
+
+	got, err := CollectLogs(context.Background(), ch, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, logs(3), got)
+	assert.Equal(t, logs(4)[3], <-ch)
+}
+
+func TestCollectLogs_EarlyClose(t *testing.T) {
+	ch := make(chan types.Log, 2)
+	ch <- logs(1)[0]
+	close(ch)
+
+	got, err := CollectLogs(context.Background(), ch, 3)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Equal(t, logs(1), got)
+}
+
+func TestCollectLogs_Timeout(t *testing.T) {
+	ch := make(chan types.Log, 2)
+	ch <- logs(1)[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	got, err := CollectLogs(ctx, ch, 3)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, logs(1), got)
+}
+
+func TestDrainUntilClosed(t *testing.T) {
+	ch := make(chan types.Log)
+	go func() {
+		defer close(ch)
+		for _, l := range logs(3) {
+			ch <- l
+		}
+	}()
+
+	got, err := DrainUntilClosed(context.Background(), ch)
+	assert.NoError(t, err)
+	assert.Equal(t, logs(3), got)
+}
+
+func TestDrainUntilClosed_Timeout(t *testing.T) {
+	ch := make(chan types.Log, 2)
+	ch <- logs(1)[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	got, err := DrainUntilClosed(ctx, ch)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, logs(1), got)
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"errors"
+)
+
+// Reasons a chain stops without failing, any other stop reason is the error that stopped the chain.
+// Run returns nil for them, use StopReason to tell them apart.
+var (
+	// ErrChainCompleted means the chain committed EndBlock, there is nothing left to index
+	ErrChainCompleted = errors.New("chain completed")
+	// ErrChainCanceled means the chain was stopped by the context of Run, or gracefully by RunFor
+	ErrChainCanceled = errors.New("chain canceled")
+)
+
+// Reasons of a CompletionInfo
+const (
+	// CompletionReasonCompleted means the chain committed EndBlock
+	CompletionReasonCompleted = "completed"
+	// CompletionReasonCanceled means the chain was stopped by the context of Run, or gracefully by RunFor
+	CompletionReasonCanceled = "canceled"
+	// CompletionReasonFailed means an error stopped the chain
+	CompletionReasonFailed = "failed"
+)
+
+// CompletionInfo describes how a chain terminated, see Completion
+type CompletionInfo struct {
+	// FinalCursor is the last committed block, the next run resumes after it
+	FinalCursor uint64
+	// Reason is one of CompletionReasonCompleted, CompletionReasonCanceled or CompletionReasonFailed
+	Reason string
+	// Err is the error that stopped the chain, nil unless Reason is CompletionReasonFailed
+	Err error
+}
+
+// newCompletionInfo describes a chain stopped by err with its cursor at finalCursor
+func newCompletionInfo(finalCursor uint64, err error) CompletionInfo {
+	info := CompletionInfo{FinalCursor: finalCursor}
+	switch {
+	case errors.Is(err, ErrChainCompleted):
+		info.Reason = CompletionReasonCompleted
+	case errors.Is(err, ErrChainCanceled):
+		info.Reason = CompletionReasonCanceled
+	default:
+		info.Reason = CompletionReasonFailed
+		info.Err = err
+	}
+	return info
+}
+
+// complete delivers info on the completion channel without blocking,
+// replacing the info of a previous Run nobody read
+func (c *chainState) complete(info CompletionInfo) {
+	select {
+	case <-c.completion:
+	default:
+	}
+	c.completion <- info
+}
+
+// isStopReason reports whether err is a normal stop rather than a failure
+func isStopReason(err error) bool {
+	return errors.Is(err, ErrChainCompleted) || errors.Is(err, ErrChainCanceled)
+}
+
+// StopReason returns why the chain stopped during the last Run:
+// ErrChainCompleted, ErrChainCanceled or the error that stopped it.
+// It returns nil while the chain is running, if it never ran or if the chain doesn't exist.
+func (p *Processor) StopReason(chainId string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return nil
+	}
+	return chain.stopReason
+}
+
+// Completion returns a channel delivering how the chain terminated each time a Run stops it,
+// e.g. to persist the final cursor of a bounded backfill once its logs channel is closed.
+// The channel holds the info of the last Run until it is read, it is closed when the chain is removed.
+// It returns nil if the chain doesn't exist.
+func (p *Processor) Completion(chainId string) <-chan CompletionInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain, exists := p.chains[chainId]
+	if !exists {
+		return nil
+	}
+	return chain.completion
+}
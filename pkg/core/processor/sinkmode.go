@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// storeLogs stores the committed logs of a chain with Options.Sink in place of emitting them.
+// The logs are converted by sinkEvents, raw events by default.
+func (p *Processor) storeLogs(ctx context.Context, chain *chainState, logs []types.Log, target uint64) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	toEvents := chain.sinkEvents
+	if toEvents == nil {
+		toEvents = rawEvents
+	}
+	events, err := toEvents(ctx, logs)
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		if err := chain.opts.Sink.Store(ctx, chain.chainInfo.ChainId, events); err != nil {
+			return fmt.Errorf("error storing %d events of chain %s: %w", len(events), chain.chainInfo.ChainId, err)
+		}
+	}
+
+	for _, l := range logs {
+		chain.recordEmitted(l, target)
+	}
+	return nil
+}
+
+// rawEvents converts logs to undecoded events, only the log position is parsed and the log is kept in RawLog
+func rawEvents(ctx context.Context, logs []types.Log) ([]types.Event, error) {
+	events := make([]types.Event, 0, len(logs))
+	for _, l := range logs {
+		blockNumber, err := utils.HexQtyToUint64(l.BlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("malformed block number of log %s: %w", l.LogIndex, err)
+		}
+		logIndex, err := utils.HexQtyToUint64(l.LogIndex)
+		if err != nil {
+			return nil, fmt.Errorf("malformed index of log of block %s: %w", l.BlockNumber, err)
+		}
+		events = append(events, types.Event{
+			BlockNumber:     blockNumber,
+			BlockHash:       l.BlockHash,
+			Address:         l.Address,
+			TransactionHash: l.TransactionHash,
+			LogIndex:        logIndex,
+			RawLog:          &l,
+			ChainId:         l.ChainId,
+		})
+	}
+	return events, nil
+}
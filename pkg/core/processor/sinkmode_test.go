@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/sink"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBusyServer serves 20 logs in every block up to head 20
+func newBusyServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x14"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			filter := req.Params[0].(map[string]any)
+			from, _ := utils.HexQtyToUint64(filter["fromBlock"].(string))
+			to, _ := utils.HexQtyToUint64(filter["toBlock"].(string))
+			var logs []map[string]any
+			for block := from; block <= to; block++ {
+				for i := uint64(0); i < 20; i++ {
+					logs = append(logs, map[string]any{
+						"address":     "0xabc",
+						"topics":      []string{"0x01"},
+						"data":        "0x",
+						"blockNumber": utils.Uint64ToHexQty(block),
+						"logIndex":    utils.Uint64ToHexQty(i),
+					})
+				}
+			}
+			result = logs
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestSink_NeverBlocksOnChannel(t *testing.T) {
+	srv := newBusyServer(t)
+	defer srv.Close()
+
+	store := sink.NewMemorySink()
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:          5,
+		EndBlock:           10,
+		FetcherConcurrency: 2,
+		// Far below the logs of a window, nobody reads the channel
+		LogsBufferSize: 1,
+		Sink:           store,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	events := store.Events("1")
+	assert.Len(t, events, 200)
+	assert.Equal(t, uint64(1), events[0].BlockNumber)
+	assert.Equal(t, "0x01", events[0].RawLog.Topics[0])
+	assert.Equal(t, uint64(19), events[199].LogIndex)
+	last, ok, err := store.GetLastBlock(ctx, "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), last)
+	assert.Equal(t, uint64(200), processor.Stats().Chains["1"].LogsEmitted)
+
+	// Nothing was sent on the logs channel
+	assert.Empty(t, processor.DrainLogs("1"))
+}
+
+// failingSink fails every Store
+type failingSink struct {
+	sink.MemorySink
+}
+
+func (s *failingSink) Store(ctx context.Context, chainId string, events []types.Event) error {
+	return errors.New("disk full")
+}
+
+func TestSink_StoreErrorStopsChain(t *testing.T) {
+	srv := newBusyServer(t)
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize: 5,
+		EndBlock:  10,
+		Sink:      &failingSink{},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorContains(t, processor.Run(ctx), "disk full")
+
+	// The failed window is not committed
+	cursor, err := processor.GetCursor("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), cursor)
+
+	err = NewProcessor().AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{RangeSize: 5, EmitBatches: true, Sink: sink.NewMemorySink()})
+	assert.Error(t, err)
+}
+
+func TestEventIndexer_Sink(t *testing.T) {
+	var filterTopics []any
+	srv := newTokenServer(t, &filterTopics)
+	defer srv.Close()
+
+	store := sink.NewMemorySink()
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10, EndBlock: 2, Sink: store},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, indexer.Run(ctx))
+
+	// Decoded events are stored, the anonymous log is skipped
+	var eventTypes []string
+	for _, event := range store.Events("1") {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(t, big.NewInt(100), event.Fields["value"])
+	}
+	assert.Equal(t, []string{"Approval", "Transfer"}, eventTypes)
+	for range indexer.Events() {
+		t.Fatal("events are stored, not emitted")
+	}
+}
@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// stateVersion is the version of the ExportState format
+const stateVersion = 1
+
+// processorState is the ExportState format
+type processorState struct {
+	Version int                      `json:"version"`
+	Chains  map[string]chainSnapshot `json:"chains"`
+}
+
+// chainSnapshot is the resumable state of a chain: its cursor and the window hashes checked for reorgs
+type chainSnapshot struct {
+	Cursor uint64 `json:"cursor"`
+	// GenesisPending is true when block 0 is still to be indexed, see Options.IndexGenesis
+	GenesisPending bool `json:"genesisPending,omitempty"`
+	// WindowHashes are the stored window end hashes, oldest first
+	WindowHashes []windowHash `json:"windowHashes"`
+}
+
+type windowHash struct {
+	Block uint64 `json:"block"`
+	Hash  string `json:"hash"`
+}
+
+// ExportState serializes the cursor and the stored window hashes of every chain, e.g. to migrate an indexer
+// to a new host. Unlike a cursor checkpoint, the window hashes keep the reorg detection of the blocks
+// committed before the export. Call it while the processor is not running, see ImportState.
+func (p *Processor) ExportState() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.isRunning {
+		return nil, fmt.Errorf("cannot export state while processor is running")
+	}
+
+	state := processorState{Version: stateVersion, Chains: make(map[string]chainSnapshot, len(p.chains))}
+	for chainId, chain := range p.chains {
+		hashes := make([]windowHash, 0, len(chain.windowOrder))
+		for _, block := range chain.windowOrder {
+			hashes = append(hashes, windowHash{Block: block, Hash: chain.storedWindowHash[block]})
+		}
+		state.Chains[chainId] = chainSnapshot{
+			Cursor:         chain.cursor,
+			GenesisPending: chain.genesisPending,
+			WindowHashes:   hashes,
+		}
+	}
+	return json.Marshal(state)
+}
+
+// ImportState restores the chains exported by ExportState, so the processor resumes exactly where
+// the exporting one left off. Every exported chain must already be added, the chains missing from data are left untouched.
+// Nothing is restored when data is invalid. Call it before Run.
+func (p *Processor) ImportState(data []byte) error {
+	var state processorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	if state.Version != stateVersion {
+		return fmt.Errorf("unsupported state version %d, expected %d", state.Version, stateVersion)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isRunning {
+		return fmt.Errorf("cannot import state while processor is running")
+	}
+	for chainId := range state.Chains {
+		chain, exists := p.chains[chainId]
+		if !exists {
+			return fmt.Errorf("chain %s not found", chainId)
+		}
+		// The channels of a completed chain are closed
+		if chain.completed {
+			return fmt.Errorf("cannot import state of completed chain %s", chainId)
+		}
+	}
+
+	for chainId, snapshot := range state.Chains {
+		chain := p.chains[chainId]
+
+		// The hashes replace the current ones, the oldest are dropped beyond the capacity of the chain
+		chain.storedWindowHash = make(map[uint64]string, chain.storedWindowHashCap)
+		chain.windowOrder = nil
+		for _, h := range snapshot.WindowHashes {
+			p.storeWindowHash(h.Block, h.Hash, chain)
+		}
+		chain.blockCache.invalidateFrom(0)
+		clear(chain.seenLogs)
+
+		chain.setCursor(snapshot.Cursor)
+		chain.genesisPending = snapshot.GenesisPending
+		// The imported cursor wins over resolving the start from the head
+		chain.startResolved = true
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStateServer serves a chain at head 1000 without logs, the eth_getLogs ranges are recorded in fromBlocks
+func newStateServer(t *testing.T, mu *sync.Mutex, fromBlocks *[]uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = utils.Uint64ToHexQty(1000)
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			from, err := utils.HexQtyToUint64(req.Params[0].(map[string]any)["fromBlock"].(string))
+			assert.NoError(t, err)
+			mu.Lock()
+			*fromBlocks = append(*fromBlocks, from)
+			mu.Unlock()
+			result = []map[string]any{}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestExportImportState_RoundTrip(t *testing.T) {
+	var mu sync.Mutex
+	var fromBlocks []uint64
+	srv := newStateServer(t, &mu, &fromBlocks)
+	defer srv.Close()
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+
+	old := NewProcessor()
+	assert.NoError(t, old.AddChain(chain, &Options{RangeSize: 10, EndBlock: 100}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, old.Run(ctx))
+
+	data, err := old.ExportState()
+	assert.NoError(t, err)
+
+	// A fresh processor on the new host
+	migrated := NewProcessor()
+	assert.NoError(t, migrated.AddChain(chain, &Options{RangeSize: 10, EndBlock: 150}))
+	assert.NoError(t, migrated.ImportState(data))
+
+	oldChain, newChain := old.chains["1"], migrated.chains["1"]
+	assert.Equal(t, uint64(100), newChain.cursor)
+	assert.Equal(t, oldChain.cursor, newChain.cursor)
+	assert.Equal(t, oldChain.windowOrder, newChain.windowOrder)
+	assert.Equal(t, oldChain.storedWindowHash, newChain.storedWindowHash)
+	assert.NotEmpty(t, newChain.storedWindowHash)
+	watermark, err := migrated.Watermark("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), watermark)
+
+	// The migrated processor resumes right after the exported cursor
+	mu.Lock()
+	fromBlocks = nil
+	mu.Unlock()
+	assert.NoError(t, migrated.Run(ctx))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, fromBlocks)
+	for _, from := range fromBlocks {
+		assert.Greater(t, from, uint64(100))
+	}
+}
+
+func TestImportState_Errors(t *testing.T) {
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC("http://localhost", 0)}
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(chain, &Options{RangeSize: 10, StartBlock: 50}))
+
+	assert.Error(t, processor.ImportState([]byte("not json")))
+	assert.ErrorContains(t, processor.ImportState([]byte(`{"version":2,"chains":{}}`)), "version")
+
+	// A chain not added aborts the whole import
+	err := processor.ImportState([]byte(`{"version":1,"chains":{"1":{"cursor":500},"2":{"cursor":700}}}`))
+	assert.ErrorContains(t, err, "chain 2 not found")
+	cursor, err := processor.GetCursor("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50), cursor)
+
+	// Not while running
+	processor.isRunning = true
+	_, err = processor.ExportState()
+	assert.Error(t, err)
+	assert.Error(t, processor.ImportState([]byte(`{"version":1,"chains":{}}`)))
+}
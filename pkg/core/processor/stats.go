@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ChainStats is a snapshot of the counters of a single chain
+type ChainStats struct {
+	// Number of blocks committed by the arbiter
+	BlocksProcessed uint64
+	// Number of logs sent to the logs channel
+	LogsEmitted uint64
+	// Number of the emitted logs that were removals, i.e. had Removed set by the provider
+	LogsRemoved uint64
+	// Number of logs missed by slow subscribers with BackpressureDrop, counted once per subscriber
+	LogsDropped uint64
+	// Number of reorgs detected
+	Reorgs uint64
+	// Number of RPC calls made, retries included
+	RPCCalls uint64
+	// Number of RPC calls that returned an error
+	Errors uint64
+	// Number of inconsistent receipts detected by ValidateReceipts
+	ReceiptMismatches uint64
+	// Number of receipts skipped by BloomPrecheck
+	ReceiptsSkipped uint64
+	// Number of audited blocks whose logs count differs between the fetch modes
+	AuditDiscrepancies uint64
+	// Number of times the lag went above LagAlertThreshold
+	LagAlerts uint64
+	// Number of events emitted without enrichment because the EventIndexer Enricher failed
+	EnrichErrors uint64
+}
+
+// ProcessorStats is a snapshot of the counters since Run started
+type ProcessorStats struct {
+	// Total is the sum of the counters of all chains
+	Total ChainStats
+	// Chains is the per-chain breakdown with chainId as key
+	Chains map[string]ChainStats
+}
+
+// chainCounters holds the live counters of a chain.
+// They are updated from the chain goroutines so every access must be atomic.
+type chainCounters struct {
+	blocksProcessed    atomic.Uint64
+	logsEmitted        atomic.Uint64
+	logsRemoved        atomic.Uint64
+	logsDropped        atomic.Uint64
+	reorgs             atomic.Uint64
+	rpcCalls           atomic.Uint64
+	errors             atomic.Uint64
+	receiptMismatches  atomic.Uint64
+	receiptsSkipped    atomic.Uint64
+	auditDiscrepancies atomic.Uint64
+	lagAlerts          atomic.Uint64
+	enrichErrors       atomic.Uint64
+}
+
+// recordRPC counts an RPC call and its error if any, returning err untouched.
+// Calls aborted by context cancellation are shutdowns, not provider errors.
+func (c *chainCounters) recordRPC(err error) error {
+	c.rpcCalls.Add(1)
+	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		c.errors.Add(1)
+	}
+	return err
+}
+
+func (c *chainCounters) reset() {
+	c.blocksProcessed.Store(0)
+	c.logsEmitted.Store(0)
+	c.logsRemoved.Store(0)
+	c.logsDropped.Store(0)
+	c.reorgs.Store(0)
+	c.rpcCalls.Store(0)
+	c.errors.Store(0)
+	c.receiptMismatches.Store(0)
+	c.receiptsSkipped.Store(0)
+	c.auditDiscrepancies.Store(0)
+	c.lagAlerts.Store(0)
+	c.enrichErrors.Store(0)
+}
+
+func (c *chainCounters) snapshot() ChainStats {
+	return ChainStats{
+		BlocksProcessed:    c.blocksProcessed.Load(),
+		LogsEmitted:        c.logsEmitted.Load(),
+		LogsRemoved:        c.logsRemoved.Load(),
+		LogsDropped:        c.logsDropped.Load(),
+		Reorgs:             c.reorgs.Load(),
+		RPCCalls:           c.rpcCalls.Load(),
+		Errors:             c.errors.Load(),
+		ReceiptMismatches:  c.receiptMismatches.Load(),
+		ReceiptsSkipped:    c.receiptsSkipped.Load(),
+		AuditDiscrepancies: c.auditDiscrepancies.Load(),
+		LagAlerts:          c.lagAlerts.Load(),
+		EnrichErrors:       c.enrichErrors.Load(),
+	}
+}
+
+// recordEnrichError counts an enricher failure of chainId
+func (p *Processor) recordEnrichError(chainId string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if chain, exists := p.chains[chainId]; exists {
+		chain.stats.enrichErrors.Add(1)
+	}
+}
+
+// Stats returns the counters of all chains since Run started.
+// It is safe to call while the processor is running.
+func (p *Processor) Stats() ProcessorStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := ProcessorStats{
+		Chains: make(map[string]ChainStats, len(p.chains)),
+	}
+	for chainId, chain := range p.chains {
+		s := chain.stats.snapshot()
+		stats.Chains[chainId] = s
+
+		stats.Total.BlocksProcessed += s.BlocksProcessed
+		stats.Total.LogsEmitted += s.LogsEmitted
+		stats.Total.LogsRemoved += s.LogsRemoved
+		stats.Total.LogsDropped += s.LogsDropped
+		stats.Total.Reorgs += s.Reorgs
+		stats.Total.RPCCalls += s.RPCCalls
+		stats.Total.Errors += s.Errors
+		stats.Total.ReceiptMismatches += s.ReceiptMismatches
+		stats.Total.ReceiptsSkipped += s.ReceiptsSkipped
+		stats.Total.AuditDiscrepancies += s.AuditDiscrepancies
+		stats.Total.LagAlerts += s.LagAlerts
+		stats.Total.EnrichErrors += s.EnrichErrors
+	}
+
+	return stats
+}
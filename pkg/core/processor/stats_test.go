@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_AfterBoundedRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch req.Method {
+		case "eth_blockNumber":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  "0x64",
+			})
+
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(fmt.Sprintf("%s", req.Params[0]))
+			assert.NoError(t, err)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result": map[string]any{
+					"Number":     req.Params[0],
+					"Hash":       req.Params[0],
+					"ParentHash": utils.Uint64ToHexQty(blockNum - 1),
+				},
+			})
+
+		case "eth_getLogs":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result": []map[string]any{
+					{
+						"address":     "0xabc",
+						"topics":      []any{"0xddf252ad"},
+						"data":        "0x",
+						"blockNumber": "0x1",
+						"logIndex":    "0x0",
+					},
+				},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		RangeSize:          10,
+		FetcherConcurrency: 4,
+		LogsBufferSize:     1024,
+	}
+	chain := ChainInfo{
+		ChainId: "1",
+		RPC:     rpc.NewHTTPRPC(srv.URL, 0),
+	}
+
+	processor := NewProcessor()
+	processor.AddChain(chain, &opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = processor.Run(ctx)
+
+	stats := processor.Stats()
+	chainStats := stats.Chains[chain.ChainId]
+
+	// 100 blocks split in 10 windows, one log per window
+	assert.Equal(t, uint64(100), chainStats.BlocksProcessed)
+	assert.Equal(t, uint64(10), chainStats.LogsEmitted)
+	assert.Equal(t, uint64(0), chainStats.Reorgs)
+	assert.Equal(t, uint64(0), chainStats.Errors)
+	// At least one head, 10 getLogs and 2 headers per window
+	assert.GreaterOrEqual(t, chainStats.RPCCalls, uint64(31))
+	assert.Equal(t, chainStats, stats.Total)
+}
@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// storeStrideHashes stores the hashes of the blocks of [from..to) at the multiples of WindowHashStride,
+// in ascending order before the hash of the window end
+func (p *Processor) storeStrideHashes(ctx context.Context, chain *chainState, from uint64, to uint64) error {
+	stride := chain.opts.WindowHashStride
+	if stride == 0 {
+		return nil
+	}
+
+	for number := (from + stride - 1) / stride * stride; number < to; number += stride {
+		var block types.Block
+		err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+			var err error
+			block, err = p.getBlock(ctx, chain, number)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		p.storeWindowHash(number, block.Hash, chain)
+	}
+	return nil
+}
+
+// previousHashedBlock returns the newest block below number with a stored hash
+func (c *chainState) previousHashedBlock(number uint64) (uint64, bool) {
+	for i := len(c.windowOrder) - 1; i >= 0; i-- {
+		if c.windowOrder[i] < number {
+			return c.windowOrder[i], true
+		}
+	}
+	return 0, false
+}
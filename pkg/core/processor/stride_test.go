@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowHashStride_StoresIntraWindowHashes(t *testing.T) {
+	var mu sync.Mutex
+	var fromBlocks []uint64
+	srv := newStateServer(t, &mu, &fromBlocks)
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:           50,
+		EndBlock:            100,
+		WindowHashStride:    10,
+		ReorgLookbackBlocks: 100,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, processor.Run(ctx))
+
+	chain := processor.chains["1"]
+	assert.Equal(t, []uint64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, chain.windowOrder)
+	assert.Equal(t, utils.Uint64ToHexQty(30), chain.storedWindowHash[30])
+}
+
+func TestWindowHashStride_FinerReorgResolution(t *testing.T) {
+	// Blocks above 250 were replaced by the reorg, block 251 builds on the canonical block 250
+	hash := func(number uint64) string {
+		if number > 250 {
+			return fmt.Sprintf("0xnew%d", number)
+		}
+		return fmt.Sprintf("0xcanonical%d", number)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_getBlockByNumber" {
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		number, err := utils.HexQtyToUint64(req.Params[0].(string))
+		assert.NoError(t, err)
+		result := map[string]any{"number": req.Params[0], "hash": hash(number), "parentHash": hash(number - 1)}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	ancestor := func(stride uint64) uint64 {
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+			RangeSize:           100,
+			WindowHashStride:    stride,
+			ReorgLookbackBlocks: 300,
+		}))
+		chain := processor.chains["1"]
+
+		// The hashes stored before the reorg, the ones above 250 are stale
+		step := uint64(100)
+		if stride > 0 {
+			step = stride
+		}
+		for number := step; number <= 300; number += step {
+			stored := hash(number)
+			if number > 250 {
+				stored = fmt.Sprintf("0xold%d", number)
+			}
+			processor.storeWindowHash(number, stored, chain)
+		}
+		chain.setCursor(300)
+
+		ancestor, err := processor.handleReorg(context.Background(), chain)
+		assert.NoError(t, err)
+		return ancestor
+	}
+
+	// Only the window ends: the reorg is resolved to the window end 200
+	assert.Equal(t, uint64(200), ancestor(0))
+	// A hash every 10 blocks resolves it to the fork point
+	assert.Equal(t, uint64(250), ancestor(10))
+}
@@ -0,0 +1,28 @@
+package processor
+
+import (
+	"time"
+)
+
+// logWindow logs the timings of a committed window at debug level, see Options.Logger
+func (c *chainState) logWindow(from uint64, to uint64, logs int, fetch time.Duration, commit time.Duration) {
+	if c.opts.Logger == nil {
+		return
+	}
+
+	blocks := to - from + 1
+	var blocksPerSecond float64
+	if fetch > 0 {
+		blocksPerSecond = float64(blocks) / fetch.Seconds()
+	}
+	c.opts.Logger.Debug("window committed",
+		"chain", c.chainInfo.ChainId,
+		"from", from,
+		"to", to,
+		"blocks", blocks,
+		"logs", logs,
+		"fetch", fetch,
+		"commit", commit,
+		"blocksPerSecond", blocksPerSecond,
+	)
+}
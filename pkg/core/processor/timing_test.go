@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler keeps the records of the enabled levels
+type recordingHandler struct {
+	level   slog.Level
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestLogWindow_DebugLevel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x20"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+		case "eth_getLogs":
+			result = []map[string]any{
+				{"address": "0xabc", "topics": []string{"0x01"}, "data": "0x", "blockNumber": req.Params[0].(map[string]any)["fromBlock"], "logIndex": "0x0"},
+			}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	// run indexes blocks 1 to 10 by windows of 4 with a logger at level
+	run := func(level slog.Level) []slog.Record {
+		handler := &recordingHandler{level: level}
+		processor := NewProcessor()
+		assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+			RangeSize:      4,
+			EndBlock:       10,
+			LogsBufferSize: 10,
+			Logger:         slog.New(handler),
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, processor.Run(ctx))
+
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return handler.records
+	}
+
+	records := run(slog.LevelDebug)
+	var windows [][2]uint64
+	for _, r := range records {
+		assert.Equal(t, slog.LevelDebug, r.Level)
+		assert.Equal(t, "window committed", r.Message)
+
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		assert.Equal(t, "1", attrs["chain"].String())
+		assert.Equal(t, int64(1), attrs["logs"].Int64())
+		assert.Equal(t, slog.KindDuration, attrs["fetch"].Kind())
+		assert.Equal(t, slog.KindDuration, attrs["commit"].Kind())
+		assert.Contains(t, attrs, "blocksPerSecond")
+		windows = append(windows, [2]uint64{attrs["from"].Uint64(), attrs["to"].Uint64()})
+	}
+	assert.Equal(t, [][2]uint64{{1, 4}, {5, 8}, {9, 10}}, windows)
+
+	// Off unless the handler enables debug
+	assert.Empty(t, run(slog.LevelInfo))
+}
@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"context"
+	"log"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// maxTipBlockPolls bounds the poll intervals waited for a block near the tip the node doesn't have yet
+const maxTipBlockPolls = 10
+
+// getCommitBlock fetches a block hashed by the arbiter with the retry config of the chain.
+// A block near the tip that isn't found, e.g. because a load balanced node lags behind the one that reported the head,
+// is polled again every PollInterval instead of failing the batch.
+// Near the tip means within the confirmations and a window of the last fetched head, deeper blocks are expected to exist.
+func (p *Processor) getCommitBlock(ctx context.Context, chain *chainState, number uint64) (types.Block, error) {
+	for polls := 0; ; polls++ {
+		var block types.Block
+		err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+			var err error
+			block, err = p.getBlock(ctx, chain, number)
+			return err
+		})
+		if err == nil || !errors.IsBlockNotFound(err) || !chain.nearTip(number) || polls >= maxTipBlockPolls {
+			return block, err
+		}
+
+		log.Printf("Chain %s block %d not available yet, waiting for it...\n", chain.chainInfo.ChainId, number)
+		select {
+		case <-ctx.Done():
+			return block, ctx.Err()
+		case <-chain.opts.Clock.After(chain.opts.PollInterval):
+		}
+	}
+}
+
+// nearTip reports whether number is within the confirmations and a window of the last fetched head
+func (c *chainState) nearTip(number uint64) bool {
+	depth := resolveConfirmations(c.chainInfo.ChainId, c.opts) + uint64(c.opts.RangeSize)
+	return number+depth >= c.head.Load()
+}
@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// TxLogs returns the logs of the transaction txHash on the chain matching its Topics, Addresses or Contracts,
+// e.g. to decode a transaction on demand. The chain RPC must implement rpc.TxReceiptsRPC.
+// It doesn't depend on the cursor and is safe to call while the processor is running.
+func (p *Processor) TxLogs(ctx context.Context, chainId string, txHash string) ([]types.Log, error) {
+	p.mu.RLock()
+	chain, exists := p.chains[chainId]
+	p.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("chain %s not found", chainId)
+	}
+
+	client, ok := chain.chainInfo.RPC.(rpc.TxReceiptsRPC)
+	if !ok {
+		return nil, fmt.Errorf("TxLogs requires an RPC implementing rpc.TxReceiptsRPC, got %T", chain.chainInfo.RPC)
+	}
+	logs, err := rpc.GetLogsByTx(ctx, client, txHash)
+	if chain.recordError("eth_getTransactionReceipt", 0, 0, chain.stats.recordRPC(err)) != nil {
+		return nil, err
+	}
+
+	var matching []types.Log
+	for _, log := range logs {
+		if p.matchesTopicFilter(log, chain) && p.matchesAddressFilter(log, chain) && (chain.contracts == nil || chain.contracts.matches(log)) {
+			matching = append(matching, log)
+		}
+	}
+	chain.tagChainId(matching)
+	return matching, nil
+}
+
+// DecodeTx decodes the logs of the transaction txHash matching the indexed events, in log order.
+// Like Run, logs that don't match the layout of their event are skipped.
+// It can be called without running the indexer.
+func (x *EventIndexer) DecodeTx(ctx context.Context, txHash string) ([]*types.Event, error) {
+	logs, err := x.processor.TxLogs(ctx, x.chainId, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*types.Event
+	for _, l := range logs {
+		event, err := x.decode(l)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReceiptServer serves the receipt of transaction 0xtx1: an Approval and a Transfer of token 0xabc,
+// a Transfer of token 0xdef and a log of an unknown event
+func newReceiptServer(t *testing.T) *httptest.Server {
+	transferTopic := utils.FunctionSignatureToTopic("Transfer(address,address,uint256)")
+	approvalTopic := utils.FunctionSignatureToTopic("Approval(address,address,uint256)")
+	owner := "0x000000000000000000000000" + "1111111111111111111111111111111111111111"
+	spender := "0x000000000000000000000000" + "2222222222222222222222222222222222222222"
+	amount := "0x" + "0000000000000000000000000000000000000000000000000000000000000064"
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method != "eth_getTransactionReceipt" {
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		if req.Params[0] == "0xtx1" {
+			log := func(address string, topics []string, index string) map[string]any {
+				return map[string]any{"address": address, "topics": topics, "data": amount, "blockNumber": "0x1", "transactionHash": "0xtx1", "logIndex": index}
+			}
+			result = map[string]any{"transactionHash": "0xtx1", "blockNumber": "0x1", "status": "0x1", "logs": []map[string]any{
+				log("0xabc", []string{approvalTopic, owner, spender}, "0x0"),
+				log("0xabc", []string{transferTopic, owner, spender}, "0x1"),
+				log("0xdef", []string{transferTopic, spender, owner}, "0x2"),
+				log("0xabc", []string{"0x01"}, "0x3"),
+			}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestTxLogs_AppliesFilters(t *testing.T) {
+	srv := newReceiptServer(t)
+	defer srv.Close()
+
+	processor := NewProcessor()
+	client := rpc.NewHTTPRPC(srv.URL, 0)
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: client}, &Options{RangeSize: 10}))
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "2", RPC: client}, &Options{
+		RangeSize: 10,
+		Topics:    []string{"Transfer(address,address,uint256)"},
+		Addresses: []string{"0xABC"},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Without filters every log of the transaction is returned
+	logs, err := processor.TxLogs(ctx, "1", "0xtx1")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 4)
+
+	logs, err = processor.TxLogs(ctx, "2", "0xtx1")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "0x1", logs[0].LogIndex)
+
+	_, err = processor.TxLogs(ctx, "1", "0xtx2")
+	assert.Error(t, err)
+	_, err = processor.TxLogs(ctx, "3", "0xtx1")
+	assert.Error(t, err)
+}
+
+func TestEventIndexer_DecodeTx(t *testing.T) {
+	srv := newReceiptServer(t)
+	defer srv.Close()
+
+	indexer, err := NewEventIndexer(
+		ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)},
+		&Options{RangeSize: 10},
+		map[string]string{
+			"Transfer(address,address,uint256)": transferEventABI,
+			"Approval(address,address,uint256)": approvalEventABI,
+		},
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := indexer.DecodeTx(ctx, "0xtx1")
+	assert.NoError(t, err)
+
+	// The unknown event isn't fetched by the topics filter
+	var eventTypes []string
+	for _, event := range events {
+		eventTypes = append(eventTypes, event.EventType)
+		assert.Equal(t, big.NewInt(100), event.Fields["value"])
+	}
+	assert.Equal(t, []string{"Approval", "Transfer", "Transfer"}, eventTypes)
+	assert.Equal(t, "0x2222222222222222222222222222222222222222", events[2].Fields["from"])
+}
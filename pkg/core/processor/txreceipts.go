@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultTxReceiptsConcurrency is the TxReceiptsConcurrency used when it is not set
+const defaultTxReceiptsConcurrency = 8
+
+// fetchLogsFromTxReceipts gets the logs of [from..to] from the receipt of every transaction,
+// fetched concurrently up to TxReceiptsConcurrency per block.
+// The logs keep the block, transaction and log order.
+func (p *Processor) fetchLogsFromTxReceipts(ctx context.Context, from uint64, to uint64, chain *chainState) ([]types.Log, error) {
+	// Checked by AddChain
+	client := chain.chainInfo.RPC.(rpc.TxReceiptsRPC)
+
+	var allLogs []types.Log
+	for blockNum := from; blockNum <= to; blockNum++ {
+		block, err := client.GetBlockWithTxs(ctx, utils.Uint64ToHexQty(blockNum))
+		if chain.recordError("eth_getBlockByNumber", blockNum, blockNum, chain.stats.recordRPC(err)) != nil {
+			return nil, fmt.Errorf("failed to get transactions of block %d: %w", blockNum, err)
+		}
+
+		// Each receipt has its slot so the block order doesn't depend on which call returns first
+		receipts := make([]types.Receipt, len(block.Transactions))
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(chain.opts.TxReceiptsConcurrency)
+		for i, tx := range block.Transactions {
+			g.Go(func() error {
+				receipt, err := client.GetTransactionReceipt(gctx, tx.Hash)
+				if chain.recordError("eth_getTransactionReceipt", blockNum, blockNum, chain.stats.recordRPC(err)) != nil {
+					return fmt.Errorf("failed to get receipt of transaction %s: %w", tx.Hash, err)
+				}
+				receipts[i] = receipt
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNum, err)
+		}
+
+		if chain.opts.ValidateReceipts {
+			if err := validateReceipts(receipts, blockNum); err != nil {
+				log.Println("Receipts validation failed: ", err)
+				chain.stats.receiptMismatches.Add(1)
+				return nil, err
+			}
+		}
+
+		allLogs = p.appendReceiptsLogs(allLogs, receipts, chain)
+	}
+	return allLogs, nil
+}
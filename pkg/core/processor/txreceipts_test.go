@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchModeTxReceipts(t *testing.T) {
+	const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	var inFlight, maxInFlight atomic.Int64
+
+	// Only the per transaction methods, eth_getLogs and eth_getBlockReceipts are not supported
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x2"
+		case "eth_getBlockByNumber":
+			blockNum, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			block := map[string]any{
+				"number":     req.Params[0],
+				"hash":       req.Params[0],
+				"parentHash": utils.Uint64ToHexQty(blockNum - 1),
+			}
+			if req.Params[1] == true {
+				txs := []map[string]any{}
+				for i := 0; i < 4; i++ {
+					txs = append(txs, map[string]any{
+						"hash":             fmt.Sprintf("0xtx%d_%d", blockNum, i),
+						"transactionIndex": utils.Uint64ToHexQty(uint64(i)),
+					})
+				}
+				block["transactions"] = txs
+			}
+			result = block
+		case "eth_getTransactionReceipt":
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				max := maxInFlight.Load()
+				if n <= max || maxInFlight.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			// Receipts complete out of order
+			time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+
+			var blockNum, txIndex int
+			_, err := fmt.Sscanf(req.Params[0].(string), "0xtx%d_%d", &blockNum, &txIndex)
+			assert.NoError(t, err)
+			// Two logs per transaction, only the Transfer of the token matches
+			result = map[string]any{
+				"blockHash":        utils.Uint64ToHexQty(uint64(blockNum)),
+				"blockNumber":      utils.Uint64ToHexQty(uint64(blockNum)),
+				"transactionHash":  req.Params[0],
+				"transactionIndex": utils.Uint64ToHexQty(uint64(txIndex)),
+				"type":             "0x2",
+				"logs": []map[string]any{
+					{
+						"address":         "0xToken",
+						"topics":          []any{transferTopic},
+						"blockNumber":     utils.Uint64ToHexQty(uint64(blockNum)),
+						"blockHash":       utils.Uint64ToHexQty(uint64(blockNum)),
+						"transactionHash": req.Params[0],
+						"logIndex":        utils.Uint64ToHexQty(uint64(txIndex * 2)),
+					},
+					{
+						"address":         "0xother",
+						"topics":          []any{transferTopic},
+						"blockNumber":     utils.Uint64ToHexQty(uint64(blockNum)),
+						"blockHash":       utils.Uint64ToHexQty(uint64(blockNum)),
+						"transactionHash": req.Params[0],
+						"logIndex":        utils.Uint64ToHexQty(uint64(txIndex*2 + 1)),
+					},
+				},
+			}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:             2,
+		EndBlock:              2,
+		FetchMode:             FetchModeTxReceipts,
+		TxReceiptsConcurrency: 2,
+		ValidateReceipts:      true,
+		Topics:                []string{transferTopic},
+		Addresses:             []string{"0xtoken"},
+		RetryConfig:           &rpc.RetryConfig{MaxAttempts: 1},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- processor.Run(ctx) }()
+
+	logsCh, err := processor.Logs("1")
+	assert.NoError(t, err)
+	var logs []types.Log
+	for l := range logsCh {
+		logs = append(logs, l)
+	}
+	assert.NoError(t, <-runErr)
+
+	// The token logs of every transaction, in block, transaction and log order
+	var expected []string
+	for block := 1; block <= 2; block++ {
+		for tx := 0; tx < 4; tx++ {
+			expected = append(expected, fmt.Sprintf("0xtx%d_%d", block, tx))
+		}
+	}
+	var got []string
+	for _, l := range logs {
+		assert.True(t, strings.EqualFold("0xtoken", l.Address))
+		got = append(got, l.TransactionHash)
+	}
+	assert.Equal(t, expected, got)
+	assert.LessOrEqual(t, maxInFlight.Load(), int64(2))
+}
+
+func TestFetchModeTxReceipts_RequiresTxReceiptsRPC(t *testing.T) {
+	processor := NewProcessor()
+	err := processor.AddChain(ChainInfo{ChainId: "1", RPC: &rpc.QuorumRPC{}}, &Options{
+		RangeSize: 2,
+		FetchMode: FetchModeTxReceipts,
+	})
+	assert.Error(t, err)
+}
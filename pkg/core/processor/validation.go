@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// validateReceipts checks that the receipts of a block and their logs all belong to blockNum,
+// share the same block hash, and that each log matches the transaction of its receipt.
+func validateReceipts(receipts []types.Receipt, blockNum uint64) error {
+	var blockHash string
+	for _, receipt := range receipts {
+		number, err := utils.HexQtyToUint64(receipt.BlockNumber)
+		if err != nil || number != blockNum {
+			return &errors.ConsistencyError{
+				Message: fmt.Sprintf("receipt %s has block number %q, requested block %d", receipt.TransactionHash, receipt.BlockNumber, blockNum),
+			}
+		}
+
+		if blockHash == "" {
+			blockHash = receipt.BlockHash
+		} else if !strings.EqualFold(blockHash, receipt.BlockHash) {
+			return &errors.ConsistencyError{
+				Message: fmt.Sprintf("receipts of block %d have different block hashes %s and %s", blockNum, blockHash, receipt.BlockHash),
+			}
+		}
+
+		for _, l := range receipt.Logs {
+			if err := validateReceiptLog(l, receipt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateReceiptLog checks a log against its receipt, empty log fields are not checked
+func validateReceiptLog(l types.Log, receipt types.Receipt) error {
+	mismatch := func(field, got, expected string) error {
+		return &errors.ConsistencyError{
+			Message: fmt.Sprintf("log %s of receipt %s has %s %q, expected %q", l.LogIndex, receipt.TransactionHash, field, got, expected),
+		}
+	}
+
+	if l.BlockNumber != "" && !sameQuantity(l.BlockNumber, receipt.BlockNumber) {
+		return mismatch("block number", l.BlockNumber, receipt.BlockNumber)
+	}
+	// A removed log carries the hash of the block it was removed from
+	if l.BlockHash != "" && !l.Removed && !strings.EqualFold(l.BlockHash, receipt.BlockHash) {
+		return mismatch("block hash", l.BlockHash, receipt.BlockHash)
+	}
+	if l.TransactionHash != "" && !strings.EqualFold(l.TransactionHash, receipt.TransactionHash) {
+		return mismatch("transaction hash", l.TransactionHash, receipt.TransactionHash)
+	}
+	if l.TransactionIndex != "" && !sameQuantity(l.TransactionIndex, receipt.TransactionIndex) {
+		return mismatch("transaction index", l.TransactionIndex, receipt.TransactionIndex)
+	}
+	return nil
+}
+
+// sameQuantity compares two hex quantities regardless of their formatting
+func sameQuantity(a, b string) bool {
+	x, err1 := utils.HexQtyToUint64(a)
+	y, err2 := utils.HexQtyToUint64(b)
+	if err1 != nil || err2 != nil {
+		return a == b
+	}
+	return x == y
+}
+
+// checkWindowBlockHash cross-checks the logs of blockNum with the header fetched for the reorg check.
+// It returns false when a log carries another block hash.
+func checkWindowBlockHash(logs []types.Log, blockNum uint64, header types.Block) bool {
+	for _, l := range logs {
+		number, err := utils.HexQtyToUint64(l.BlockNumber)
+		// A removed log carries the hash of the block it was removed from
+		if err != nil || number != blockNum || l.BlockHash == "" || l.Removed {
+			continue
+		}
+		if !strings.EqualFold(l.BlockHash, header.Hash) {
+			log.Printf("Log %s of block %d has block hash %s but header hash is %s", l.LogIndex, blockNum, l.BlockHash, header.Hash)
+			return false
+		}
+	}
+	return true
+}
+
+// checkWindowHashes cross-checks the logs of the window [from..to] with the header of every block they reference.
+// header is the header of from, already fetched for the reorg check.
+// It returns the first block whose logs carry another hash than its header, ok is false then.
+func (p *Processor) checkWindowHashes(ctx context.Context, chain *chainState, from uint64, to uint64, header types.Block, logs []types.Log) (mismatch uint64, ok bool, err error) {
+	numbers := make(map[uint64]struct{})
+	for _, l := range logs {
+		number, err := utils.HexQtyToUint64(l.BlockNumber)
+		if err == nil && number >= from && number <= to {
+			numbers[number] = struct{}{}
+		}
+	}
+	sorted := make([]uint64, 0, len(numbers))
+	for number := range numbers {
+		sorted = append(sorted, number)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, number := range sorted {
+		blockHeader := header
+		if number != from {
+			err := rpc.RetryWithBackoff(ctx, chain.retry(), func() error {
+				var err error
+				blockHeader, err = p.getBlock(ctx, chain, number)
+				return err
+			})
+			if err != nil {
+				return 0, false, err
+			}
+		}
+		if !checkWindowBlockHash(logs, number, blockHeader) {
+			return number, false, nil
+		}
+	}
+	return 0, true, nil
+}
+
+// receiptMismatch counts a mismatch of the window starting at from on block,
+// and returns an errors.ReceiptMismatchError once the window was fetched WindowRetries more times
+func (c *chainState) receiptMismatch(from uint64, block uint64) error {
+	if c.mismatchWindow != from {
+		c.mismatchWindow, c.mismatchCount = from, 0
+	}
+	c.mismatchCount++
+	if c.mismatchCount <= c.opts.WindowRetries {
+		log.Printf("Logs of block %d disagree with its header, fetching window from block %d again (%d/%d)\n", block, from, c.mismatchCount, c.opts.WindowRetries)
+		return nil
+	}
+	attempts := c.mismatchCount
+	c.mismatchCount = 0
+	return &errors.ReceiptMismatchError{Block: block, Attempts: attempts}
+}
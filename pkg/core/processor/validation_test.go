@@ -0,0 +1,230 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/rpc"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchLogsFromReceipts_BlockNumberMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				{
+					// Requested block 0x5 but the provider answers with block 0x4
+					"blockHash":   "0xbh4",
+					"blockNumber": "0x4",
+					"logs": []map[string]any{
+						{
+							"address":     "0xabc",
+							"topics":      []any{"0xddf252ad"},
+							"blockNumber": "0x4",
+							"blockHash":   "0xbh4",
+						},
+					},
+					"transactionHash":  "0xth1",
+					"transactionIndex": "0x0",
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	chain := ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}
+	retry := rpc.RetryConfig{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+
+	// Without validation the logs are trusted
+	processor := NewProcessor()
+	processor.AddChain(chain, &Options{RangeSize: 10, FetchMode: FetchModeReceipts, RetryConfig: &retry})
+	logs, err := processor.fetchLogsFromReceipts(context.Background(), 5, 5, processor.chains[chain.ChainId])
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+
+	processor = NewProcessor()
+	processor.AddChain(chain, &Options{RangeSize: 10, FetchMode: FetchModeReceipts, ValidateReceipts: true, RetryConfig: &retry})
+	_, err = processor.fetchLogsFromReceipts(context.Background(), 5, 5, processor.chains[chain.ChainId])
+	assert.Error(t, err)
+
+	var consistencyErr *errors.ConsistencyError
+	assert.ErrorAs(t, err, &consistencyErr)
+	assert.True(t, errors.IsRetryableError(err))
+	assert.Equal(t, uint64(1), processor.Stats().Chains[chain.ChainId].ReceiptMismatches)
+}
+
+func TestValidateReceipts(t *testing.T) {
+	receipt := types.Receipt{
+		BlockHash:        "0xbh1",
+		BlockNumber:      "0x1",
+		TransactionHash:  "0xth1",
+		TransactionIndex: "0x0",
+		Logs: []types.Log{
+			{BlockNumber: "0x1", BlockHash: "0xBH1", TransactionHash: "0xth1", TransactionIndex: "0x00"},
+		},
+	}
+	assert.NoError(t, validateReceipts([]types.Receipt{receipt}, 1))
+
+	otherHash := receipt
+	otherHash.BlockHash = "0xbh2"
+	assert.Error(t, validateReceipts([]types.Receipt{receipt, otherHash}, 1))
+
+	wrongTxIndex := receipt
+	wrongTxIndex.Logs = []types.Log{{BlockNumber: "0x1", TransactionIndex: "0x3"}}
+	assert.Error(t, validateReceipts([]types.Receipt{wrongTxIndex}, 1))
+}
+
+func TestCheckWindowBlockHash(t *testing.T) {
+	header := types.Block{Number: "0x1", Hash: "0xbh1"}
+
+	logs := []types.Log{
+		{BlockNumber: "0x1", BlockHash: "0xbh1"},
+		{BlockNumber: "0x2", BlockHash: "0xother"}, // not the checked block
+	}
+	assert.True(t, checkWindowBlockHash(logs, 1, header))
+
+	logs = append(logs, types.Log{BlockNumber: "0x1", BlockHash: "0xstale"})
+	assert.False(t, checkWindowBlockHash(logs, 1, header))
+}
+
+// newReceiptsServer serves blocks up to 3 with one log per block in its receipts.
+// headerHash gives the header hash of a block on its nth fetch, logHash the block hash carried by its log.
+func newReceiptsServer(t *testing.T, headerHash func(block uint64, fetch int) string, logHash func(block uint64) string) *httptest.Server {
+	var mu sync.Mutex
+	fetches := make(map[uint64]int)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x3"
+		case "eth_getBlockByNumber":
+			number, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			mu.Lock()
+			fetches[number]++
+			fetch := fetches[number]
+			mu.Unlock()
+			result = map[string]any{
+				"number":     req.Params[0],
+				"hash":       headerHash(number, fetch),
+				"parentHash": utils.Uint64ToHexQty(number - 1),
+			}
+		case "eth_getBlockReceipts":
+			number, err := utils.HexQtyToUint64(req.Params[0].(string))
+			assert.NoError(t, err)
+			hash := logHash(number)
+			result = []map[string]any{{
+				"blockHash":        hash,
+				"blockNumber":      req.Params[0],
+				"transactionHash":  "0xth" + req.Params[0].(string),
+				"transactionIndex": "0x0",
+				"status":           "0x1",
+				"logs": []map[string]any{{
+					"address":          "0xabc",
+					"topics":           []any{"0xddf252ad"},
+					"blockNumber":      req.Params[0],
+					"blockHash":        hash,
+					"transactionHash":  "0xth" + req.Params[0].(string),
+					"transactionIndex": "0x0",
+					"logIndex":         "0x0",
+				}},
+			}}
+		default:
+			http.Error(w, "method no supported", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+}
+
+func TestValidateReceipts_MismatchStopsAfterWindowRetries(t *testing.T) {
+	// The receipts of block 2 always carry another hash than its header
+	srv := newReceiptsServer(t,
+		func(block uint64, _ int) string { return utils.Uint64ToHexQty(block) },
+		func(block uint64) string {
+			if block == 2 {
+				return "0xstale"
+			}
+			return utils.Uint64ToHexQty(block)
+		})
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:        3,
+		EndBlock:         3,
+		LogsBufferSize:   10,
+		FetchMode:        FetchModeReceipts,
+		ValidateReceipts: true,
+		WindowRetries:    1,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Block 2 isn't the first of the window, it is checked against its own header
+	err := processor.Run(ctx)
+	var mismatchErr *errors.ReceiptMismatchError
+	if assert.ErrorAs(t, err, &mismatchErr) {
+		assert.Equal(t, uint64(2), mismatchErr.Block)
+		assert.Equal(t, 2, mismatchErr.Attempts)
+	}
+	assert.Equal(t, uint64(2), processor.Stats().Chains["1"].ReceiptMismatches)
+	watermark, err := processor.Watermark("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), watermark)
+}
+
+func TestValidateReceipts_RefetchesCachedHeader(t *testing.T) {
+	// The first header of block 2 is stale, the receipts are right
+	srv := newReceiptsServer(t,
+		func(block uint64, fetch int) string {
+			if block == 2 && fetch == 1 {
+				return "0xstale"
+			}
+			return utils.Uint64ToHexQty(block)
+		},
+		func(block uint64) string { return utils.Uint64ToHexQty(block) })
+	defer srv.Close()
+
+	processor := NewProcessor()
+	assert.NoError(t, processor.AddChain(ChainInfo{ChainId: "1", RPC: rpc.NewHTTPRPC(srv.URL, 0)}, &Options{
+		RangeSize:        3,
+		EndBlock:         3,
+		LogsBufferSize:   10,
+		FetchMode:        FetchModeReceipts,
+		ValidateReceipts: true,
+		WindowRetries:    1,
+		// The stale header must not be served again from the cache
+		BlockCacheSize: 16,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, processor.Run(ctx))
+	assert.Equal(t, uint64(1), processor.Stats().Chains["1"].ReceiptMismatches)
+	watermark, err := processor.Watermark("1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), watermark)
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy decides how long to wait before retrying a failed call.
+// NextDelay is called once attempt calls have failed, the last one with lastErr,
+// and returns the wait before the next call, or false to stop retrying.
+// lastErr lets a strategy honor provider hints, e.g. a rate limit reset time.
+type BackoffStrategy interface {
+	NextDelay(attempt int, lastErr error) (time.Duration, bool)
+}
+
+// ExponentialBackoff multiplies the wait by Multiplier after every failed attempt, up to MaxBackoff.
+// It is the strategy of a RetryConfig without Strategy.
+type ExponentialBackoff struct {
+	// MaxAttempts is the maximum number of calls, including the first one
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the wait increases after each retry
+	Multiplier float64
+	// EnableJitter adds up to a quarter of the wait at random, to spread the retries of concurrent callers
+	EnableJitter bool
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int, lastErr error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+
+	// Compare as float so a large attempt can't overflow the duration
+	backoff := float64(b.InitialBackoff) * math.Pow(b.Multiplier, float64(attempt-1))
+	wait := b.InitialBackoff
+	if attempt > 1 {
+		wait = time.Duration(backoff)
+		if backoff > float64(b.MaxBackoff) {
+			wait = b.MaxBackoff
+		}
+	}
+
+	if b.EnableJitter && wait/4 > 0 {
+		wait += time.Duration(rand.Int63n(int64(wait / 4)))
+	}
+	return wait, true
+}
+
+// ConstantBackoff waits Delay between every attempt
+type ConstantBackoff struct {
+	// MaxAttempts is the maximum number of calls, including the first one
+	MaxAttempts int
+	// Delay is the wait before every retry
+	Delay time.Duration
+}
+
+func (b ConstantBackoff) NextDelay(attempt int, lastErr error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	return b.Delay, true
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of the retry and poll loops.
+// Inject a FakeClock to test backoff and poll intervals without waiting.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// RealClock is the Clock of the time package
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (RealClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// FakeClock is a virtual Clock for tests.
+// Waits return immediately and advance the virtual time by their duration,
+// the durations are recorded so tests can assert the exact wait sequence.
+type FakeClock struct {
+	now   time.Time
+	waits []time.Duration
+	mu    sync.Mutex
+}
+
+// NewFakeClock returns a FakeClock whose virtual time starts at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After advances the virtual time by d and returns a channel that already holds the new time
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.advance(d)
+	return ch
+}
+
+// Sleep advances the virtual time by d without waiting
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.advance(d)
+}
+
+// Waits returns the durations of the waits so far, in order
+func (c *FakeClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+func (c *FakeClock) advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// clockOrReal returns clock, or the real clock when nil
+func clockOrReal(clock Clock) Clock {
+	if clock == nil {
+		return RealClock{}
+	}
+	return clock
+}
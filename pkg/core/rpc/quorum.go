@@ -0,0 +1,245 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// Divergence describes a call where at least one provider disagreed with the quorum
+type Divergence struct {
+	// RPC method where the divergence happened
+	Method string
+	// Params of the call, e.g. the filter for GetLogs
+	Params any
+	// Indexes of the providers that agreed with the returned response
+	Agreed []int
+	// Indexes of the providers that returned a different response
+	Diverged []int
+	// Errors of the providers that failed, with the provider index as key
+	Errors map[int]error
+}
+
+// QuorumRPC queries M independent providers and only returns a response when at least K of them agree.
+// Unlike a failover client it guards against a single provider silently dropping or fabricating logs.
+// Head is the exception since providers are rarely at the same height,
+// it returns the highest block that at least K providers have reached.
+type QuorumRPC struct {
+	// providers are queried concurrently for every call
+	providers []RPC
+	// quorum is the minimum number of providers that must agree
+	quorum int
+	// OnDivergence is called when at least two providers return different results, even if the quorum is reached.
+	// Failed providers alone are not a divergence, their errors are only reported along with one.
+	// Use it to flag suspicious windows, optional.
+	OnDivergence func(Divergence)
+}
+
+// NewQuorumRPC creates a client requiring quorum agreeing providers out of providers.
+func NewQuorumRPC(quorum int, providers ...RPC) (*QuorumRPC, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one provider is required")
+	}
+	if quorum <= 0 || quorum > len(providers) {
+		return nil, fmt.Errorf("invalid quorum %d for %d providers", quorum, len(providers))
+	}
+	return &QuorumRPC{
+		providers: providers,
+		quorum:    quorum,
+	}, nil
+}
+
+type providerResult[T any] struct {
+	value T
+	err   error
+}
+
+// queryAll calls every provider concurrently and returns the results in provider order
+func queryAll[T any](ctx context.Context, providers []RPC, call func(ctx context.Context, r RPC) (T, error)) []providerResult[T] {
+	results := make([]providerResult[T], len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider RPC) {
+			defer wg.Done()
+			v, err := call(ctx, provider)
+			results[i] = providerResult[T]{value: v, err: err}
+		}(i, provider)
+	}
+	wg.Wait()
+	return results
+}
+
+// agree groups the successful results by fingerprint and returns the value of the largest group
+// if it reaches the quorum. Differing successful results are reported to OnDivergence.
+func agree[T any](q *QuorumRPC, method string, params any, results []providerResult[T], fingerprint func(T) string) (T, error) {
+	var zero T
+
+	groups := make(map[string][]int)
+	failed := make(map[int]error)
+	for i, res := range results {
+		if res.err != nil {
+			failed[i] = res.err
+			continue
+		}
+		key := fingerprint(res.value)
+		groups[key] = append(groups[key], i)
+	}
+
+	var best []int
+	for _, idx := range groups {
+		if len(idx) > len(best) || (len(idx) == len(best) && len(idx) > 0 && idx[0] < best[0]) {
+			best = idx
+		}
+	}
+
+	if len(groups) > 1 && q.OnDivergence != nil {
+		var diverged []int
+		for i := range results {
+			if _, ok := failed[i]; ok {
+				continue
+			}
+			if !containsIndex(best, i) {
+				diverged = append(diverged, i)
+			}
+		}
+		q.OnDivergence(Divergence{
+			Method:   method,
+			Params:   params,
+			Agreed:   best,
+			Diverged: diverged,
+			Errors:   failed,
+		})
+	}
+
+	if len(best) < q.quorum {
+		// When every provider failed, surface one error so retry can classify it
+		if len(failed) == len(results) {
+			return zero, failed[0]
+		}
+		return zero, &errors.QuorumError{
+			Method:    method,
+			Quorum:    q.quorum,
+			Agreed:    len(best),
+			Providers: len(results),
+		}
+	}
+
+	return results[best[0]].value, nil
+}
+
+func containsIndex(idx []int, i int) bool {
+	for _, v := range idx {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// Head returns the highest block reached by at least quorum providers
+func (q *QuorumRPC) Head(ctx context.Context) (string, error) {
+	results := queryAll(ctx, q.providers, func(ctx context.Context, r RPC) (string, error) {
+		return r.Head(ctx)
+	})
+
+	var heads []uint64
+	var lastErr error
+	for _, res := range results {
+		if res.err != nil {
+			lastErr = res.err
+			continue
+		}
+		head, err := utils.HexQtyToUint64(res.value)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		heads = append(heads, head)
+	}
+
+	if len(heads) < q.quorum {
+		if len(heads) == 0 && lastErr != nil {
+			return "", lastErr
+		}
+		return "", &errors.QuorumError{
+			Method:    "eth_blockNumber",
+			Quorum:    q.quorum,
+			Agreed:    len(heads),
+			Providers: len(q.providers),
+		}
+	}
+
+	sort.Slice(heads, func(i, j int) bool { return heads[i] > heads[j] })
+	return utils.Uint64ToHexQty(heads[q.quorum-1]), nil
+}
+
+// ChainID returns the chain id reported by at least quorum providers, each provider must implement ChainIDRPC
+func (q *QuorumRPC) ChainID(ctx context.Context) (string, error) {
+	results := queryAll(ctx, q.providers, func(ctx context.Context, r RPC) (string, error) {
+		provider, ok := r.(ChainIDRPC)
+		if !ok {
+			return "", fmt.Errorf("provider %T doesn't report its chain id", r)
+		}
+		return provider.ChainID(ctx)
+	})
+	return agree(q, "eth_chainId", nil, results, func(chainId string) string {
+		return strings.ToLower(chainId)
+	})
+}
+
+func (q *QuorumRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block, error) {
+	results := queryAll(ctx, q.providers, func(ctx context.Context, r RPC) (types.Block, error) {
+		return r.GetBlock(ctx, blockNumber)
+	})
+	return agree(q, "eth_getBlockByNumber", blockNumber, results, func(b types.Block) string {
+		return b.Hash + ":" + b.ParentHash
+	})
+}
+
+func (q *QuorumRPC) GetLogs(ctx context.Context, filter types.Filter) ([]types.Log, error) {
+	results := queryAll(ctx, q.providers, func(ctx context.Context, r RPC) ([]types.Log, error) {
+		return r.GetLogs(ctx, filter)
+	})
+	return agree(q, "eth_getLogs", filter, results, logsFingerprint)
+}
+
+func (q *QuorumRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]types.Receipt, error) {
+	results := queryAll(ctx, q.providers, func(ctx context.Context, r RPC) ([]types.Receipt, error) {
+		return r.GetBlockReceipts(ctx, blockNumber)
+	})
+	return agree(q, "eth_getBlockReceipts", blockNumber, results, func(receipts []types.Receipt) string {
+		var logs []types.Log
+		keys := make([]string, 0, len(receipts))
+		for _, receipt := range receipts {
+			keys = append(keys, receipt.TransactionHash+":"+receipt.Status)
+			logs = append(logs, receipt.Logs...)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, "|") + "#" + logsFingerprint(logs)
+	})
+}
+
+// logsFingerprint identifies a log set independently of the order returned by the provider.
+// The content is part of the identity so a fabricated or altered log is a divergence.
+func logsFingerprint(logs []types.Log) string {
+	keys := make([]string, len(logs))
+	for i, l := range logs {
+		keys[i] = strings.Join([]string{
+			strings.ToLower(l.BlockHash),
+			strings.ToLower(l.TransactionHash),
+			l.LogIndex,
+			strings.ToLower(l.Address),
+			strings.Join(l.Topics, ","),
+			strings.ToLower(l.Data),
+		}, ":")
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "|")
+}
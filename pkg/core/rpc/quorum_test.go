@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogsServer(logs []map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  logs,
+		})
+	}))
+}
+
+func quorumTestLog(logIndex string, data string) map[string]any {
+	return map[string]any{
+		"address":         "0xtoken",
+		"topics":          []any{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		"data":            data,
+		"blockNumber":     "0x1",
+		"transactionHash": "0xtx1",
+		"blockHash":       "0xblock1",
+		"logIndex":        logIndex,
+	}
+}
+
+func TestQuorumRPC_GetLogs_DivergentProviderFlagged(t *testing.T) {
+	honest := []map[string]any{quorumTestLog("0x0", "0x01"), quorumTestLog("0x1", "0x02")}
+	// Same logs in another order must still agree
+	reordered := []map[string]any{quorumTestLog("0x1", "0x02"), quorumTestLog("0x0", "0x01")}
+	// Drops one log and fabricates another
+	divergent := []map[string]any{quorumTestLog("0x0", "0x01"), quorumTestLog("0x2", "0xff")}
+
+	srv1 := newLogsServer(honest)
+	defer srv1.Close()
+	srv2 := newLogsServer(divergent)
+	defer srv2.Close()
+	srv3 := newLogsServer(reordered)
+	defer srv3.Close()
+
+	q, err := NewQuorumRPC(2, NewHTTPRPC(srv1.URL, 0), NewHTTPRPC(srv2.URL, 0), NewHTTPRPC(srv3.URL, 0))
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var divergences []Divergence
+	q.OnDivergence = func(d Divergence) {
+		mu.Lock()
+		divergences = append(divergences, d)
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	filter := types.Filter{FromBlock: "0x1", ToBlock: "0xa"}
+	logs, err := q.GetLogs(ctx, filter)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "0x0", logs[0].LogIndex)
+	assert.Equal(t, "0x1", logs[1].LogIndex)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, divergences, 1)
+	assert.Equal(t, "eth_getLogs", divergences[0].Method)
+	assert.Equal(t, filter, divergences[0].Params)
+	assert.Equal(t, []int{0, 2}, divergences[0].Agreed)
+	assert.Equal(t, []int{1}, divergences[0].Diverged)
+}
+
+func TestQuorumRPC_GetLogs_FailedProviderIsNotDivergence(t *testing.T) {
+	logs := []map[string]any{quorumTestLog("0x0", "0x01")}
+	srv1 := newLogsServer(logs)
+	defer srv1.Close()
+	srv2 := newLogsServer(logs)
+	defer srv2.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer down.Close()
+
+	var mu sync.Mutex
+	calls := 0
+	onDivergence := func(Divergence) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	filter := types.Filter{FromBlock: "0x1", ToBlock: "0xa"}
+
+	// The providers answering agree
+	q, err := NewQuorumRPC(2, NewHTTPRPC(srv1.URL, 0), NewHTTPRPC(down.URL, 0), NewHTTPRPC(srv2.URL, 0))
+	assert.NoError(t, err)
+	q.OnDivergence = onDivergence
+	_, err = q.GetLogs(ctx, filter)
+	assert.NoError(t, err)
+
+	// Nothing to compare when every provider fails
+	q, err = NewQuorumRPC(1, NewHTTPRPC(down.URL, 0), NewHTTPRPC(down.URL, 0))
+	assert.NoError(t, err)
+	q.OnDivergence = onDivergence
+	_, err = q.GetLogs(ctx, filter)
+	assert.Error(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 0, calls)
+}
+
+func TestQuorumRPC_GetLogs_QuorumNotReached(t *testing.T) {
+	srv1 := newLogsServer([]map[string]any{quorumTestLog("0x0", "0x01")})
+	defer srv1.Close()
+	srv2 := newLogsServer([]map[string]any{quorumTestLog("0x0", "0x02")})
+	defer srv2.Close()
+	srv3 := newLogsServer([]map[string]any{})
+	defer srv3.Close()
+
+	q, err := NewQuorumRPC(2, NewHTTPRPC(srv1.URL, 0), NewHTTPRPC(srv2.URL, 0), NewHTTPRPC(srv3.URL, 0))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = q.GetLogs(ctx, types.Filter{FromBlock: "0x1", ToBlock: "0xa"})
+	assert.Error(t, err)
+
+	var quorumErr *errors.QuorumError
+	assert.ErrorAs(t, err, &quorumErr)
+	assert.Equal(t, 1, quorumErr.Agreed)
+	assert.Equal(t, 2, quorumErr.Quorum)
+}
+
+func TestQuorumRPC_Head(t *testing.T) {
+	newHeadServer := func(head string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"result":  head,
+			})
+		}))
+	}
+	srv1 := newHeadServer("0x64")
+	defer srv1.Close()
+	srv2 := newHeadServer("0x66")
+	defer srv2.Close()
+	srv3 := newHeadServer("0x60")
+	defer srv3.Close()
+
+	q, err := NewQuorumRPC(2, NewHTTPRPC(srv1.URL, 0), NewHTTPRPC(srv2.URL, 0), NewHTTPRPC(srv3.URL, 0))
+	assert.NoError(t, err)
+
+	head, err := q.Head(context.Background())
+	assert.NoError(t, err)
+	// Highest block reached by at least 2 providers
+	assert.Equal(t, "0x64", head)
+}
+
+func TestNewQuorumRPC_InvalidQuorum(t *testing.T) {
+	_, err := NewQuorumRPC(2)
+	assert.Error(t, err)
+
+	_, err = NewQuorumRPC(3, NewHTTPRPC("http://localhost", 0), NewHTTPRPC("http://localhost", 0))
+	assert.Error(t, err)
+}
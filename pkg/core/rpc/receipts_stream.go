@@ -0,0 +1,185 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// FilteredReceiptsRPC fetches the receipts of a block keeping only the logs matching a filter.
+// It is optional, the processor Options.StreamReceipts uses it to bound the memory of the receipts modes on busy blocks.
+type FilteredReceiptsRPC interface {
+	// Get the receipts of the block, every receipt keeps only the logs for which keep returns true
+	GetBlockReceiptsFiltered(ctx context.Context, blockNumber string, keep func(types.Log) bool) ([]types.Receipt, error)
+}
+
+// GetBlockReceiptsFiltered is GetBlockReceipts parsing the response as a stream, one receipt at a time,
+// and dropping the logs rejected by keep as soon as their receipt is parsed.
+// Only the matching logs are retained instead of the whole response and every log of the block.
+func (r *HTTPRPC) GetBlockReceiptsFiltered(ctx context.Context, blockNumber string, keep func(types.Log) bool) ([]types.Receipt, error) {
+	res, err := r.post(ctx, "eth_getBlockReceipts", blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return decodeReceiptsStream("eth_getBlockReceipts", res.Body, keep)
+}
+
+// decodeReceiptsStream reads a JSON-RPC response of eth_getBlockReceipts from body, see decodeReceiptsResult for the accepted results
+func decodeReceiptsStream(method string, body io.Reader, keep func(types.Log) bool) ([]types.Receipt, error) {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var receipts []types.Receipt
+	var resultErr error
+	var rpcErr *errors.RPCError
+	seenResult := false
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		}
+		switch key {
+		case "result":
+			seenResult = true
+			receipts, resultErr = streamReceipts(method, dec, keep)
+			// The stream can't be resumed after a malformed result
+			if resultErr != nil && !isShapeError(resultErr) {
+				return nil, resultErr
+			}
+		case "error":
+			if err := dec.Decode(&rpcErr); err != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+		}
+	}
+
+	if rpcErr != nil {
+		return nil, &errors.RPCError{
+			Code: rpcErr.Code,
+			Message: rpcErr.Message,
+		}
+	}
+	if !seenResult {
+		return nil, &errors.ResultShapeError{Method: method, Expected: "array", Got: "null"}
+	}
+	if resultErr != nil {
+		return nil, resultErr
+	}
+	return receipts, nil
+}
+
+// streamReceipts reads the result, the array is decoded one receipt at a time.
+// A result wrapping the array in an object is rare so it is read at once.
+func streamReceipts(method string, dec *json.Decoder, keep func(types.Log) bool) ([]types.Receipt, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	switch token {
+	case json.Delim('['):
+		receipts := []types.Receipt{}
+		for dec.More() {
+			var receipt types.Receipt
+			if err := dec.Decode(&receipt); err != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+			receipt.Logs = filterLogs(receipt.Logs, keep)
+			receipts = append(receipts, receipt)
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+		return receipts, nil
+
+	case json.Delim('{'):
+		wrapper := make(map[string]json.RawMessage)
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+			wrapper[fmt.Sprint(key)] = value
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(wrapper)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		}
+		receipts, err := decodeReceiptsResult(method, raw)
+		if err != nil {
+			return nil, err
+		}
+		for i := range receipts {
+			receipts[i].Logs = filterLogs(receipts[i].Logs, keep)
+		}
+		return receipts, nil
+
+	case nil:
+		return nil, &errors.ResultShapeError{Method: method, Expected: "array", Got: "null"}
+	default:
+		shape := "number"
+		switch token.(type) {
+		case string:
+			shape = "string"
+		case bool:
+			shape = "boolean"
+		}
+		return nil, &errors.ResultShapeError{Method: method, Expected: "array", Got: shape}
+	}
+}
+
+// filterLogs keeps the logs for which keep returns true, in place
+func filterLogs(logs []types.Log, keep func(types.Log) bool) []types.Log {
+	if keep == nil {
+		return logs
+	}
+	kept := logs[:0]
+	for _, log := range logs {
+		if keep(log) {
+			kept = append(kept, log)
+		}
+	}
+	// Release the backing array when nothing matches, a busy block has many receipts without matching logs
+	if len(kept) == 0 && len(logs) > 0 {
+		return nil
+	}
+	return kept
+}
+
+// expectDelim reads the next token of dec, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("error reading response body: expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// isShapeError tells whether err is a ResultShapeError
+func isShapeError(err error) bool {
+	_, ok := err.(*errors.ResultShapeError)
+	return ok
+}
@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticReceipts builds the receipts of a busy block, logsPerReceipt logs each.
+// One log in 20 is emitted by 0xwatched, the others by 0xother.
+func syntheticReceipts(count, logsPerReceipt int) []map[string]any {
+	receipts := make([]map[string]any, 0, count)
+	logIndex := 0
+	for tx := 0; tx < count; tx++ {
+		txHash := fmt.Sprintf("0x%064x", tx)
+		logs := make([]map[string]any, 0, logsPerReceipt)
+		for i := 0; i < logsPerReceipt; i++ {
+			address := "0xother"
+			if logIndex%20 == 0 {
+				address = "0xwatched"
+			}
+			logs = append(logs, map[string]any{
+				"address":          address,
+				"topics":           []string{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", fmt.Sprintf("0x%064x", tx), fmt.Sprintf("0x%064x", i)},
+				"data":             "0x0000000000000000000000000000000000000000000000000de0b6b3a7640000",
+				"blockNumber":      "0x1",
+				"blockHash":        "0xblock",
+				"transactionHash":  txHash,
+				"transactionIndex": fmt.Sprintf("0x%x", tx),
+				"logIndex":         fmt.Sprintf("0x%x", logIndex),
+			})
+			logIndex++
+		}
+		receipts = append(receipts, map[string]any{
+			"blockHash":        "0xblock",
+			"blockNumber":      "0x1",
+			"from":             "0xsender",
+			"to":               "0xother",
+			"gasUsed":          "0x5208",
+			"status":           "0x1",
+			"transactionHash":  txHash,
+			"transactionIndex": fmt.Sprintf("0x%x", tx),
+			"type":             "0x2",
+			"logs":             logs,
+		})
+	}
+	return receipts
+}
+
+func keepWatched(log types.Log) bool {
+	return log.Address == "0xwatched"
+}
+
+func newReceiptsServer(t testing.TB, response map[string]any) *httptest.Server {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+}
+
+func TestGetBlockReceiptsFiltered_MatchesNonStreaming(t *testing.T) {
+	receipts := syntheticReceipts(50, 4)
+	responses := map[string]map[string]any{
+		"array":   {"jsonrpc": "2.0", "id": 1, "result": receipts},
+		"wrapped": {"jsonrpc": "2.0", "id": 1, "result": map[string]any{"receipts": receipts}},
+		// result before the envelope fields
+		"reordered": {"result": receipts, "id": 1, "jsonrpc": "2.0"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			srv := newReceiptsServer(t, response)
+			defer srv.Close()
+			client := NewHTTPRPC(srv.URL, 0)
+
+			expected, err := client.GetBlockReceipts(ctx, "0x1")
+			assert.NoError(t, err)
+			for i := range expected {
+				expected[i].Logs = filterLogs(expected[i].Logs, keepWatched)
+			}
+
+			got, err := client.GetBlockReceiptsFiltered(ctx, "0x1", keepWatched)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, got)
+
+			var kept int
+			for _, receipt := range got {
+				kept += len(receipt.Logs)
+			}
+			assert.Equal(t, 10, kept)
+		})
+	}
+}
+
+func TestGetBlockReceiptsFiltered_Errors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// RPC error, with or without a null result
+	for _, response := range []map[string]any{
+		{"jsonrpc": "2.0", "id": 1, "error": map[string]any{"code": -32000, "message": "block not found"}},
+		{"jsonrpc": "2.0", "id": 1, "result": nil, "error": map[string]any{"code": -32000, "message": "block not found"}},
+	} {
+		srv := newReceiptsServer(t, response)
+		_, err := NewHTTPRPC(srv.URL, 0).GetBlockReceiptsFiltered(ctx, "0x1", keepWatched)
+		srv.Close()
+		var rpcErr *errors.RPCError
+		assert.ErrorAs(t, err, &rpcErr)
+	}
+
+	// Unexpected results
+	for _, result := range []any{nil, "0x1", map[string]any{"count": 1}} {
+		srv := newReceiptsServer(t, map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		_, err := NewHTTPRPC(srv.URL, 0).GetBlockReceiptsFiltered(ctx, "0x1", keepWatched)
+		srv.Close()
+		var shapeErr *errors.ResultShapeError
+		assert.ErrorAs(t, err, &shapeErr)
+	}
+
+	// Truncated response
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":[{"blockNumber":"0x1","logs":[`))
+	}))
+	defer srv.Close()
+	_, err := NewHTTPRPC(srv.URL, 0).GetBlockReceiptsFiltered(ctx, "0x1", keepWatched)
+	assert.True(t, err != nil && strings.Contains(err.Error(), "error reading response body"))
+}
+
+func benchmarkReceipts(b *testing.B, fetch func(ctx context.Context, client *HTTPRPC) ([]types.Receipt, error)) {
+	srv := newReceiptsServer(b, map[string]any{"jsonrpc": "2.0", "id": 1, "result": syntheticReceipts(2000, 4)})
+	defer srv.Close()
+	client := NewHTTPRPC(srv.URL, 0)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fetch(ctx, client); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetBlockReceipts_Large(b *testing.B) {
+	benchmarkReceipts(b, func(ctx context.Context, client *HTTPRPC) ([]types.Receipt, error) {
+		receipts, err := client.GetBlockReceipts(ctx, "0x1")
+		for i := range receipts {
+			receipts[i].Logs = filterLogs(receipts[i].Logs, keepWatched)
+		}
+		return receipts, err
+	})
+}
+
+func BenchmarkGetBlockReceiptsFiltered_Large(b *testing.B) {
+	benchmarkReceipts(b, func(ctx context.Context, client *HTTPRPC) ([]types.Receipt, error) {
+		return client.GetBlockReceiptsFiltered(ctx, "0x1", keepWatched)
+	})
+}
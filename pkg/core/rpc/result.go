@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// jsonShape returns the JSON kind of raw: "null", "object", "array", "string", "number" or "boolean".
+// An empty raw, i.e. a response without result, is null.
+func jsonShape(raw json.RawMessage) string {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return "null"
+	}
+	switch trimmed[0] {
+	case 'n':
+		return "null"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+// decodeBlockResult decodes the result of eth_getBlockByNumber.
+// A null result, returned for a block the node doesn't have, is an error rather than a zero block.
+func decodeBlockResult(method string, raw json.RawMessage) (types.Block, error) {
+	var block types.Block
+	if shape := jsonShape(raw); shape != "object" {
+		return block, &errors.ResultShapeError{Method: method, Expected: "object", Got: shape}
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return block, fmt.Errorf("error reading response body: %w", err)
+	}
+	return block, nil
+}
+
+// decodeStringResult decodes a hex encoded string result, e.g. of eth_call or eth_chainId
+func decodeStringResult(method string, raw json.RawMessage) (string, error) {
+	if shape := jsonShape(raw); shape != "string" {
+		return "", &errors.ResultShapeError{Method: method, Expected: "string", Got: shape}
+	}
+	var result string
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+	return result, nil
+}
+
+// decodeBlockWithTxsResult decodes the result of eth_getBlockByNumber with full transactions
+func decodeBlockWithTxsResult(method string, raw json.RawMessage) (types.BlockWithTxs, error) {
+	var block types.BlockWithTxs
+	if shape := jsonShape(raw); shape != "object" {
+		return block, &errors.ResultShapeError{Method: method, Expected: "object", Got: shape}
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return block, fmt.Errorf("error reading response body: %w", err)
+	}
+	return block, nil
+}
+
+// decodeReceiptResult decodes the result of eth_getTransactionReceipt.
+// A null result, returned for a transaction the node hasn't indexed yet, is an error rather than a zero receipt.
+func decodeReceiptResult(method string, raw json.RawMessage) (types.Receipt, error) {
+	var receipt types.Receipt
+	if shape := jsonShape(raw); shape != "object" {
+		return receipt, &errors.ResultShapeError{Method: method, Expected: "object", Got: shape}
+	}
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return receipt, fmt.Errorf("error reading response body: %w", err)
+	}
+	return receipt, nil
+}
+
+// decodeReceiptsResult decodes the result of eth_getBlockReceipts.
+// Besides the standard array, it accepts an object wrapping the array,
+// either under "receipts" or as its only array field, as returned by some providers and aliases.
+func decodeReceiptsResult(method string, raw json.RawMessage) ([]types.Receipt, error) {
+	switch shape := jsonShape(raw); shape {
+	case "array":
+	case "object":
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &wrapper); err != nil {
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		}
+		inner, err := wrappedArray(wrapper, "receipts")
+		if err != nil {
+			return nil, &errors.ResultShapeError{Method: method, Expected: "array", Got: "object without receipts array"}
+		}
+		raw = inner
+	default:
+		return nil, &errors.ResultShapeError{Method: method, Expected: "array", Got: shape}
+	}
+
+	var receipts []types.Receipt
+	if err := json.Unmarshal(raw, &receipts); err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if receipts == nil {
+		receipts = []types.Receipt{}
+	}
+	return receipts, nil
+}
+
+// wrappedArray returns the array under key in wrapper, or its only array field when key is missing
+func wrappedArray(wrapper map[string]json.RawMessage, key string) (json.RawMessage, error) {
+	if inner, ok := wrapper[key]; ok && jsonShape(inner) == "array" {
+		return inner, nil
+	}
+
+	var found json.RawMessage
+	for _, value := range wrapper {
+		if jsonShape(value) != "array" {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("several array fields")
+		}
+		found = value
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no array field")
+	}
+	return found, nil
+}
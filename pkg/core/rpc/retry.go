@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/rand"
 	"time"
 
 	"github.com/ryuux05/godex/pkg/core/errors"
@@ -27,6 +26,13 @@ type RetryConfig struct {
 	// To spread retry out.
 	// Default: true
 	EnableJitter bool
+	// Clock waits the backoffs, nil uses the real clock.
+	// Tests can inject a FakeClock to retry without waiting.
+	Clock Clock
+	// Strategy replaces the exponential backoff of the fields above, e.g. a ConstantBackoff.
+	// It decides both the waits and when to give up, MaxAttempts is then ignored.
+	// Default: nil (ExponentialBackoff)
+	Strategy BackoffStrategy
 }
 
 func DefaultRetryConfig() RetryConfig {
@@ -39,7 +45,34 @@ func DefaultRetryConfig() RetryConfig {
 	}
 }
 
-// RetryWithBackoff executes function with exponential backoff
+// strategy returns the BackoffStrategy of the config, the exponential backoff of its fields by default
+func (c RetryConfig) strategy() BackoffStrategy {
+	if c.Strategy != nil {
+		return c.Strategy
+	}
+	return ExponentialBackoff{
+		MaxAttempts: c.MaxAttempts,
+		InitialBackoff: c.InitialBackoff,
+		MaxBackoff: c.MaxBackoff,
+		Multiplier: c.Multiplier,
+		EnableJitter: c.EnableJitter,
+	}
+}
+
+// Backoff returns the wait of the strategy of the config after attempt failed attempts, for callers retrying on their own.
+// Once the strategy gives up, it keeps the wait of the last attempt it allows, InitialBackoff when it allows none.
+func (c RetryConfig) Backoff(attempt int, lastErr error) time.Duration {
+	strategy := c.strategy()
+	for ; attempt >= 1; attempt-- {
+		if wait, ok := strategy.NextDelay(attempt, lastErr); ok {
+			return wait
+		}
+	}
+	return c.InitialBackoff
+}
+
+// RetryWithBackoff executes function with the backoff strategy of config,
+// exponential backoff unless config.Strategy is set.
 // Only for retriable error.
 //
 // Example:
@@ -51,12 +84,17 @@ func DefaultRetryConfig() RetryConfig {
 //	    return err
 //	})
 func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error) error {
-	var lastErr error
-	backoff := config.InitialBackoff
+	return RetryWithStrategy(ctx, config.strategy(), config.Clock, fn)
+}
+
+// RetryWithStrategy executes function until it succeeds, fails with a non-retryable error
+// or strategy gives up, waiting the delays of strategy on clock in between. A nil clock uses the real clock.
+func RetryWithStrategy(ctx context.Context, strategy BackoffStrategy, clock Clock, fn func() error) error {
+	clock = clockOrReal(clock)
 
-	for attempt := 0; attempt < config.MaxAttempts; attempt ++ {
+	for attempt := 1; ; attempt ++ {
 		// Execute function
-		lastErr = fn()
+		lastErr := fn()
 
 		// If there is no error return nil
 		if lastErr == nil {
@@ -68,34 +106,20 @@ func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error)
 			return fmt.Errorf("non-retryable error: %w", lastErr)
 		}
 
-		// Last attempt failed - don't wait, just return
-		if attempt == config.MaxAttempts-1 {
-			break
-		}
-
-		// Calculate wait time with exponential backoff and jitter
-		wait := backoff
-		if config.EnableJitter {
-			jitter := time.Duration(rand.Int63n(int64(backoff / 4)))
-			wait = backoff + jitter
+		// Strategy gave up - don't wait, just return
+		wait, ok := strategy.NextDelay(attempt, lastErr)
+		if !ok {
+			return fmt.Errorf("max retry attempts (%d) exceeded: %w", attempt, lastErr)
 		}
 
-		log.Printf("Retry attempt %d/%d failed: %v. Retrying in %v...",
-			attempt+1, config.MaxAttempts, lastErr, wait)
+		log.Printf("Retry attempt %d failed: %v. Retrying in %v...",
+			attempt, lastErr, wait)
 
 		// Wait for context cancellation and backoff
 		select {
-		case <- time.After(wait):
-			backoff = time.Duration(float64(backoff) * config.Multiplier)
-			if backoff > config.MaxBackoff {
-				backoff = config.MaxBackoff
-			}
+		case <- clock.After(wait):
 		case <- ctx.Done():
 			return fmt.Errorf("retry cancelled: %w", ctx.Err())
 		}
-
 	}
-
-	return fmt.Errorf("max retry attempts (%d) exceeded: %w", config.MaxAttempts, lastErr)
 }
-
@@ -30,12 +30,14 @@ func TestRetryWithBackoff_Success(t *testing.T) {
 }
 
 func TestRetryWithBackoff_SuccessAfterRetries(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	config := RetryConfig{
 		MaxAttempts:    3,
 		InitialBackoff: 10 * time.Millisecond,
 		MaxBackoff:     100 * time.Millisecond,
 		Multiplier:     2.0,
 		EnableJitter:   false,
+		Clock:          clock,
 	}
 
 
@@ -54,6 +56,7 @@ func TestRetryWithBackoff_SuccessAfterRetries(t *testing.T) {
 	err := RetryWithBackoff(context.Background(), config, fn)
 	assert.NoError(t, err)
 	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, clock.Waits())
 }
 
 func TestRetryWithBackoff_MaxAttemptExceeded(t *testing.T) {
@@ -102,15 +105,16 @@ func TestRetryWithBackoff_NonRetryableError(t *testing.T) {
 }
 
 func TestRetryWithBackoff_ExponentialBackoff(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	config := RetryConfig{
 		MaxAttempts:    4,
 		InitialBackoff: 10 * time.Millisecond,
 		MaxBackoff:     1 * time.Second,
 		Multiplier:     2.0,
 		EnableJitter:   false,
+		Clock:          clock,
 	}
 
-	start := time.Now()
 	calls := 0
 	fn := func() error {
 		calls++
@@ -121,23 +125,23 @@ func TestRetryWithBackoff_ExponentialBackoff(t *testing.T) {
 	}
 
 	_ = RetryWithBackoff(context.Background(), config, fn)
-	elapsed := time.Since(start)
 
-	// Should wait: 10ms + 20ms + 40ms = 70ms minimum
-	assert.GreaterOrEqual(t, elapsed, 70*time.Millisecond)
+	// Should wait: 10ms + 20ms + 40ms, nothing after the last attempt
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}, clock.Waits())
 	assert.Equal(t, 4, calls)
 } 
 
 func TestRetryWithBackoff_MaxBackoff(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	config := RetryConfig{
 		MaxAttempts:    4,
 		InitialBackoff: 10 * time.Millisecond,
 		MaxBackoff:     50 * time.Millisecond,
 		Multiplier:     10.0,
 		EnableJitter:   false,
+		Clock:          clock,
 	}
 
-	start := time.Now()
 	calls := 0
 	fn := func() error {
 		calls++
@@ -148,9 +152,115 @@ func TestRetryWithBackoff_MaxBackoff(t *testing.T) {
 	}
 
 	_ = RetryWithBackoff(context.Background(), config, fn)
-	elapsed := time.Since(start)
 
-	// Should wait: 10ms + 50ms + 50ms = 110ms minimum
-	assert.GreaterOrEqual(t, elapsed, 110*time.Millisecond)
+	// Should wait: 10ms + 50ms + 50ms
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}, clock.Waits())
 	assert.Equal(t, 4, calls)
-}
\ No newline at end of file
+}
+
+func TestRetryWithBackoff_Jitter(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config := RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     1 * time.Second,
+		Multiplier:     2.0,
+		EnableJitter:   true,
+		Clock:          clock,
+	}
+
+	start := clock.Now()
+	_ = RetryWithBackoff(context.Background(), config, func() error {
+		return &errors.HTTPError{StatusCode: 503, Message: "unavailable"}
+	})
+
+	// Jitter adds up to a quarter of the backoff
+	waits := clock.Waits()
+	if assert.Len(t, waits, 2) {
+		assert.GreaterOrEqual(t, waits[0], 100*time.Millisecond)
+		assert.Less(t, waits[0], 125*time.Millisecond)
+		assert.GreaterOrEqual(t, waits[1], 200*time.Millisecond)
+		assert.Less(t, waits[1], 250*time.Millisecond)
+	}
+	assert.Equal(t, waits[0]+waits[1], clock.Now().Sub(start))
+}
+
+func TestExponentialBackoff_NextDelay(t *testing.T) {
+	strategy := ExponentialBackoff{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2.0,
+	}
+
+	var waits []time.Duration
+	for attempt := 1; ; attempt++ {
+		wait, ok := strategy.NextDelay(attempt, nil)
+		if !ok {
+			break
+		}
+		waits = append(waits, wait)
+	}
+	// Capped at MaxBackoff, no wait after the last attempt
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}, waits)
+}
+
+func TestConstantBackoff(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config := RetryConfig{
+		Clock:    clock,
+		Strategy: ConstantBackoff{MaxAttempts: 4, Delay: 25 * time.Millisecond},
+	}
+
+	calls := 0
+	err := RetryWithBackoff(context.Background(), config, func() error {
+		calls++
+		return &errors.HTTPError{StatusCode: 503, Message: "unavailable"}
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max retry attempts (4)")
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []time.Duration{25 * time.Millisecond, 25 * time.Millisecond, 25 * time.Millisecond}, clock.Waits())
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	config := RetryConfig{Strategy: ConstantBackoff{MaxAttempts: 3, Delay: 25 * time.Millisecond}, InitialBackoff: time.Second}
+
+	assert.Equal(t, 25*time.Millisecond, config.Backoff(1, nil))
+	// Past the attempts of the strategy the last allowed wait is kept
+	assert.Equal(t, 25*time.Millisecond, config.Backoff(5, nil))
+
+	none := RetryConfig{Strategy: ConstantBackoff{MaxAttempts: 1, Delay: 25 * time.Millisecond}, InitialBackoff: time.Second}
+	assert.Equal(t, time.Second, none.Backoff(1, nil))
+}
+
+// rateLimitBackoff waits longer on rate limiting, like a policy honoring the provider reset time
+type rateLimitBackoff struct{}
+
+func (rateLimitBackoff) NextDelay(attempt int, lastErr error) (time.Duration, bool) {
+	if httpErr, ok := lastErr.(*errors.HTTPError); ok && httpErr.StatusCode == 429 {
+		return time.Minute, attempt < 3
+	}
+	return time.Second, attempt < 3
+}
+
+func TestRetryWithStrategy_CustomStrategy(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	calls := 0
+	err := RetryWithStrategy(context.Background(), rateLimitBackoff{}, clock, func() error {
+		calls++
+		switch calls {
+		case 1:
+			return &errors.HTTPError{StatusCode: 429, Message: "too many requests"}
+		case 2:
+			return &errors.HTTPError{StatusCode: 503, Message: "unavailable"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Minute, time.Second}, clock.Waits())
+}
@@ -20,3 +20,26 @@ type RPC interface {
 	// Get block receipt for the current block number
 	GetBlockReceipts(ctx context.Context, blockNumber string) ([]types.Receipt, error)
 }
+
+// ChainIDRPC reports the chain id of the node. It is optional, e.g. for the processor Options.VerifyChainID.
+type ChainIDRPC interface {
+	// Get the chain id with eth_chainId, hex encoded
+	ChainID(ctx context.Context) (string, error)
+}
+
+// CallRPC executes read-only contract calls. It is optional, e.g. for an EventIndexer Enricher
+// reading contract state at the block of an event.
+type CallRPC interface {
+	// Execute call against the state at blockNumber and return its hex encoded result
+	Call(ctx context.Context, call types.CallMsg, blockNumber string) (string, error)
+}
+
+// TxReceiptsRPC fetches the receipts one transaction at a time, for providers without eth_getBlockReceipts.
+// It is optional, an RPC implementing it can be used with the processor FetchModeTxReceipts.
+type TxReceiptsRPC interface {
+	// Get block with its full transactions for the current block number
+	GetBlockWithTxs(ctx context.Context, blockNumber string) (types.BlockWithTxs, error)
+
+	// Get the receipt of a transaction
+	GetTransactionReceipt(ctx context.Context, txHash string) (types.Receipt, error)
+}
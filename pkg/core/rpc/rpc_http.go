@@ -3,9 +3,13 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"runtime/debug"
 	"time"
 
 	"github.com/ryuux05/godex/pkg/core/errors"
@@ -19,10 +23,29 @@ type HTTPRPC struct{
 	rateLimit uint16
 	// http client
 	client *http.Client
+	// UserAgent is sent with every request, defaults to "godex/<version>".
+	// Some providers identify the app or the rate-limit tier with it.
+	UserAgent string
+	// Headers are static headers sent with every request, e.g. an app id or an API key header
+	Headers http.Header
+	// Methods overrides standard methods for chains that use other names or params,
+	// with the standard method name (e.g. "eth_getBlockReceipts") as key. Methods not in it are sent as is.
+	Methods map[string]MethodOverride
+}
+
+// MethodOverride remaps a standard JSON-RPC method
+type MethodOverride struct {
+	// Name is sent instead of the standard method name, the standard name is kept when empty
+	Name string
+	// Params reshapes the standard params, e.g. to inject a provider specific one. Optional.
+	Params func(params []interface{}) []interface{}
 }
 
 
 
+// modulePath is used to find the version of this module in the build info
+const modulePath = "github.com/ryuux05/godex"
+
 // Response type for rpc
 type rpcResponse[T any] struct {
 	JSONRPC string `json:"jsonrpc"`
@@ -31,24 +54,148 @@ type rpcResponse[T any] struct {
 	Error *errors.RPCError `json:"error"`
 }
 
+// newRequestBody builds the JSON-RPC 2.0 request envelope shared by the transports
+func newRequestBody(id uint, method string, params ...interface{}) map[string]interface{} {
+	if params == nil {
+		params = []interface{}{}
+	}
+	return map[string]interface{} {
+		"jsonrpc": "2.0",
+		"id": id,
+		"method": method,
+		"params": params,
+	}
+}
+
+// defaultHTTPTimeout bounds a whole request, from the connection to the end of the body, unless WithRequestTimeout is used
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPOption configures the client of an HTTPRPC
+type HTTPOption func(c *httpConfig)
+
+// httpConfig is the client configuration built by the HTTPOptions
+type httpConfig struct {
+	transport *http.Transport
+	timeout time.Duration
+}
+
+// WithProxy routes the requests through the proxy returned by proxy, e.g. http.ProxyFromEnvironment
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) HTTPOption {
+	return func(c *httpConfig) {
+		c.transport.Proxy = proxy
+	}
+}
+
+// WithProxyURL routes every request through the proxy at proxyURL
+func WithProxyURL(proxyURL *url.URL) HTTPOption {
+	return WithProxy(http.ProxyURL(proxyURL))
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. a custom CA for a private node or client certificates for mutual TLS
+func WithTLSConfig(config *tls.Config) HTTPOption {
+	return func(c *httpConfig) {
+		c.transport.TLSClientConfig = config
+	}
+}
+
+// WithRequestTimeout bounds a whole request, from the connection to the end of the body, defaults to 10s.
+// 0 leaves the requests bounded by their context only, e.g. to read big responses with the finer timeouts below.
+func WithRequestTimeout(timeout time.Duration) HTTPOption {
+	return func(c *httpConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithDialTimeout bounds establishing the TCP connection, DNS resolution included
+func WithDialTimeout(timeout time.Duration) HTTPOption {
+	return func(c *httpConfig) {
+		c.transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+}
+
+// WithTLSHandshakeTimeout bounds the TLS handshake of a new connection
+func WithTLSHandshakeTimeout(timeout time.Duration) HTTPOption {
+	return func(c *httpConfig) {
+		c.transport.TLSHandshakeTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for the response headers once the request is sent.
+// It doesn't include reading the body, so a slow node fails fast while big responses can still stream in.
+func WithResponseHeaderTimeout(timeout time.Duration) HTTPOption {
+	return func(c *httpConfig) {
+		c.transport.ResponseHeaderTimeout = timeout
+	}
+}
+
 // NewHTTPRPC creates an HTTP JSON-RPC client.
 // endpoint is the base RPC URL (e.g., https://...).
 // rateLimit is the maximum requests per second (0 disables limiting).
-func NewHTTPRPC(endpoint string, rateLimit uint16) *HTTPRPC {
+// opts configure the client, e.g. a proxy, TLS or timeouts, the default transport is used without them.
+func NewHTTPRPC(endpoint string, rateLimit uint16, opts ...HTTPOption) *HTTPRPC {
+	client := &http.Client{Timeout: defaultHTTPTimeout}
+	if len(opts) > 0 {
+		config := httpConfig{
+			transport: http.DefaultTransport.(*http.Transport).Clone(),
+			timeout: defaultHTTPTimeout,
+		}
+		for _, opt := range opts {
+			opt(&config)
+		}
+		client.Transport = config.transport
+		client.Timeout = config.timeout
+	}
+
 	return &HTTPRPC{
 		endpoint: endpoint,
 		rateLimit: rateLimit,
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: client,
+		UserAgent: defaultUserAgent(),
+		Headers: make(http.Header),
 	}
 }
 
-func(r *HTTPRPC) Head(ctx context.Context) (string, error) {
-	body := map[string]interface{} {
-		"jsonrpc": "2.0",
-		"id": 1,
-		"method": "eth_blockNumber",
-		"params": []interface{}{},
+// defaultUserAgent is godex/<version>, the version is the one of the module in the build
+func defaultUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+			}
+		}
 	}
+	return "godex/" + version
+}
+
+// requestBody builds the request of a standard method with its override applied
+func (r *HTTPRPC) requestBody(method string, params ...interface{}) map[string]interface{} {
+	if override, ok := r.Methods[method]; ok {
+		if override.Name != "" {
+			method = override.Name
+		}
+		if override.Params != nil {
+			params = override.Params(params)
+		}
+	}
+	return newRequestBody(1, method, params...)
+}
+
+// setHeaders sets the JSON content type, the user agent and the static headers on req
+func (r *HTTPRPC) setHeaders(req *http.Request) {
+	for key, values := range r.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.UserAgent != "" {
+		req.Header.Set("User-Agent", r.UserAgent)
+	}
+}
+
+func(r *HTTPRPC) Head(ctx context.Context) (string, error) {
+	body := r.requestBody("eth_blockNumber")
 
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -59,7 +206,7 @@ func(r *HTTPRPC) Head(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error creating http request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	r.setHeaders(req)
 
 	res, err := r.client.Do(req)
 
@@ -98,15 +245,7 @@ func(r *HTTPRPC) Head(ctx context.Context) (string, error) {
 
 // GetBlock returns the block header for now (second params is set to false)
 func(r *HTTPRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block, error) {
-	body := map[string]interface{} {
-		"jsonrpc": "2.0",
-		"id": 1,
-		"method": "eth_getBlockByNumber",
-		"params": []interface{}{
-			blockNumber,
-			false,
-		},
-	}
+	body := r.requestBody("eth_getBlockByNumber", blockNumber, false)
 
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -118,7 +257,7 @@ func(r *HTTPRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block,
 	if err != nil {
 		return types.Block{}, fmt.Errorf("error creating http request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	r.setHeaders(req)
 	
 
 	res, err := r.client.Do(req)
@@ -136,7 +275,7 @@ func(r *HTTPRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block,
 
 
 
-	var resp rpcResponse[types.Block]
+	var resp rpcResponse[json.RawMessage]
 
 	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
 		return types.Block{}, fmt.Errorf("error reading response body: %w", err)
@@ -148,18 +287,14 @@ func(r *HTTPRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block,
 		}
 	}
 
-	return resp.Result, nil
+	return decodeBlockResult("eth_getBlockByNumber", resp.Result)
 }
 
 func(r *HTTPRPC) GetLogs(ctx context.Context, filter types.Filter) ([]types.Log, error) {
-	body := map[string]interface{} {
-		"jsonrpc": "2.0",
-		"id": 1,
-		"method": "eth_getLogs",
-		"params": []interface{}{
-			filter,
-		},
+	if err := filter.Validate(); err != nil {
+		return []types.Log{}, err
 	}
+	body := r.requestBody("eth_getLogs", filter)
 
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -170,7 +305,7 @@ func(r *HTTPRPC) GetLogs(ctx context.Context, filter types.Filter) ([]types.Log,
 	if err != nil {
 		return []types.Log{}, fmt.Errorf("error creating http request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	r.setHeaders(req)
 	
 	res, err := r.client.Do(req)
 	if err != nil {
@@ -202,14 +337,7 @@ func(r *HTTPRPC) GetLogs(ctx context.Context, filter types.Filter) ([]types.Log,
 }
 
 func(r *HTTPRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]types.Receipt, error) {
-	body := map[string]interface{} {
-		"jsonrpc": "2.0",
-		"id": 1,
-		"method": "eth_getBlockReceipts",
-		"params": []interface{}{
-			blockNumber,
-		},		
-	}
+	body := r.requestBody("eth_getBlockReceipts", blockNumber)
 
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -220,7 +348,7 @@ func(r *HTTPRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]ty
 	if err != nil {
 		return []types.Receipt{}, fmt.Errorf("error creating http request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	r.setHeaders(req)
 
 	res, err := r.client.Do(req)
 	if err != nil {
@@ -235,7 +363,7 @@ func(r *HTTPRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]ty
 		}
 	} 
 
-	var resp rpcResponse[[]types.Receipt]
+	var resp rpcResponse[json.RawMessage]
 
 	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
 		return []types.Receipt{}, fmt.Errorf("error reading response body: %w", err)
@@ -247,5 +375,88 @@ func(r *HTTPRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]ty
 		}
 	}
 
+	return decodeReceiptsResult("eth_getBlockReceipts", resp.Result)
+}
+
+// post sends a request and returns the response once its status is checked, the caller closes its body
+func (r *HTTPRPC) post(ctx context.Context, method string, params ...interface{}) (*http.Response, error) {
+	b, err := json.Marshal(r.requestBody(method, params...))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("error creating http request: %w", err)
+	}
+	r.setHeaders(req)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rpc: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, &errors.HTTPError{
+			StatusCode: res.StatusCode,
+			Message: res.Status,
+		}
+	}
+	return res, nil
+}
+
+// call sends a request and returns its raw result, the result shape is checked by the caller
+func (r *HTTPRPC) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	res, err := r.post(ctx, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var resp rpcResponse[json.RawMessage]
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, &errors.RPCError{
+			Code: resp.Error.Code,
+			Message: resp.Error.Message,
+		}
+	}
 	return resp.Result, nil
 }
+
+// ChainID returns the chain id reported by the node, hex encoded
+func (r *HTTPRPC) ChainID(ctx context.Context) (string, error) {
+	raw, err := r.call(ctx, "eth_chainId")
+	if err != nil {
+		return "", err
+	}
+	return decodeStringResult("eth_chainId", raw)
+}
+
+func (r *HTTPRPC) Call(ctx context.Context, call types.CallMsg, blockNumber string) (string, error) {
+	raw, err := r.call(ctx, "eth_call", call, blockNumber)
+	if err != nil {
+		return "", err
+	}
+	return decodeStringResult("eth_call", raw)
+}
+
+// GetBlockWithTxs returns the block with its full transactions (second params is set to true)
+func (r *HTTPRPC) GetBlockWithTxs(ctx context.Context, blockNumber string) (types.BlockWithTxs, error) {
+	raw, err := r.call(ctx, "eth_getBlockByNumber", blockNumber, true)
+	if err != nil {
+		return types.BlockWithTxs{}, err
+	}
+	return decodeBlockWithTxsResult("eth_getBlockByNumber", raw)
+}
+
+func (r *HTTPRPC) GetTransactionReceipt(ctx context.Context, txHash string) (types.Receipt, error) {
+	raw, err := r.call(ctx, "eth_getTransactionReceipt", txHash)
+	if err != nil {
+		return types.Receipt{}, err
+	}
+	return decodeReceiptResult("eth_getTransactionReceipt", raw)
+}
@@ -2,12 +2,18 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ryuux05/godex/pkg/core/errors"
 	"github.com/ryuux05/godex/pkg/core/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -75,10 +81,10 @@ func TestGetBlock_Success(t *testing.T) {
 			"jsonrpc": "2.0",
 			"id":      1,
 			"result": map[string]any{
-				"Number":     "0x3039",
-				"Hash":       "0xabc",
-				"ParentHash": "0xdef",
-				"Timestamp":  "1700000000",
+				"number":     "0x3039",
+				"hash":       "0xabc",
+				"parentHash": "0xdef",
+				"timestamp":  "1700000000",
 			},
 		})
 	}))
@@ -138,15 +144,15 @@ func TestGetLogs_Success(t *testing.T) {
 			"id":      1,
 			"result": []map[string]any{
 				{
-					"Address":          "0xabc",
-					"Topics":           []any{"0xddf252ad"},
-					"Data":             "0x01",
-					"BlockNumber":      "0x1",
-					"TransactionHash":  "0xth1",
-					"TransactionIndex": "0",
-					"BlockHash":        "0xbh1",
-					"LogIndex":         "0x0",
-					"Removed":          false,
+					"address":          "0xabc",
+					"topics":           []any{"0xddf252ad"},
+					"data":             "0x01",
+					"blockNumber":      "0x1",
+					"transactionHash":  "0xth1",
+					"transactionIndex": "0",
+					"blockHash":        "0xbh1",
+					"logIndex":         "0x0",
+					"removed":          false,
 				},
 			},
 		})
@@ -206,6 +212,27 @@ func TestGetLogs_HTTPStatusNotOK(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetLogs_InvalidFilter(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": []any{}})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Refused before reaching the node
+	_, err := rpc.GetLogs(ctx, types.Filter{BlockHash: "0xbh", FromBlock: "0x1", ToBlock: "0x2"})
+	assert.ErrorContains(t, err, "blockHash")
+	_, err = rpc.GetLogs(ctx, types.Filter{FromBlock: "0x2", ToBlock: "0x1"})
+	assert.ErrorContains(t, err, "after toBlock")
+	assert.False(t, called)
+	assert.False(t, errors.IsRetryableError(err))
+}
+
 func TestGetBlockReceipts_Success(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]any {
@@ -346,4 +373,469 @@ func TestGetBlockReceipts_EmptyBlock(t *testing.T) {
 	receipt, err := rpc.GetBlockReceipts(ctx, "0x000")
 	assert.NoError(t, err)
 	assert.Len(t, receipt, 0)
-}
\ No newline at end of file
+}
+func TestHTTPRPC_Headers(t *testing.T) {
+	var userAgent, appId string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		appId = r.Header.Get("X-App-Id")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  "0x1",
+		})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Default user agent instead of Go-http-client
+	_, err := rpc.Head(ctx)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(userAgent, "godex/"), userAgent)
+	assert.Equal(t, "", appId)
+
+	rpc.UserAgent = "my-indexer/1.2"
+	rpc.Headers.Set("X-App-Id", "abc")
+	_, err = rpc.Head(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-indexer/1.2", userAgent)
+	assert.Equal(t, "abc", appId)
+}
+
+func TestHTTPRPC_MethodOverride(t *testing.T) {
+	var method string
+	var params []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		method, params = req.Method, req.Params
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  []map[string]any{{"transactionHash": "0xth1", "blockNumber": "0x1"}},
+		})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Standard name by default
+	_, err := rpc.GetBlockReceipts(ctx, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth_getBlockReceipts", method)
+	assert.Equal(t, []any{"0x1"}, params)
+
+	rpc.Methods = map[string]MethodOverride{
+		"eth_getBlockReceipts": {
+			Name: "alchemy_getTransactionReceipts",
+			Params: func(params []interface{}) []interface{} {
+				return []interface{}{map[string]interface{}{"blockNumber": params[0]}}
+			},
+		},
+	}
+	receipts, err := rpc.GetBlockReceipts(ctx, "0x1")
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, "alchemy_getTransactionReceipts", method)
+	assert.Equal(t, []any{map[string]any{"blockNumber": "0x1"}}, params)
+
+	// Other methods are untouched
+	_, _ = rpc.Head(ctx)
+	assert.Equal(t, "eth_blockNumber", method)
+}
+
+func TestHTTPRPC_TLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x1"})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// The certificate of the server is signed by an unknown CA
+	_, err := NewHTTPRPC(srv.URL, 0).Head(ctx)
+	assert.Error(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	head, err := NewHTTPRPC(srv.URL, 0, WithTLSConfig(&tls.Config{RootCAs: pool})).Head(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", head)
+}
+
+func TestHTTPRPC_ProxyURL(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy receives the absolute URL of the node
+		proxiedHost = r.URL.Host
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x2"})
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// The node is only reachable through the proxy
+	head, err := NewHTTPRPC("http://node.internal:8545", 0, WithProxyURL(proxyURL)).Head(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", head)
+	assert.Equal(t, "node.internal:8545", proxiedHost)
+
+	var called bool
+	head, err = NewHTTPRPC("http://node.internal:8545", 0, WithProxy(func(r *http.Request) (*url.URL, error) {
+		called = true
+		return proxyURL, nil
+	})).Head(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", head)
+	assert.True(t, called)
+}
+
+func TestHTTPRPC_Timeouts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("tls handshake", func(t *testing.T) {
+		// Accepts the connections but never answers the handshake
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer ln.Close()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		start := time.Now()
+		_, err = NewHTTPRPC("https://"+ln.Addr().String(), 0, WithTLSHandshakeTimeout(50*time.Millisecond)).Head(ctx)
+		assert.ErrorContains(t, err, "TLS handshake timeout")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	// Headers after 300ms, then the body after another 300ms
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/headers" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x1"})
+	}))
+	defer slow.Close()
+
+	t.Run("response headers", func(t *testing.T) {
+		start := time.Now()
+		_, err := NewHTTPRPC(slow.URL+"/headers", 0, WithResponseHeaderTimeout(50*time.Millisecond)).Head(ctx)
+		assert.ErrorContains(t, err, "timeout awaiting response headers")
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("slow body", func(t *testing.T) {
+		// The headers come in time, the request timeout covers the body read too
+		_, err := NewHTTPRPC(slow.URL, 0, WithResponseHeaderTimeout(200*time.Millisecond), WithRequestTimeout(100*time.Millisecond)).Head(ctx)
+		assert.Error(t, err)
+
+		// Without it the body can take longer than the header timeout
+		head, err := NewHTTPRPC(slow.URL, 0, WithResponseHeaderTimeout(200*time.Millisecond), WithRequestTimeout(0)).Head(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "0x1", head)
+	})
+}
+
+func TestGetBlockReceipts_ObjectWrappedResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": map[string]any{
+				"blockHash": "0xbh1",
+				"receipts": []map[string]any{
+					{"blockHash": "0xbh1", "blockNumber": "0x1", "transactionHash": "0xth1"},
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	receipts, err := rpc.GetBlockReceipts(ctx, "0x1")
+	assert.NoError(t, err)
+	if assert.Len(t, receipts, 1) {
+		assert.Equal(t, "0xth1", receipts[0].TransactionHash)
+	}
+}
+
+func TestGetBlockReceipts_UnexpectedShape(t *testing.T) {
+	for _, result := range []any{"0x1", map[string]any{"blockHash": "0xbh1"}, nil} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+		}))
+
+		rpc := NewHTTPRPC(srv.URL, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+
+		_, err := rpc.GetBlockReceipts(ctx, "0x1")
+		var shapeErr *errors.ResultShapeError
+		if assert.ErrorAs(t, err, &shapeErr) {
+			assert.Equal(t, "array", shapeErr.Expected)
+		}
+
+		cancel()
+		srv.Close()
+	}
+}
+
+func TestGetBlockReceipts_BlobTransaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result": []map[string]any{
+				// Blob transaction, with the EIP-4844 fields
+				{
+					"blockHash":         "0xblock123",
+					"blockNumber":       "0x1",
+					"contractAddress":   nil,
+					"cumulativeGasUsed": "0x5208",
+					"effectiveGasPrice": "0x3b9aca00",
+					"from":              "0xsequencer",
+					"gasUsed":           "0x5208",
+					"logs":              []map[string]any{},
+					"logsBloom":         "0x00",
+					"status":            "0x1",
+					"to":                "0xinbox",
+					"transactionHash":   "0xblobtx",
+					"transactionIndex":  "0x0",
+					"type":              "0x3",
+					"blobGasUsed":       "0x20000",
+					"blobGasPrice":      "0x1",
+				},
+				// Regular transaction, without them
+				{
+					"blockHash":         "0xblock123",
+					"blockNumber":       "0x1",
+					"contractAddress":   nil,
+					"cumulativeGasUsed": "0xa410",
+					"effectiveGasPrice": "0x3b9aca00",
+					"from":              "0xsender",
+					"gasUsed":           "0x5208",
+					"logs":              []map[string]any{},
+					"logsBloom":         "0x00",
+					"status":            "0x1",
+					"to":                "0xreceiver",
+					"transactionHash":   "0xtx",
+					"transactionIndex":  "0x1",
+					"type":              "0x2",
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	receipts, err := rpc.GetBlockReceipts(ctx, "0x1")
+	assert.NoError(t, err)
+	if !assert.Len(t, receipts, 2) {
+		return
+	}
+
+	if assert.NotNil(t, receipts[0].BlobGasUsed) && assert.NotNil(t, receipts[0].BlobGasPrice) {
+		assert.Equal(t, "0x20000", *receipts[0].BlobGasUsed)
+		assert.Equal(t, "0x1", *receipts[0].BlobGasPrice)
+	}
+	assert.Nil(t, receipts[1].BlobGasUsed)
+	assert.Nil(t, receipts[1].BlobGasPrice)
+}
+
+func TestGetBlock_NullResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  nil,
+		})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := rpc.GetBlock(ctx, "0x3039")
+	var shapeErr *errors.ResultShapeError
+	if assert.ErrorAs(t, err, &shapeErr) {
+		assert.Equal(t, "eth_getBlockByNumber", shapeErr.Method)
+		assert.Equal(t, "null", shapeErr.Got)
+	}
+	// The node may not be synced to the block yet
+	assert.True(t, errors.IsRetryableError(err))
+	assert.True(t, errors.IsBlockNotFound(err))
+}
+
+func TestGetTransactionReceipt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		// The node doesn't know the second transaction yet
+		var result any
+		if req.Params[0] == "0xtx1" {
+			result = map[string]any{"transactionHash": "0xtx1", "blockNumber": "0x1", "status": "0x1"}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	receipt, err := rpc.GetTransactionReceipt(ctx, "0xtx1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xtx1", receipt.TransactionHash)
+
+	_, err = rpc.GetTransactionReceipt(ctx, "0xtx2")
+	var shapeErr *errors.ResultShapeError
+	assert.ErrorAs(t, err, &shapeErr)
+	assert.True(t, errors.IsRetryableError(err))
+}
+
+func TestGetBlockWithTxs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_getBlockByNumber", req.Method)
+		assert.Equal(t, true, req.Params[1])
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": map[string]any{
+			"number": "0x1",
+			"hash":   "0xbh1",
+			"transactions": []map[string]any{
+				{"hash": "0xtx1", "transactionIndex": "0x0", "type": "0x2"},
+				{"hash": "0xtx2", "transactionIndex": "0x1", "type": "0x3"},
+			},
+		}})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	block, err := rpc.GetBlockWithTxs(ctx, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbh1", block.Hash)
+	if assert.Len(t, block.Transactions, 2) {
+		assert.Equal(t, "0xtx2", block.Transactions[1].Hash)
+		assert.Equal(t, "0x3", block.Transactions[1].Type)
+	}
+}
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_call", req.Method)
+		assert.Equal(t, map[string]any{"to": "0xtoken", "data": "0x313ce567"}, req.Params[0])
+		assert.Equal(t, "0x10", req.Params[1])
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1,
+			"result": "0x0000000000000000000000000000000000000000000000000000000000000012"})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	result, err := rpc.Call(ctx, types.CallMsg{To: "0xtoken", Data: "0x313ce567"}, "0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000012", result)
+}
+
+func TestChainID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_chainId", req.Method)
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x89"})
+	}))
+	defer srv.Close()
+
+	rpc := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	chainID, err := rpc.ChainID(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x89", chainID)
+}
+
+func TestGetLogsByTx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_getTransactionReceipt", req.Method)
+
+		var result any
+		if req.Params[0] == "0xtx1" {
+			result = map[string]any{"transactionHash": "0xtx1", "blockNumber": "0x1", "status": "0x1", "logs": []map[string]any{
+				{"address": "0xabc", "topics": []string{"0x01"}, "transactionHash": "0xtx1", "logIndex": "0x0"},
+				{"address": "0xdef", "topics": []string{"0x02"}, "transactionHash": "0xtx1", "logIndex": "0x1"},
+			}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	client := NewHTTPRPC(srv.URL, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	logs, err := GetLogsByTx(ctx, client, "0xtx1")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "0xabc", logs[0].Address)
+	assert.Equal(t, "0x1", logs[1].LogIndex)
+
+	// Unknown transaction
+	_, err = GetLogsByTx(ctx, client, "0xtx2")
+	assert.ErrorContains(t, err, "0xtx2")
+}
@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// IPCRPC is a JSON-RPC client over a Unix domain socket, e.g. the geth.ipc of a local node.
+// Requests are newline-delimited and share a single connection,
+// responses are matched to their call by id so calls can run concurrently.
+type IPCRPC struct {
+	// unix socket connection to the node
+	conn net.Conn
+	// serializes the writes on conn
+	writeMu sync.Mutex
+	// id of the last request sent
+	lastId uint
+	// in-flight calls waiting for their response, with the request id as key
+	pending map[uint]chan rpcResponse[json.RawMessage]
+	// set once the connection is broken, every call then fails with it
+	closeErr error
+	// guards lastId, pending and closeErr
+	mu sync.Mutex
+}
+
+// NewIPCRPC connects to the IPC endpoint of a node.
+// path is the Unix socket path (e.g., ~/.ethereum/geth.ipc).
+func NewIPCRPC(path string) (*IPCRPC, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to ipc endpoint: %w", err)
+	}
+
+	r := &IPCRPC{
+		conn:    conn,
+		pending: make(map[uint]chan rpcResponse[json.RawMessage]),
+	}
+	go r.readLoop()
+
+	return r, nil
+}
+
+// Close closes the connection, in-flight calls fail
+func (r *IPCRPC) Close() error {
+	return r.conn.Close()
+}
+
+// readLoop dispatches the responses to the waiting calls until the connection breaks
+func (r *IPCRPC) readLoop() {
+	dec := json.NewDecoder(r.conn)
+	for {
+		var resp rpcResponse[json.RawMessage]
+		if err := dec.Decode(&resp); err != nil {
+			r.fail(fmt.Errorf("error reading ipc response: %w", err))
+			return
+		}
+
+		r.mu.Lock()
+		ch, ok := r.pending[resp.ID]
+		delete(r.pending, resp.ID)
+		r.mu.Unlock()
+
+		// The call may have given up already
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// fail marks the connection as broken and releases the waiting calls
+func (r *IPCRPC) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closeErr = err
+	for id, ch := range r.pending {
+		close(ch)
+		delete(r.pending, id)
+	}
+}
+
+// call sends a request and waits for the response with the same id
+func (r *IPCRPC) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	ch := make(chan rpcResponse[json.RawMessage], 1)
+
+	r.mu.Lock()
+	if r.closeErr != nil {
+		r.mu.Unlock()
+		return nil, r.closeErr
+	}
+	r.lastId++
+	id := r.lastId
+	r.pending[id] = ch
+	r.mu.Unlock()
+
+	forget := func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}
+
+	b, err := json.Marshal(newRequestBody(id, method, params...))
+	if err != nil {
+		forget()
+		return nil, fmt.Errorf("error marshaling body: %w", err)
+	}
+
+	r.writeMu.Lock()
+	if deadline, ok := ctx.Deadline(); ok {
+		r.conn.SetWriteDeadline(deadline)
+	} else {
+		r.conn.SetWriteDeadline(time.Time{})
+	}
+	_, err = r.conn.Write(append(b, '\n'))
+	r.writeMu.Unlock()
+	if err != nil {
+		forget()
+		return nil, fmt.Errorf("error fetching rpc: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		forget()
+		return nil, fmt.Errorf("error fetching rpc: %w", ctx.Err())
+	case resp, ok := <-ch:
+		if !ok {
+			r.mu.Lock()
+			err := r.closeErr
+			r.mu.Unlock()
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, &errors.RPCError{
+				Code:    resp.Error.Code,
+				Message: resp.Error.Message,
+			}
+		}
+		return resp.Result, nil
+	}
+}
+
+// ipcCall performs the call and decodes the result into T
+func ipcCall[T any](ctx context.Context, r *IPCRPC, method string, params ...interface{}) (T, error) {
+	var result T
+	raw, err := r.call(ctx, method, params...)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return result, fmt.Errorf("error reading response body: %w", err)
+	}
+	return result, nil
+}
+
+func (r *IPCRPC) Head(ctx context.Context) (string, error) {
+	return ipcCall[string](ctx, r, "eth_blockNumber")
+}
+
+// GetBlock returns the block header (second params is set to false)
+func (r *IPCRPC) GetBlock(ctx context.Context, blockNumber string) (types.Block, error) {
+	raw, err := r.call(ctx, "eth_getBlockByNumber", blockNumber, false)
+	if err != nil {
+		return types.Block{}, err
+	}
+	return decodeBlockResult("eth_getBlockByNumber", raw)
+}
+
+func (r *IPCRPC) GetLogs(ctx context.Context, filter types.Filter) ([]types.Log, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return ipcCall[[]types.Log](ctx, r, "eth_getLogs", filter)
+}
+
+func (r *IPCRPC) GetBlockReceipts(ctx context.Context, blockNumber string) ([]types.Receipt, error) {
+	raw, err := r.call(ctx, "eth_getBlockReceipts", blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReceiptsResult("eth_getBlockReceipts", raw)
+}
+
+// ChainID returns the chain id reported by the node, hex encoded
+func (r *IPCRPC) ChainID(ctx context.Context) (string, error) {
+	raw, err := r.call(ctx, "eth_chainId")
+	if err != nil {
+		return "", err
+	}
+	return decodeStringResult("eth_chainId", raw)
+}
+
+func (r *IPCRPC) Call(ctx context.Context, call types.CallMsg, blockNumber string) (string, error) {
+	raw, err := r.call(ctx, "eth_call", call, blockNumber)
+	if err != nil {
+		return "", err
+	}
+	return decodeStringResult("eth_call", raw)
+}
+
+// GetBlockWithTxs returns the block with its full transactions (second params is set to true)
+func (r *IPCRPC) GetBlockWithTxs(ctx context.Context, blockNumber string) (types.BlockWithTxs, error) {
+	raw, err := r.call(ctx, "eth_getBlockByNumber", blockNumber, true)
+	if err != nil {
+		return types.BlockWithTxs{}, err
+	}
+	return decodeBlockWithTxsResult("eth_getBlockByNumber", raw)
+}
+
+func (r *IPCRPC) GetTransactionReceipt(ctx context.Context, txHash string) (types.Receipt, error) {
+	raw, err := r.call(ctx, "eth_getTransactionReceipt", txHash)
+	if err != nil {
+		return types.Receipt{}, err
+	}
+	return decodeReceiptResult("eth_getTransactionReceipt", raw)
+}
@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newIPCServer serves newline-delimited JSON-RPC on a Unix socket.
+// Each request is handled in its own goroutine so responses may come back out of order.
+func newIPCServer(t *testing.T, handle func(method string, params []any) (any, map[string]any)) string {
+	path := filepath.Join(t.TempDir(), "geth.ipc")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var writeMu sync.Mutex
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					var req struct {
+						ID     uint   `json:"id"`
+						Method string `json:"method"`
+						Params []any  `json:"params"`
+					}
+					if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+						return
+					}
+					go func() {
+						result, rpcErr := handle(req.Method, req.Params)
+						resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+						if rpcErr != nil {
+							resp["error"] = rpcErr
+						} else {
+							resp["result"] = result
+						}
+						b, _ := json.Marshal(resp)
+
+						writeMu.Lock()
+						defer writeMu.Unlock()
+						conn.Write(append(b, '\n'))
+					}()
+				}
+			}()
+		}
+	}()
+
+	return path
+}
+
+func TestIPCRPC_Success(t *testing.T) {
+	path := newIPCServer(t, func(method string, params []any) (any, map[string]any) {
+		switch method {
+		case "eth_blockNumber":
+			return "0x10d4f", nil
+		case "eth_getBlockByNumber":
+			return map[string]any{"number": params[0], "hash": "0xabc", "parentHash": "0xdef"}, nil
+		case "eth_getLogs":
+			return []map[string]any{{"address": "0xabc", "blockNumber": "0x1", "logIndex": "0x0"}}, nil
+		case "eth_getBlockReceipts":
+			return []map[string]any{{"transactionHash": "0xth1", "blockNumber": params[0]}}, nil
+		}
+		return nil, map[string]any{"code": -32601, "message": "method not found"}
+	})
+
+	rpc, err := NewIPCRPC(path)
+	assert.NoError(t, err)
+	defer rpc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	head, err := rpc.Head(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x10d4f", head)
+
+	block, err := rpc.GetBlock(ctx, "0x3039")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x3039", block.Number)
+	assert.Equal(t, "0xabc", block.Hash)
+
+	logs, err := rpc.GetLogs(ctx, types.Filter{FromBlock: "0x1", ToBlock: "0x1"})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "0xabc", logs[0].Address)
+
+	receipts, err := rpc.GetBlockReceipts(ctx, "0x1")
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, "0xth1", receipts[0].TransactionHash)
+}
+
+func TestIPCRPC_RPCError(t *testing.T) {
+	path := newIPCServer(t, func(method string, params []any) (any, map[string]any) {
+		return nil, map[string]any{"code": -32000, "message": "oops"}
+	})
+
+	rpc, err := NewIPCRPC(path)
+	assert.NoError(t, err)
+	defer rpc.Close()
+
+	_, err = rpc.Head(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "oops")
+}
+
+func TestIPCRPC_ConcurrentCalls(t *testing.T) {
+	path := newIPCServer(t, func(method string, params []any) (any, map[string]any) {
+		blockNumber := params[0].(string)
+		// Answer the lower blocks last so the responses are out of order
+		var n int
+		fmt.Sscanf(blockNumber, "0x%x", &n)
+		time.Sleep(time.Duration(50-n) * time.Millisecond)
+		return map[string]any{"number": blockNumber, "hash": "0xh" + blockNumber}, nil
+	})
+
+	rpc, err := NewIPCRPC(path)
+	assert.NoError(t, err)
+	defer rpc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			blockNumber := fmt.Sprintf("0x%x", i)
+			block, err := rpc.GetBlock(ctx, blockNumber)
+			assert.NoError(t, err)
+			assert.Equal(t, "0xh"+blockNumber, block.Hash)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestIPCRPC_ContextCanceled(t *testing.T) {
+	path := newIPCServer(t, func(method string, params []any) (any, map[string]any) {
+		time.Sleep(time.Second)
+		return "0x1", nil
+	})
+
+	rpc, err := NewIPCRPC(path)
+	assert.NoError(t, err)
+	defer rpc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = rpc.Head(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestIPCRPC_ConnectionClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geth.ipc")
+	ln, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	// Drop the connection without answering
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	rpc, err := NewIPCRPC(path)
+	assert.NoError(t, err)
+	defer rpc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = rpc.Head(ctx)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = NewIPCRPC(filepath.Join(t.TempDir(), "missing.ipc"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// GetLogsByTx returns the logs emitted by the transaction txHash, read from its receipt with eth_getTransactionReceipt.
+// The HTTP and IPC clients implement TxReceiptsRPC.
+//
+// Example:
+//
+//	logs, err := rpc.GetLogsByTx(ctx, client, "0x5c50...")
+func GetLogsByTx(ctx context.Context, r TxReceiptsRPC, txHash string) ([]types.Log, error) {
+	receipt, err := r.GetTransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting receipt of transaction %s: %w", txHash, err)
+	}
+	return receipt.Logs, nil
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/errors"
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
+// Poll interval of WaitForBlock, doubled after every poll up to waitMaxBackoff
+var (
+	waitInitialBackoff = 100 * time.Millisecond
+	waitMaxBackoff     = 5 * time.Second
+)
+
+// WaitForBlock polls the head of r until it reaches target.
+// The poll interval backs off exponentially, retryable head errors are polled again.
+// maxWait bounds the total wait (0 waits until ctx is done).
+//
+// Example:
+//
+//	// Make sure the block is available before indexing it
+//	if err := rpc.WaitForBlock(ctx, client, 19_000_000, time.Minute); err != nil {
+//	    return err
+//	}
+func WaitForBlock(ctx context.Context, r RPC, target uint64, maxWait time.Duration) error {
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+
+	backoff := waitInitialBackoff
+	var head uint64
+	for {
+		headHex, err := r.Head(ctx)
+		if err == nil {
+			head, err = utils.HexQtyToUint64(headHex)
+			if err != nil {
+				return fmt.Errorf("error parsing head: %w", err)
+			}
+			if head >= target {
+				return nil
+			}
+		} else if ctx.Err() == nil && !errors.IsRetryableError(err) {
+			return fmt.Errorf("error fetching head: %w", err)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+			if backoff > waitMaxBackoff {
+				backoff = waitMaxBackoff
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for block %d, head is %d: %w", target, head, ctx.Err())
+		}
+	}
+}
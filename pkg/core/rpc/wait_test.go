@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/godex/pkg/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAdvancingHeadServer serves a head that grows by one block every 20ms from 0x10
+func newAdvancingHeadServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	start := time.Now()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		head := 0x10 + uint64(time.Since(start)/(20*time.Millisecond))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": utils.Uint64ToHexQty(head)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaitForBlock_HeadAdvances(t *testing.T) {
+	waitInitialBackoff = 10 * time.Millisecond
+	defer func() { waitInitialBackoff = 100 * time.Millisecond }()
+
+	var calls atomic.Int32
+	srv := newAdvancingHeadServer(t, &calls)
+	rpc := NewHTTPRPC(srv.URL, 0)
+
+	err := WaitForBlock(context.Background(), rpc, 0x15, 2*time.Second)
+	assert.NoError(t, err)
+	assert.Greater(t, int(calls.Load()), 1)
+
+	head, err := rpc.Head(context.Background())
+	assert.NoError(t, err)
+	n, err := utils.HexQtyToUint64(head)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, n, uint64(0x15))
+
+	// Already reached, no wait
+	calls.Store(0)
+	assert.NoError(t, WaitForBlock(context.Background(), rpc, 0x10, time.Second))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWaitForBlock_MaxWait(t *testing.T) {
+	var calls atomic.Int32
+	srv := newAdvancingHeadServer(t, &calls)
+	rpc := NewHTTPRPC(srv.URL, 0)
+
+	start := time.Now()
+	err := WaitForBlock(context.Background(), rpc, 0xffff, 100*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Canceling the context stops the wait as well
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WaitForBlock(ctx, rpc, 0xffff, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWaitForBlock_NonRetryableError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	err := WaitForBlock(context.Background(), NewHTTPRPC(srv.URL, 0), 0x10, time.Second)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// BatchWriter buffers the events of a chain and stores them in a sink by batches of batchSize.
+// Its checkpoint only advances once a batch is stored, so it never gets ahead of the sink.
+type BatchWriter struct {
+	sink      Sink
+	chainId   string
+	batchSize int
+	buffer    []types.Event
+	// checkpoint is the highest block of the stored events, valid once hasCheckpoint is set
+	checkpoint    atomic.Uint64
+	hasCheckpoint atomic.Bool
+}
+
+// NewBatchWriter returns a writer storing the events of chainId in s by batches of batchSize,
+// a batchSize of 0 or less stores every event on its own.
+func NewBatchWriter(s Sink, chainId string, batchSize int) *BatchWriter {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &BatchWriter{
+		sink:      s,
+		chainId:   chainId,
+		batchSize: batchSize,
+	}
+}
+
+// Write buffers event and stores the buffer once it holds a full batch
+func (w *BatchWriter) Write(ctx context.Context, event types.Event) error {
+	w.buffer = append(w.buffer, event)
+	if len(w.buffer) < w.batchSize {
+		return nil
+	}
+	return w.Flush(ctx)
+}
+
+// Flush stores the buffered events, even a partial batch.
+// On error the events stay buffered and the checkpoint is unchanged, so Flush can be called again.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	if err := w.sink.Store(ctx, w.chainId, w.buffer); err != nil {
+		return fmt.Errorf("error storing %d events of chain %s: %w", len(w.buffer), w.chainId, err)
+	}
+
+	for _, event := range w.buffer {
+		if !w.hasCheckpoint.Load() || event.BlockNumber > w.checkpoint.Load() {
+			w.checkpoint.Store(event.BlockNumber)
+			w.hasCheckpoint.Store(true)
+		}
+	}
+	// The sink may keep the stored slice, start a new one
+	w.buffer = nil
+	return nil
+}
+
+// Checkpoint returns the highest block of the stored events, ok is false until a batch is stored.
+// It is safe to call while Run is running.
+func (w *BatchWriter) Checkpoint() (block uint64, ok bool) {
+	return w.checkpoint.Load(), w.hasCheckpoint.Load()
+}
+
+// Run stores the events read from events until it is closed or ctx is done.
+// In both cases the partial batch is flushed before returning, so it isn't lost on shutdown:
+// when ctx is done, the events already in the channel are read without blocking,
+// then flushed with a context that is not canceled.
+func (w *BatchWriter) Run(ctx context.Context, events <-chan *types.Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event, ok := <-events:
+					if ok {
+						w.buffer = append(w.buffer, *event)
+						continue
+					}
+				default:
+				}
+				return w.Flush(context.WithoutCancel(ctx))
+			}
+		case event, ok := <-events:
+			if !ok {
+				return w.Flush(ctx)
+			}
+			if err := w.Write(ctx, *event); err != nil {
+				return err
+			}
+		}
+	}
+}
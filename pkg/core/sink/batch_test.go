@@ -0,0 +1,87 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingSink counts the Store calls and fails them while failing is set
+type countingSink struct {
+	*MemorySink
+	stores  int
+	failing bool
+}
+
+func (s *countingSink) Store(ctx context.Context, chainId string, events []types.Event) error {
+	if s.failing {
+		return fmt.Errorf("sink unavailable")
+	}
+	s.stores++
+	return s.MemorySink.Store(ctx, chainId, events)
+}
+
+func TestBatchWriter_FlushesPartialBatchOnShutdown(t *testing.T) {
+	s := &countingSink{MemorySink: NewMemorySink()}
+	w := NewBatchWriter(s, "1", 10)
+
+	events := make(chan *types.Event, 10)
+	for b := uint64(1); b <= 3; b++ {
+		events <- &types.Event{BlockNumber: b}
+	}
+
+	// Stopped before the batch of 10 is full
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, w.Run(ctx, events))
+
+	assert.Len(t, s.Events("1"), 3)
+	assert.Equal(t, 1, s.stores)
+
+	checkpoint, ok := w.Checkpoint()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), checkpoint)
+	last, _, err := s.GetLastBlock(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, last, checkpoint)
+}
+
+func TestBatchWriter_StoresFullBatches(t *testing.T) {
+	s := &countingSink{MemorySink: NewMemorySink()}
+	w := NewBatchWriter(s, "1", 2)
+
+	events := make(chan *types.Event, 5)
+	for b := uint64(1); b <= 5; b++ {
+		events <- &types.Event{BlockNumber: b}
+	}
+	close(events)
+	assert.NoError(t, w.Run(context.Background(), events))
+
+	// 2 full batches then the partial one once the channel is closed
+	assert.Equal(t, 3, s.stores)
+	assert.Len(t, s.Events("1"), 5)
+	checkpoint, _ := w.Checkpoint()
+	assert.Equal(t, uint64(5), checkpoint)
+}
+
+func TestBatchWriter_CheckpointOnlyAdvancesOnStore(t *testing.T) {
+	s := &countingSink{MemorySink: NewMemorySink(), failing: true}
+	w := NewBatchWriter(s, "1", 2)
+	ctx := context.Background()
+
+	assert.NoError(t, w.Write(ctx, types.Event{BlockNumber: 1}))
+	assert.Error(t, w.Write(ctx, types.Event{BlockNumber: 2}))
+	_, ok := w.Checkpoint()
+	assert.False(t, ok)
+
+	// The failed batch is still buffered
+	s.failing = false
+	assert.NoError(t, w.Flush(ctx))
+	assert.Len(t, s.Events("1"), 2)
+	checkpoint, ok := w.Checkpoint()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), checkpoint)
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ryuux05/godex/pkg/core/types"
+)
+
+// Sink persists the decoded events of the chains.
+// Implementations must be safe for concurrent use.
+//
+// Store must be idempotent on the EventKey of the events: the same log is emitted again
+// when a reorg overlap or a tip re-scan replays blocks, and must not be stored twice.
+// A database sink upserts on the key, e.g. with a unique index and ON CONFLICT DO UPDATE.
+type Sink interface {
+	// Store persists the events of a chain, replacing the stored events with the same EventKey
+	Store(ctx context.Context, chainId string, events []types.Event) error
+	// GetLastBlock returns the highest block stored for chainId,
+	// ok is false when nothing was stored for the chain yet.
+	GetLastBlock(ctx context.Context, chainId string) (block uint64, ok bool, err error)
+}
+
+// EventKey identifies a log across replays, the unique key of an idempotent Sink
+type EventKey struct {
+	ChainId         string
+	BlockNumber     uint64
+	TransactionHash string
+	LogIndex        uint64
+}
+
+// KeyOf returns the EventKey of event stored for chainId
+func KeyOf(chainId string, event types.Event) EventKey {
+	return EventKey{
+		ChainId:         chainId,
+		BlockNumber:     event.BlockNumber,
+		TransactionHash: event.TransactionHash,
+		LogIndex:        event.LogIndex,
+	}
+}
+
+// MemorySink keeps the events in memory, useful for tests and short lived indexers
+type MemorySink struct {
+	// stored events with chainId as key
+	events map[string][]types.Event
+	// position of the stored events in events
+	positions map[EventKey]int
+	// highest stored block with chainId as key
+	lastBlock map[string]uint64
+	mu sync.RWMutex
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{
+		events:    make(map[string][]types.Event),
+		positions: make(map[EventKey]int),
+		lastBlock: make(map[string]uint64),
+	}
+}
+
+func (s *MemorySink) Store(ctx context.Context, chainId string, events []types.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		// A replayed event replaces the stored one
+		key := KeyOf(chainId, event)
+		if i, ok := s.positions[key]; ok {
+			s.events[chainId][i] = event
+			continue
+		}
+		s.positions[key] = len(s.events[chainId])
+		s.events[chainId] = append(s.events[chainId], event)
+		if last, ok := s.lastBlock[chainId]; !ok || event.BlockNumber > last {
+			s.lastBlock[chainId] = event.BlockNumber
+		}
+	}
+	return nil
+}
+
+func (s *MemorySink) GetLastBlock(ctx context.Context, chainId string) (uint64, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last, ok := s.lastBlock[chainId]
+	return last, ok, nil
+}
+
+// Events returns a copy of the events stored for chainId
+func (s *MemorySink) Events(chainId string) []types.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]types.Event(nil), s.events[chainId]...)
+}
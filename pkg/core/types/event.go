@@ -29,6 +29,11 @@ type Event struct {
 	EventType string `json:"EventType"`
 	// The output of the log
 	Fields EventFields
+	// The log the event was decoded from.
+	// Gives access to the fields dropped by decoding such as TransactionIndex, Removed and raw Data
+	RawLog *Log `json:"rawLog,omitempty"`
+	// The id of the chain the event was indexed from, only set when Options.TagChainId is enabled
+	ChainId string `json:"chainId,omitempty"`
 }
 
 type EventFields map[string]interface{}
\ No newline at end of file
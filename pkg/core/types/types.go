@@ -1,16 +1,55 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ryuux05/godex/pkg/core/utils"
+)
+
 const ZeroAddress Address = "0x0000000000000000000000000000000000000000"
 
 type Block struct {
 	// Current block number
-	Number string
+	Number string `json:"number"`
 	// The hash of the block
-	Hash string
+	Hash string `json:"hash"`
 	// The previous block hash
-	ParentHash string
+	ParentHash string `json:"parentHash"`
 	// The time the block is created
-	Timestamp string
+	Timestamp string `json:"timestamp"`
+}
+
+// Transaction is a transaction as listed by a block fetched with its transactions
+type Transaction struct {
+	// The hash of the transaction
+	Hash string `json:"hash"`
+	// An index of the transaction in the block
+	TransactionIndex string `json:"transactionIndex"`
+	// The address of the sender
+	From string `json:"from"`
+	// The address of the receiver. null when it's a contract creation transaction
+	To string `json:"to"`
+	// The value type
+	Type string `json:"type"`
+}
+
+// BlockWithTxs is a block fetched with its full transactions
+type BlockWithTxs struct {
+	Block
+	// The transactions of the block, in block order
+	Transactions []Transaction `json:"transactions"`
+}
+
+// CallMsg is a read-only contract call sent with eth_call
+type CallMsg struct {
+	// The address the call is sent from, optional
+	From string `json:"from,omitempty"`
+	// The address of the contract
+	To string `json:"to"`
+	// The hash of the method signature and encoded parameters, e.g. "0x313ce567" for decimals()
+	Data string `json:"data,omitempty"`
 }
 
 type Address string
@@ -33,8 +72,14 @@ type Log struct {
 	BlockHash string `json:"blockHash,omitempty"`
 	// The integer of the log index position in the block. null when it's a pending log
 	LogIndex string `json:"logIndex,omitempty"`
-	// The integer of the log index position in the block. null when it's a pending log
+	// True when the log was removed due to a chain reorganization, false if it's a valid log
 	Removed bool `json:"removed,omitempty"`
+	// The type of the transaction that emitted this log.
+	// Only set in receipts mode when Options.TagTxType is enabled, nil otherwise
+	TxType *uint8 `json:"txType,omitempty"`
+	// The id of the chain the log was indexed from.
+	// Only set when Options.TagChainId is enabled, empty otherwise
+	ChainId string `json:"chainId,omitempty"`
 }
 
 type Receipt struct {
@@ -67,6 +112,32 @@ type Receipt struct {
 	TransactionIndex string `json:"transactionIndex"`
 	// The value type
 	Type string `json:"type"`
+	// The blob gas used by a blob transaction (type 0x3, EIP-4844). nil for the other transactions
+	BlobGasUsed *string `json:"blobGasUsed,omitempty"`
+	// The price per blob gas paid by a blob transaction (type 0x3, EIP-4844). nil for the other transactions
+	BlobGasPrice *string `json:"blobGasPrice,omitempty"`
+}
+
+// Transaction types as defined by EIP-2718
+const (
+	TxTypeLegacy     uint8 = 0x0
+	TxTypeAccessList uint8 = 0x1 // EIP-2930
+	TxTypeDynamicFee uint8 = 0x2 // EIP-1559
+	TxTypeBlob       uint8 = 0x3 // EIP-4844
+)
+
+// TxType returns the transaction type of the receipt.
+// Receipts from pre-Berlin nodes don't have a type, they are treated as legacy.
+// Unknown future types are returned as is so callers can decide how to handle them.
+func (r Receipt) TxType() uint8 {
+	if r.Type == "" {
+		return TxTypeLegacy
+	}
+	v, err := utils.HexQtyToUint64(r.Type)
+	if err != nil || v > 0xff {
+		return TxTypeLegacy
+	}
+	return uint8(v)
 }
 
 type Filter struct {
@@ -82,10 +153,70 @@ type Filter struct {
 	// - Keccal256 hashes like "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
 	// - Keccak256 hashes only like "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
 	Topics []string `json:"topics,omitempty"`   // positional; omit if unused
+	// Topic0 matches the logs whose first topic is any of these hashes, the OR-filter of eth_getLogs.
+	// When set it is sent as the first topic position and Topics fill the positions after it.
+	Topic0 []string `json:"-"`
+	// IndexedTopics fill the topic positions after Topic0, IndexedTopics[i] lists the accepted values of topic i+1.
+	// An empty position matches any value.
+	IndexedTopics [][]string `json:"-"`
 	// Using the blockHash field is equivalent to setting the fromBlock and toBlock to the block number the blockHash references. If blockHash is present in the filter criteria, neither fromBlock nor toBlock is allowed
 	BlockHash string `json:"blockHash,omitempty"`
 }
 
+// MarshalJSON encodes Topic0, when set, as an array in the first topic position,
+// followed by Topics and IndexedTopics
+func (f Filter) MarshalJSON() ([]byte, error) {
+	type plain Filter
+	if len(f.Topic0) == 0 && len(f.IndexedTopics) == 0 {
+		return json.Marshal(plain(f))
+	}
+
+	topics := make([]any, 0, 1+len(f.Topics)+len(f.IndexedTopics))
+	if len(f.Topic0) > 0 {
+		topics = append(topics, f.Topic0)
+	} else if len(f.Topics) == 0 {
+		// Any event signature
+		topics = append(topics, nil)
+	}
+	for _, topic := range f.Topics {
+		topics = append(topics, topic)
+	}
+	for _, position := range f.IndexedTopics {
+		if len(position) == 0 {
+			topics = append(topics, nil)
+		} else {
+			topics = append(topics, position)
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Topics []any `json:"topics"`
+	}{plain(f), topics})
+}
+
+// Validate checks the filter before it is sent: BlockHash excludes FromBlock and ToBlock,
+// and FromBlock can't be after ToBlock when both are hex quantities rather than tags such as "latest"
+func (f Filter) Validate() error {
+	if f.BlockHash != "" && (f.FromBlock != "" || f.ToBlock != "") {
+		return fmt.Errorf("invalid filter: blockHash %s can't be combined with fromBlock %q or toBlock %q", f.BlockHash, f.FromBlock, f.ToBlock)
+	}
+	if !strings.HasPrefix(f.FromBlock, "0x") || !strings.HasPrefix(f.ToBlock, "0x") {
+		return nil
+	}
+	from, err := utils.HexQtyToUint64(f.FromBlock)
+	if err != nil {
+		return fmt.Errorf("invalid filter: fromBlock %q: %w", f.FromBlock, err)
+	}
+	to, err := utils.HexQtyToUint64(f.ToBlock)
+	if err != nil {
+		return fmt.Errorf("invalid filter: toBlock %q: %w", f.ToBlock, err)
+	}
+	if from > to {
+		return fmt.Errorf("invalid filter: fromBlock %d is after toBlock %d", from, to)
+	}
+	return nil
+}
+
 type Cursor struct {
 
 }
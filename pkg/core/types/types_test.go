@@ -0,0 +1,187 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiptTxType(t *testing.T) {
+	tests := []struct {
+		typ      string
+		expected uint8
+	}{
+		{"0x0", TxTypeLegacy},
+		{"0x1", TxTypeAccessList},
+		{"0x2", TxTypeDynamicFee},
+		{"0x3", TxTypeBlob},
+		{"", TxTypeLegacy}, // pre-Berlin receipts have no type
+		{"0x7e", 0x7e},     // unknown types are returned as is
+		{"invalid", TxTypeLegacy},
+	}
+
+	for _, tt := range tests {
+		receipt := Receipt{Type: tt.typ}
+		assert.Equal(t, tt.expected, receipt.TxType(), tt.typ)
+	}
+}
+
+func TestFilterMarshalJSON_Topic0(t *testing.T) {
+	b, err := json.Marshal(Filter{FromBlock: "0x1", ToBlock: "0x2", Topics: []string{"0xaa"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fromBlock":"0x1","toBlock":"0x2","topics":["0xaa"]}`, string(b))
+
+	b, err = json.Marshal(Filter{FromBlock: "0x1", ToBlock: "0x2", Topic0: []string{"0xaa", "0xbb"}, Topics: []string{"0xcc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fromBlock":"0x1","toBlock":"0x2","topics":[["0xaa","0xbb"],"0xcc"]}`, string(b))
+}
+
+func TestFilterMarshalJSON_IndexedTopics(t *testing.T) {
+	b, err := json.Marshal(Filter{FromBlock: "0x1", ToBlock: "0x2", Topics: []string{"0xaa"}, IndexedTopics: [][]string{{"0xbb", "0xcc"}, nil, {"0xdd"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fromBlock":"0x1","toBlock":"0x2","topics":["0xaa",["0xbb","0xcc"],null,["0xdd"]]}`, string(b))
+
+	// Any event signature
+	b, err = json.Marshal(Filter{FromBlock: "0x1", ToBlock: "0x2", IndexedTopics: [][]string{{"0xbb"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fromBlock":"0x1","toBlock":"0x2","topics":[null,["0xbb"]]}`, string(b))
+}
+
+// Payloads as sent by geth, lowercase keys and hex quantities
+
+const gethLog = `{
+	"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+	"topics": [
+		"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		"0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60",
+		"0x000000000000000000000000a9d1e08c7793af67e9d92fe308d5697fb81d3e43"
+	],
+	"data": "0x00000000000000000000000000000000000000000000000000000002540be400",
+	"blockNumber": "0x12a05f2",
+	"transactionHash": "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060",
+	"transactionIndex": "0x4b",
+	"blockHash": "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+	"logIndex": "0x10f",
+	"removed": false
+}`
+
+const gethBlock = `{
+	"baseFeePerGas": "0x3b9aca00",
+	"difficulty": "0x0",
+	"extraData": "0x6265617665726275696c642e6f7267",
+	"gasLimit": "0x1c9c380",
+	"gasUsed": "0xe4e1c0",
+	"hash": "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+	"logsBloom": "0x00",
+	"miner": "0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5",
+	"mixHash": "0x4ca9a5c6b9ab1f4fdb2d8d1c5f4b5d2e9b4e1d9a5e6c3f8d7b6a5c4e3d2f1a0b",
+	"nonce": "0x0000000000000000",
+	"number": "0x12a05f2",
+	"parentHash": "0xa1f7d3c7b4b3d9d7a0e0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2",
+	"receiptsRoot": "0x2a0b8d6f4e3c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a",
+	"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+	"size": "0x2a1f",
+	"stateRoot": "0x3b1c9e8d7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c",
+	"timestamp": "0x65f1b2c3",
+	"totalDifficulty": "0xc70d815d562d3cfa955",
+	"transactions": ["0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"],
+	"transactionsRoot": "0x4c2d0f9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d",
+	"uncles": [],
+	"withdrawals": [],
+	"withdrawalsRoot": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"
+}`
+
+const gethReceipt = `{
+	"blockHash": "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+	"blockNumber": "0x12a05f2",
+	"contractAddress": null,
+	"cumulativeGasUsed": "0xb1c2d3",
+	"effectiveGasPrice": "0x4a817c800",
+	"from": "0x28c6c06298d514db089934071355e5743bf21d60",
+	"gasUsed": "0xfde8",
+	"logs": [` + gethLog + `],
+	"logsBloom": "0x00",
+	"status": "0x1",
+	"to": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+	"transactionHash": "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060",
+	"transactionIndex": "0x4b",
+	"type": "0x2"
+}`
+
+func TestLog_GethPayload(t *testing.T) {
+	var log Log
+	assert.NoError(t, json.Unmarshal([]byte(gethLog), &log))
+
+	assert.Equal(t, Log{
+		Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60",
+			"0x000000000000000000000000a9d1e08c7793af67e9d92fe308d5697fb81d3e43",
+		},
+		Data:             "0x00000000000000000000000000000000000000000000000000000002540be400",
+		BlockNumber:      "0x12a05f2",
+		TransactionHash:  "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060",
+		TransactionIndex: "0x4b",
+		BlockHash:        "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+		LogIndex:         "0x10f",
+	}, log)
+}
+
+func TestBlock_GethPayload(t *testing.T) {
+	var block Block
+	assert.NoError(t, json.Unmarshal([]byte(gethBlock), &block))
+
+	expected := Block{
+		Number:     "0x12a05f2",
+		Hash:       "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+		ParentHash: "0xa1f7d3c7b4b3d9d7a0e0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2",
+		Timestamp:  "0x65f1b2c3",
+	}
+	assert.Equal(t, expected, block)
+
+	// Encoded with the node keys, e.g. when a block is stored or forwarded
+	b, err := json.Marshal(block)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"number":"0x12a05f2","hash":"0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e","parentHash":"0xa1f7d3c7b4b3d9d7a0e0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2","timestamp":"0x65f1b2c3"}`, string(b))
+}
+
+func TestReceipt_GethPayload(t *testing.T) {
+	var receipt Receipt
+	assert.NoError(t, json.Unmarshal([]byte(gethReceipt), &receipt))
+
+	assert.Equal(t, "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e", receipt.BlockHash)
+	assert.Equal(t, "0x12a05f2", receipt.BlockNumber)
+	assert.Nil(t, receipt.ContractAddress)
+	assert.Equal(t, "0xb1c2d3", receipt.CumulativeGasUsed)
+	assert.Equal(t, "0x4a817c800", receipt.EffectiveGasPrice)
+	assert.Equal(t, "0x28c6c06298d514db089934071355e5743bf21d60", receipt.From)
+	assert.Equal(t, "0xfde8", receipt.GasUsed)
+	assert.Equal(t, "0x00", receipt.LogsBloom)
+	assert.Equal(t, "0x1", receipt.Status)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", receipt.To)
+	assert.Equal(t, "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060", receipt.TransactionHash)
+	assert.Equal(t, "0x4b", receipt.TransactionIndex)
+	assert.Equal(t, TxTypeDynamicFee, receipt.TxType())
+	if assert.Len(t, receipt.Logs, 1) {
+		assert.Equal(t, "0x10f", receipt.Logs[0].LogIndex)
+		assert.Len(t, receipt.Logs[0].Topics, 3)
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	assert.NoError(t, Filter{FromBlock: "0x1", ToBlock: "0x2"}.Validate())
+	assert.NoError(t, Filter{FromBlock: "0x2", ToBlock: "0x2"}.Validate())
+	assert.NoError(t, Filter{BlockHash: "0xbh"}.Validate())
+	// Tags aren't compared
+	assert.NoError(t, Filter{FromBlock: "0x10", ToBlock: "latest"}.Validate())
+
+	// BlockHash excludes the range
+	assert.ErrorContains(t, Filter{BlockHash: "0xbh", FromBlock: "0x1"}.Validate(), "blockHash")
+	assert.ErrorContains(t, Filter{BlockHash: "0xbh", ToBlock: "latest"}.Validate(), "blockHash")
+
+	// Inverted range
+	assert.ErrorContains(t, Filter{FromBlock: "0x10", ToBlock: "0xf"}.Validate(), "fromBlock 16 is after toBlock 15")
+	assert.Error(t, Filter{FromBlock: "0xzz", ToBlock: "0x1"}.Validate())
+}
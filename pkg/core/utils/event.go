@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EventNamer lets an event struct use an event name other than its type name
+type EventNamer interface {
+	EventName() string
+}
+
+// EventSignature derives the event signature from a struct whose fields are tagged with their ABI type.
+// The event name is the struct type name unless the struct implements EventNamer.
+// Fields without abi tag, or tagged "-", are not part of the event.
+// Example:
+//
+//	type Transfer struct {
+//	    From  string   `abi:"address,indexed"`
+//	    To    string   `abi:"address,indexed"`
+//	    Value *big.Int `abi:"uint256"`
+//	}
+//	EventSignature(Transfer{}) -> "Transfer(address,address,uint256)"
+func EventSignature(event any) (string, error) {
+	t := reflect.TypeOf(event)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("event must be a struct, got %T", event)
+	}
+
+	var paramTypes []string
+	indexed := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("abi")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		typ, options, _ := strings.Cut(tag, ",")
+		typ, err := canonicalABIType(strings.TrimSpace(typ))
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if options != "" {
+			if strings.TrimSpace(options) != "indexed" {
+				return "", fmt.Errorf("field %s: unknown abi tag option %q", field.Name, options)
+			}
+			indexed++
+		}
+		paramTypes = append(paramTypes, typ)
+	}
+
+	// topic0 takes one of the 4 topics
+	if indexed > 3 {
+		return "", fmt.Errorf("%d indexed fields, at most 3 are allowed", indexed)
+	}
+
+	name := t.Name()
+	if namer, ok := event.(EventNamer); ok {
+		name = namer.EventName()
+	}
+	if !isIdentifier(name) {
+		return "", fmt.Errorf("invalid event name %q", name)
+	}
+
+	return name + "(" + strings.Join(paramTypes, ",") + ")", nil
+}
+
+// EventTopic returns the topic0 of an event struct, see EventSignature for the tags.
+func EventTopic(event any) (string, error) {
+	signature, err := EventSignature(event)
+	if err != nil {
+		return "", err
+	}
+	return FunctionSignatureToTopic(signature), nil
+}
+
+// CanonicalType returns the form of an ABI type hashed in event signatures, e.g. uint -> uint256
+// and int -> int256, in arrays and tuples too. A type it doesn't know, e.g. fixed128x18, is returned as is.
+func CanonicalType(typ string) string {
+	if strings.HasPrefix(typ, "(") {
+		end := strings.LastIndex(typ, ")")
+		if end < 0 {
+			return typ
+		}
+		_, components, err := ParseSignature("tuple" + typ[:end+1])
+		if err != nil {
+			return typ
+		}
+		for i, component := range components {
+			components[i] = CanonicalType(component)
+		}
+		return "(" + strings.Join(components, ",") + ")" + typ[end+1:]
+	}
+	if canonical, err := canonicalABIType(typ); err == nil {
+		return canonical
+	}
+	return typ
+}
+
+// canonicalABIType validates an elementary ABI type, arrays included,
+// and returns its canonical form used in signatures (uint -> uint256, int -> int256).
+func canonicalABIType(typ string) (string, error) {
+	// Peel the array suffixes, e.g. uint256[2][]
+	suffix := ""
+	for strings.HasSuffix(typ, "]") {
+		open := strings.LastIndex(typ, "[")
+		if open < 0 {
+			return "", fmt.Errorf("invalid abi type %q", typ)
+		}
+		if size := typ[open+1 : len(typ)-1]; size != "" {
+			if n, err := strconv.Atoi(size); err != nil || n <= 0 {
+				return "", fmt.Errorf("invalid array size in abi type %q", typ)
+			}
+		}
+		suffix = typ[open:] + suffix
+		typ = typ[:open]
+	}
+
+	switch {
+	case typ == "address", typ == "bool", typ == "string", typ == "bytes":
+	case typ == "uint", typ == "int":
+		typ += "256"
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+		if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return "", fmt.Errorf("invalid abi type %q", typ+suffix)
+		}
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil || size < 1 || size > 32 {
+			return "", fmt.Errorf("invalid abi type %q", typ+suffix)
+		}
+	default:
+		return "", fmt.Errorf("unsupported abi type %q", typ+suffix)
+	}
+
+	return typ + suffix, nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Transfer struct {
+	From  string   `abi:"address,indexed"`
+	To    string   `abi:"address,indexed"`
+	Value *big.Int `abi:"uint256"`
+	// Not part of the event
+	Note string
+}
+
+type batchTransfer struct {
+	Operator string     `abi:"address,indexed"`
+	From     string     `abi:"address,indexed"`
+	To       string     `abi:"address,indexed"`
+	Ids      []*big.Int `abi:"uint[]"`
+	Values   []*big.Int `abi:"uint256[]"`
+	Skipped  string     `abi:"-"`
+}
+
+func (batchTransfer) EventName() string { return "TransferBatch" }
+
+func TestEventTopic_Transfer(t *testing.T) {
+	signature, err := EventSignature(Transfer{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer(address,address,uint256)", signature)
+
+	topic, err := EventTopic(&Transfer{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", topic)
+}
+
+func TestEventSignature_NameAndCanonicalTypes(t *testing.T) {
+	signature, err := EventSignature(batchTransfer{})
+	assert.NoError(t, err)
+	assert.Equal(t, "TransferBatch(address,address,address,uint256[],uint256[])", signature)
+
+	topic, err := EventTopic(batchTransfer{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb", topic)
+}
+
+func TestCanonicalType(t *testing.T) {
+	assert.Equal(t, "uint256", CanonicalType("uint"))
+	assert.Equal(t, "int256[]", CanonicalType("int[]"))
+	assert.Equal(t, "uint256[2][]", CanonicalType("uint[2][]"))
+	assert.Equal(t, "(uint256,(address,int256))[]", CanonicalType("(uint,(address,int))[]"))
+	assert.Equal(t, "bytes32", CanonicalType("bytes32"))
+	// Unknown types are kept
+	assert.Equal(t, "fixed128x18", CanonicalType("fixed128x18"))
+}
+
+func TestEventSignature_InvalidTags(t *testing.T) {
+	cases := []struct {
+		event any
+		err   string
+	}{
+		{"Transfer", "must be a struct"},
+		{struct {
+			A string `abi:"adress"`
+		}{}, "unsupported abi type"},
+		{struct {
+			A uint64 `abi:"uint7"`
+		}{}, "invalid abi type"},
+		{struct {
+			A []byte `abi:"bytes33"`
+		}{}, "invalid abi type"},
+		{struct {
+			A []uint64 `abi:"uint64[0]"`
+		}{}, "invalid array size"},
+		{struct {
+			A string `abi:"address,indexd"`
+		}{}, "unknown abi tag option"},
+		{struct {
+			A string `abi:"address,indexed"`
+			B string `abi:"address,indexed"`
+			C string `abi:"address,indexed"`
+			D string `abi:"address,indexed"`
+		}{}, "4 indexed fields"},
+		// Valid tags but anonymous structs have no name
+		{struct {
+			A string `abi:"address"`
+		}{}, "invalid event name"},
+	}
+	for _, c := range cases {
+		_, err := EventSignature(c.event)
+		if assert.Error(t, err, c.err) {
+			assert.Contains(t, err.Error(), c.err)
+		}
+	}
+}
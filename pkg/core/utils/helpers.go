@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/hex"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -9,6 +10,10 @@ import (
 )
 
 // Helpers (hex quantity <-> uint64)
+//
+// The canonical form of a hex quantity follows the JSON-RPC spec:
+// lowercase, "0x" prefixed, without leading zeros, and zero encoded as "0x0".
+// Uint64ToHexQty always returns the canonical form.
 func Uint64ToHexQty(n uint64) string {
 	if n == 0 {
 		return "0x0"
@@ -16,8 +21,15 @@ func Uint64ToHexQty(n uint64) string {
 	return "0x" + strconv.FormatUint(n, 16)
 }
 
+// HexQtyToUint64 parses a hex quantity into uint64.
+// It is lenient on input: "0X" prefix, leading zeros and mixed case digits are accepted,
+// and the empty quantity "0x" is treated as zero since some providers return it for empty values.
+// Strings without prefix are parsed as decimal.
 func HexQtyToUint64(s string) (uint64, error) {
 	if len(s) >= 2 && (s[0:2] == "0x" || s[0:2] == "0X") {
+		if len(s) == 2 {
+			return 0, nil
+		}
 		return strconv.ParseUint(s[2:], 16, 64)
 	}
 	return strconv.ParseUint(s, 10, 64)
@@ -42,6 +54,79 @@ func FunctionSignatureToTopic(signature string) string {
 	return "0x" + hex.EncodeToString(hash)
 }
 
+// ParseSignature splits a signature into its name and ordered parameter types.
+// Nested tuples are kept as a single parameter and arrays keep their suffix.
+// Example: "Event((address,uint256)[],bytes)" -> "Event", ["(address,uint256)[]", "bytes"]
+func ParseSignature(sig string) (string, []string, error) {
+	sig = strings.ReplaceAll(sig, " ", "")
+
+	open := strings.Index(sig, "(")
+	if open < 0 {
+		return "", nil, fmt.Errorf("malformed signature %q: missing parameter list", sig)
+	}
+	name := sig[:open]
+	if !isIdentifier(name) {
+		return "", nil, fmt.Errorf("malformed signature %q: invalid name", sig)
+	}
+	if !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("malformed signature %q: missing closing parenthesis", sig)
+	}
+
+	params := sig[open+1 : len(sig)-1]
+	if params == "" {
+		return name, []string{}, nil
+	}
+
+	// Split on the top level commas only, tracking the tuple depth
+	var paramTypes []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(params); i++ {
+		switch params[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return "", nil, fmt.Errorf("malformed signature %q: unbalanced parenthesis", sig)
+			}
+		case ',':
+			if depth == 0 {
+				paramTypes = append(paramTypes, params[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return "", nil, fmt.Errorf("malformed signature %q: unbalanced parenthesis", sig)
+	}
+	paramTypes = append(paramTypes, params[start:])
+
+	for _, paramType := range paramTypes {
+		if paramType == "" {
+			return "", nil, fmt.Errorf("malformed signature %q: empty parameter type", sig)
+		}
+	}
+
+	return name, paramTypes, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_' || c == '$':
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func ConvertToTopics(signatures []string) []string {
 	topics := make([]string, len(signatures))
 	for i, signature := range signatures {
@@ -59,3 +144,22 @@ func ConvertToTopics(signatures []string) []string {
 	
 	return topics
 }
+
+// BloomContains reports whether value (an address or a topic, as bytes) may be in a 2048 bits logs bloom.
+// It follows the yellow paper: the bits set for a value are the low 11 bits of the first 3 byte pairs of its Keccak256.
+// False means the value is provably absent. A malformed or empty bloom returns true since it can't exclude anything.
+func BloomContains(bloom string, value []byte) bool {
+	b, err := hex.DecodeString(strings.TrimPrefix(bloom, "0x"))
+	if err != nil || len(b) != 256 {
+		return true
+	}
+
+	hash := Keccak256(value)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+		if b[255-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint64ToHexQty(t *testing.T) {
+	assert.Equal(t, "0x0", Uint64ToHexQty(0))
+	assert.Equal(t, "0x1", Uint64ToHexQty(1))
+	assert.Equal(t, "0x3e8", Uint64ToHexQty(1000))
+	assert.Equal(t, "0xffffffffffffffff", Uint64ToHexQty(math.MaxUint64))
+}
+
+func TestHexQtyToUint64_EdgeCases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	}{
+		{"0x0", 0},
+		{"0x", 0},
+		{"0x00", 0},
+		{"0x0001", 1},
+		{"0X3E8", 1000},
+		{"0x3E8", 1000},
+		{"0xAbC", 2748},
+		{"0xffffffffffffffff", math.MaxUint64},
+		{"1000", 1000},
+	}
+
+	for _, tt := range tests {
+		got, err := HexQtyToUint64(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, got, tt.input)
+	}
+}
+
+func TestHexQtyToUint64_Invalid(t *testing.T) {
+	for _, input := range []string{"", "0xg", "0x10000000000000000", "-1", "0x-1"} {
+		_, err := HexQtyToUint64(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func FuzzHexQtyRoundTrip(f *testing.F) {
+	for _, seed := range []uint64{0, 1, 15, 16, 1000, math.MaxUint32, math.MaxUint64} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, n uint64) {
+		hexQty := Uint64ToHexQty(n)
+
+		// Canonical form: lowercase, prefixed and no leading zeros
+		if hexQty != strings.ToLower(hexQty) || !strings.HasPrefix(hexQty, "0x") {
+			t.Fatalf("non canonical hex quantity %q", hexQty)
+		}
+		if n != 0 && strings.HasPrefix(hexQty, "0x0") {
+			t.Fatalf("leading zero in hex quantity %q", hexQty)
+		}
+
+		got, err := HexQtyToUint64(hexQty)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", hexQty, err)
+		}
+		if got != n {
+			t.Fatalf("round trip mismatch: %d -> %q -> %d", n, hexQty, got)
+		}
+
+		// Uppercase form must parse to the same value
+		got, err = HexQtyToUint64(strings.ToUpper(hexQty))
+		if err != nil || got != n {
+			t.Fatalf("uppercase round trip mismatch: %d -> %q -> %d (%v)", n, strings.ToUpper(hexQty), got, err)
+		}
+	})
+}
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		sig        string
+		name       string
+		paramTypes []string
+	}{
+		{"Transfer(address,address,uint256)", "Transfer", []string{"address", "address", "uint256"}},
+		{"Transfer(address, address, uint256)", "Transfer", []string{"address", "address", "uint256"}},
+		{"Paused()", "Paused", []string{}},
+		{"Event((address,uint256),bytes)", "Event", []string{"(address,uint256)", "bytes"}},
+		{"Nested((address,(uint8,bool)),string)", "Nested", []string{"(address,(uint8,bool))", "string"}},
+		{"TransferBatch(address,address,address,uint256[],uint256[])", "TransferBatch", []string{"address", "address", "address", "uint256[]", "uint256[]"}},
+		{"Orders((address,uint256)[],bytes32[2])", "Orders", []string{"(address,uint256)[]", "bytes32[2]"}},
+	}
+
+	for _, tt := range tests {
+		name, paramTypes, err := ParseSignature(tt.sig)
+		assert.NoError(t, err, tt.sig)
+		assert.Equal(t, tt.name, name, tt.sig)
+		assert.Equal(t, tt.paramTypes, paramTypes, tt.sig)
+	}
+}
+
+func TestParseSignature_Malformed(t *testing.T) {
+	for _, sig := range []string{
+		"",
+		"Transfer",
+		"(address)",
+		"1Transfer(address)",
+		"Transfer(address",
+		"Transfer(address))",
+		"Transfer((address,uint256)",
+		"Transfer(address)(uint256)",
+		"Transfer(address,,uint256)",
+		"Transfer(address,)",
+	} {
+		_, _, err := ParseSignature(sig)
+		assert.Error(t, err, sig)
+	}
+}
+
+func TestBloomContains(t *testing.T) {
+	// Bloom of a single ERC20 Transfer log of the USDC contract
+	address, _ := hex.DecodeString("a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	topic, _ := hex.DecodeString("ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	bloom := make([]byte, 256)
+	for _, value := range [][]byte{address, topic} {
+		hash := Keccak256(value)
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+			bloom[255-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	bloomHex := "0x" + hex.EncodeToString(bloom)
+
+	assert.True(t, BloomContains(bloomHex, address))
+	assert.True(t, BloomContains(bloomHex, topic))
+
+	other, _ := hex.DecodeString("dac17f958d2ee523a2206206994597c13d831ec7")
+	assert.False(t, BloomContains(bloomHex, other))
+	assert.False(t, BloomContains("0x"+strings.Repeat("00", 256), address))
+
+	// Nothing can be excluded from a missing or malformed bloom
+	assert.True(t, BloomContains("", address))
+	assert.True(t, BloomContains("0x1234", address))
+}
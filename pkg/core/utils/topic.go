@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// AddressToTopic left pads a 20 bytes address to the 32 bytes topic of an indexed address argument.
+// The topic is lowercase like the topics returned by the nodes.
+// Example: "0x28C6c06298d514Db089934071355E5743bf21d60" -> "0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60"
+func AddressToTopic(addr string) string {
+	addr = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X"))
+	return "0x" + strings.Repeat("0", max(64-len(addr), 0)) + addr
+}
+
+// Uint256ToTopic encodes v as the 32 bytes topic of an indexed uint256 argument.
+// It returns an error when v is nil or not in [0, 2^256).
+// Example: 1 -> "0x0000000000000000000000000000000000000000000000000000000000000001"
+func Uint256ToTopic(v *big.Int) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("nil uint256")
+	}
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		return "", fmt.Errorf("%s out of uint256 range", v)
+	}
+	return fmt.Sprintf("0x%064x", v), nil
+}
+
+// TopicToAddress returns the address of an indexed address topic, its last 20 bytes, like the decoder
+func TopicToAddress(topic string) (string, error) {
+	word, err := topicWord(topic)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(word[12:]), nil
+}
+
+// TopicToUint256 returns the value of an indexed uint256 topic
+func TopicToUint256(topic string) (*big.Int, error) {
+	word, err := topicWord(topic)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(word), nil
+}
+
+// topicWord decodes a 0x prefixed 32 bytes hex topic
+func topicWord(topic string) ([]byte, error) {
+	if len(topic) != 66 || !strings.HasPrefix(topic, "0x") {
+		return nil, fmt.Errorf("invalid topic %q: expected 0x and 64 hex digits", topic)
+	}
+	word, err := hex.DecodeString(topic[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic %q: %w", topic, err)
+	}
+	return word, nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressToTopic(t *testing.T) {
+	// The indexed from of a USDC Transfer
+	const topic = "0x00000000000000000000000028c6c06298d514db089934071355e5743bf21d60"
+	assert.Equal(t, topic, AddressToTopic("0x28C6c06298d514Db089934071355E5743bf21d60"))
+	assert.Equal(t, topic, AddressToTopic("28c6c06298d514db089934071355e5743bf21d60"))
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", AddressToTopic("0x0000000000000000000000000000000000000000"))
+
+	address, err := TopicToAddress(topic)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x28c6c06298d514db089934071355e5743bf21d60", address)
+}
+
+func TestUint256ToTopic(t *testing.T) {
+	const topic = "0x00000000000000000000000000000000000000000000000000000002540be400"
+	encoded, err := Uint256ToTopic(big.NewInt(10_000_000_000))
+	assert.NoError(t, err)
+	assert.Equal(t, topic, encoded)
+	encoded, err = Uint256ToTopic(new(big.Int))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", encoded)
+
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	maxTopic, err := Uint256ToTopic(maxUint256)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", maxTopic)
+
+	v, err := TopicToUint256(topic)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10_000_000_000), v.Int64())
+
+	v, err = TopicToUint256(maxTopic)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, maxUint256.Cmp(v))
+}
+
+func TestUint256ToTopic_OutOfRange(t *testing.T) {
+	for _, v := range []*big.Int{
+		nil,
+		big.NewInt(-1),
+		new(big.Int).Lsh(big.NewInt(1), 256),
+	} {
+		topic, err := Uint256ToTopic(v)
+		assert.Error(t, err, "%v", v)
+		assert.Empty(t, topic)
+	}
+}
+
+func TestTopicToAddress_Invalid(t *testing.T) {
+	for _, topic := range []string{"", "0x1", "28c6c06298d514db089934071355e5743bf21d60", "0xzz0000000000000000000000000000000000000000000000000000000000000000"[:66]} {
+		_, err := TopicToAddress(topic)
+		assert.Error(t, err, topic)
+		_, err = TopicToUint256(topic)
+		assert.Error(t, err, topic)
+	}
+}